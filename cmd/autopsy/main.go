@@ -12,12 +12,21 @@ import (
 "time"
 
 autopsyapi "github.com/d9705996/autopsy/internal/api"
+"github.com/d9705996/autopsy/internal/ai"
 "github.com/d9705996/autopsy/internal/api/handler"
+"github.com/d9705996/autopsy/internal/auth"
+"github.com/d9705996/autopsy/internal/api/middleware"
 "github.com/d9705996/autopsy/internal/config"
 "github.com/d9705996/autopsy/internal/db"
+"github.com/d9705996/autopsy/internal/escalation"
+"github.com/d9705996/autopsy/internal/events"
 "github.com/d9705996/autopsy/internal/health"
+"github.com/d9705996/autopsy/internal/incident"
+"github.com/d9705996/autopsy/internal/notify"
 "github.com/d9705996/autopsy/internal/observability"
+"github.com/d9705996/autopsy/internal/oncall"
 "github.com/d9705996/autopsy/internal/seed"
+"github.com/d9705996/autopsy/internal/triage"
 "github.com/d9705996/autopsy/internal/version"
 "github.com/d9705996/autopsy/internal/worker"
 "github.com/prometheus/client_golang/prometheus/promhttp"
@@ -84,7 +93,28 @@ return fmt.Errorf("river migrations: %w", err)
 log.Info("river migrations applied")
 }
 
-wq, err := worker.New(ctx, pool, cfg.DB.Driver, cfg.Worker.Concurrency, log)
+aiProvider, err := ai.New(cfg.AI)
+if err != nil {
+return fmt.Errorf("ai provider: %w", err)
+}
+log.Info("ai provider ready", "provider", aiProvider.Name())
+triageSvc := triage.NewService(gormDB, aiProvider, cfg.AI)
+oncallSvc := oncall.NewService(gormDB)
+escalationSvc := escalation.NewService(gormDB, oncallSvc)
+incidentSvc := incident.NewService(gormDB)
+pageTokens := auth.NewPageTokenStore(gormDB, cfg.JWT.Secret)
+notifySvc := notify.NewService(gormDB, notify.New(cfg.Notify), &notify.Links{
+Tokens:  pageTokens,
+BaseURL: cfg.HTTP.PublicURL,
+TTL:     cfg.Notify.PageTokenTTL,
+CanAct:  handler.CanUsePageLinks,
+})
+
+wq, err := worker.New(ctx, gormDB, pool, cfg.DB.Driver, cfg.Worker.Concurrency, worker.Services{
+Triage:     triageSvc,
+Escalation: escalationSvc,
+Notify:     notifySvc,
+}, log)
 if err != nil {
 return fmt.Errorf("create worker: %w", err)
 }
@@ -99,12 +129,35 @@ log.Error("worker stop error", "err", err)
 }
 }()
 
+// --- Live update stream --------------------------------------------------
+// The broker stops with ctx, which also ends every open stream so the
+// server can shut down.
+broker := events.NewBroker(gormDB, pool, log)
+go broker.Run(ctx)
+
+// --- Token cleanup -------------------------------------------------------
+go auth.PruneTokens(ctx, gormDB, log)
+
 // --- HTTP routes ---------------------------------------------------------
 healthHandler := health.New(db.NewPinger(gormDB))
 authHandler := handler.NewAuthHandler(gormDB, cfg.JWT.Secret, cfg.JWT.AccessTTL, cfg.JWT.RefreshTTL)
 
 mux := http.NewServeMux()
-autopsyapi.RegisterRoutes(mux, healthHandler, authHandler, cfg.JWT.Secret)
+autopsyapi.RegisterRoutes(mux, autopsyapi.Handlers{
+Health:         healthHandler,
+Auth:           authHandler,
+Webhook:        handler.NewWebhookHandler(gormDB, cfg.Webhook.ReplayWindow, cfg.Webhook.MaxBodyBytes),
+WebhookSources: handler.NewWebhookSourceHandler(gormDB, triageSvc),
+Alerts:         handler.NewAlertHandler(gormDB),
+Silences:       handler.NewSilenceHandler(gormDB),
+Incidents:      handler.NewIncidentHandler(gormDB, incidentSvc),
+Components:     handler.NewComponentHandler(gormDB),
+Schedules:      handler.NewScheduleHandler(gormDB, oncallSvc),
+Escalation:     handler.NewEscalationHandler(gormDB, escalationSvc),
+Users:          handler.NewUserHandler(gormDB),
+PageActions:    handler.NewPageActionHandler(gormDB, pageTokens, escalationSvc, incidentSvc),
+Stream:         handler.NewStreamHandler(gormDB, broker),
+}, cfg.JWT.Secret)
 // Prometheus metrics endpoint
 mux.Handle("GET /metrics", promhttp.Handler())
 
@@ -113,7 +166,7 @@ registerSPA(mux, log)
 
 srv := &http.Server{
 Addr:         fmt.Sprintf(":%d", cfg.HTTP.Port),
-Handler:      mux,
+Handler:      middleware.LimitBody(cfg.HTTP.MaxBodyBytes)(mux),
 ReadTimeout:  15 * time.Second,
 WriteTimeout: 30 * time.Second,
 IdleTimeout:  60 * time.Second,
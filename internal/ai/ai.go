@@ -0,0 +1,118 @@
+// Package ai defines the AIProvider abstraction used for alert triage and
+// its implementations: a disabled no-op provider, an OpenAI-compatible
+// chat-completions client, an Anthropic Messages client, and a scripted fake
+// for tests.
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/config"
+)
+
+// Provider names accepted in AI_PROVIDER.
+const (
+	ProviderNoop      = "noop"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// ErrDisabled is returned by the no-op provider.
+var ErrDisabled = errors.New("ai: provider disabled (AI_PROVIDER=noop)")
+
+// Message is one conversation turn. Role is "user" or "assistant"; the
+// system prompt goes in Request.System.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Request is a provider-neutral completion request.
+type Request struct {
+	System   string
+	Messages []Message
+	// Model overrides the provider's configured model when set.
+	Model string
+	// MaxTokens caps the completion length; zero uses the provider default.
+	MaxTokens int
+	// JSON asks the model to answer with a single JSON object where the
+	// provider supports it.
+	JSON bool
+}
+
+// Response is a provider-neutral completion result.
+type Response struct {
+	Content      string
+	Model        string
+	StopReason   string
+	InputTokens  int
+	OutputTokens int
+}
+
+// AIProvider produces completions from a language model.
+type AIProvider interface {
+	// Name identifies the provider in logs and stored results.
+	Name() string
+	Complete(ctx context.Context, req Request) (Response, error)
+}
+
+// APIError is a non-2xx answer from a provider's HTTP API.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+	// RetryAfter is the server-suggested delay, or zero if none was given.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ai: %s returned %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the request may succeed if repeated later:
+// rate limiting and server-side failures.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// New returns the provider selected by cfg.Provider.
+func New(cfg config.AIConfig) (AIProvider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Provider {
+	case "", ProviderNoop:
+		return Noop{}, nil
+	case ProviderOpenAI:
+		return NewOpenAI(client, cfg.APIBase, cfg.APIKey, cfg.Model, cfg.MaxTokens), nil
+	case ProviderAnthropic:
+		if cfg.APIKey == "" {
+			return nil, errors.New("ai: AI_API_KEY is required when AI_PROVIDER=anthropic")
+		}
+		return NewAnthropic(client, cfg.APIBase, cfg.APIKey, cfg.Model, cfg.MaxTokens), nil
+	default:
+		return nil, fmt.Errorf("ai: unknown provider %q (want noop, openai or anthropic)", cfg.Provider)
+	}
+}
+
+// Noop is the provider used when AI features are disabled.
+type Noop struct{}
+
+// Name implements AIProvider.
+func (Noop) Name() string { return ProviderNoop }
+
+// Complete implements AIProvider. It always returns ErrDisabled.
+func (Noop) Complete(context.Context, Request) (Response, error) {
+	return Response{}, ErrDisabled
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(h http.Header) time.Duration {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
@@ -0,0 +1,158 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ai"
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stub starts an httptest server that records the last request body and
+// answers every request with status and body.
+func stub(t *testing.T, status int, body string, header http.Header) (*httptest.Server, *map[string]any, *http.Header) {
+	t.Helper()
+	got := map[string]any{}
+	gotHeader := http.Header{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		gotHeader.Set("X-Path", r.URL.Path)
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, &gotHeader
+}
+
+func TestNew(t *testing.T) {
+	p, err := ai.New(config.AIConfig{Provider: "noop"})
+	require.NoError(t, err)
+	assert.Equal(t, "noop", p.Name())
+	_, err = p.Complete(context.Background(), ai.Request{})
+	assert.ErrorIs(t, err, ai.ErrDisabled)
+
+	p, err = ai.New(config.AIConfig{Provider: "openai"})
+	require.NoError(t, err)
+	assert.Equal(t, "openai", p.Name())
+
+	_, err = ai.New(config.AIConfig{Provider: "anthropic"})
+	assert.Error(t, err, "anthropic requires an API key")
+
+	_, err = ai.New(config.AIConfig{Provider: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestOpenAI_Complete(t *testing.T) {
+	srv, got, hdr := stub(t, http.StatusOK, `{
+		"model": "gpt-test",
+		"choices": [{"message": {"role": "assistant", "content": "{\"ok\":true}"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 3}
+	}`, nil)
+
+	p := ai.NewOpenAI(srv.Client(), srv.URL+"/v1/", "sk-test", "gpt-test", 256)
+	resp, err := p.Complete(context.Background(), ai.Request{
+		System:   "be terse",
+		Messages: []ai.Message{{Role: "user", Content: "hi"}},
+		JSON:     true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ai.Response{
+		Content: `{"ok":true}`, Model: "gpt-test", StopReason: "stop",
+		InputTokens: 12, OutputTokens: 3,
+	}, resp)
+	assert.Equal(t, "/v1/chat/completions", hdr.Get("X-Path"))
+	assert.Equal(t, "Bearer sk-test", hdr.Get("Authorization"))
+	assert.Equal(t, "gpt-test", (*got)["model"])
+	assert.EqualValues(t, 256, (*got)["max_tokens"])
+	assert.Equal(t, map[string]any{"type": "json_object"}, (*got)["response_format"])
+	assert.Equal(t, []any{
+		map[string]any{"role": "system", "content": "be terse"},
+		map[string]any{"role": "user", "content": "hi"},
+	}, (*got)["messages"])
+}
+
+func TestOpenAI_APIError(t *testing.T) {
+	srv, _, _ := stub(t, http.StatusTooManyRequests,
+		`{"error":{"message":"slow down","type":"rate_limit"}}`,
+		http.Header{"Retry-After": {"7"}})
+
+	p := ai.NewOpenAI(srv.Client(), srv.URL, "", "", 0)
+	_, err := p.Complete(context.Background(), ai.Request{Messages: []ai.Message{{Role: "user", Content: "hi"}}})
+
+	var apiErr *ai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "slow down", apiErr.Message)
+	assert.Equal(t, 7*time.Second, apiErr.RetryAfter)
+	assert.True(t, apiErr.Retryable())
+}
+
+func TestAnthropic_Complete(t *testing.T) {
+	srv, got, hdr := stub(t, http.StatusOK, `{
+		"model": "claude-test",
+		"content": [{"type": "text", "text": "hello "}, {"type": "text", "text": "there"}],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 9, "output_tokens": 2}
+	}`, nil)
+
+	p := ai.NewAnthropic(srv.Client(), srv.URL, "key", "claude-test", 0)
+	resp, err := p.Complete(context.Background(), ai.Request{
+		System:   "be terse",
+		Messages: []ai.Message{{Role: "user", Content: "hi"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ai.Response{
+		Content: "hello there", Model: "claude-test", StopReason: "end_turn",
+		InputTokens: 9, OutputTokens: 2,
+	}, resp)
+	assert.Equal(t, "/v1/messages", hdr.Get("X-Path"))
+	assert.Equal(t, "key", hdr.Get("X-Api-Key"))
+	assert.Equal(t, "2023-06-01", hdr.Get("Anthropic-Version"))
+	assert.Equal(t, "be terse", (*got)["system"])
+	assert.EqualValues(t, 1024, (*got)["max_tokens"], "max_tokens is mandatory and defaulted")
+}
+
+func TestAnthropic_APIError(t *testing.T) {
+	srv, _, _ := stub(t, http.StatusBadRequest,
+		`{"type":"error","error":{"type":"invalid_request_error","message":"bad model"}}`, nil)
+
+	p := ai.NewAnthropic(srv.Client(), srv.URL, "key", "", 0)
+	_, err := p.Complete(context.Background(), ai.Request{Messages: []ai.Message{{Role: "user", Content: "hi"}}})
+
+	var apiErr *ai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "bad model", apiErr.Message)
+	assert.False(t, apiErr.Retryable())
+}
+
+func TestFake(t *testing.T) {
+	boom := errors.New("boom")
+	f := ai.NewFake(ai.FakeReply{Content: "one"}, ai.FakeReply{Err: boom})
+
+	resp, err := f.Complete(context.Background(), ai.Request{System: "s"})
+	require.NoError(t, err)
+	assert.Equal(t, "one", resp.Content)
+
+	_, err = f.Complete(context.Background(), ai.Request{})
+	assert.ErrorIs(t, err, boom)
+
+	_, err = f.Complete(context.Background(), ai.Request{})
+	assert.ErrorIs(t, err, ai.ErrFakeExhausted)
+
+	require.Len(t, f.Requests(), 3)
+	assert.Equal(t, "s", f.Requests()[0].System)
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Anthropic defaults, used when AI_API_BASE or AI_MODEL is unset.
+const (
+	DefaultAnthropicBase  = "https://api.anthropic.com"
+	DefaultAnthropicModel = "claude-3-5-haiku-latest"
+	anthropicVersion      = "2023-06-01"
+	// The Messages API requires max_tokens on every request.
+	defaultAnthropicMaxTokens = 1024
+)
+
+// Anthropic talks to the Anthropic Messages API.
+type Anthropic struct {
+	client    *http.Client
+	base      string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// NewAnthropic creates an Anthropic provider.
+func NewAnthropic(client *http.Client, base, apiKey, model string, maxTokens int) *Anthropic {
+	if base == "" {
+		base = DefaultAnthropicBase
+	}
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+	if maxTokens == 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	return &Anthropic{
+		client:    client,
+		base:      strings.TrimRight(base, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	MaxTokens int                `json:"max_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Name implements AIProvider.
+func (p *Anthropic) Name() string { return ProviderAnthropic }
+
+// Complete implements AIProvider. The Messages API has no JSON mode, so
+// Request.JSON only adds an instruction to the system prompt.
+func (p *Anthropic) Complete(ctx context.Context, req Request) (Response, error) {
+	body := anthropicRequest{
+		Model:     p.model,
+		System:    req.System,
+		MaxTokens: req.MaxTokens,
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = p.maxTokens
+	}
+	if req.Model != "" {
+		body.Model = req.Model
+	}
+	if req.JSON {
+		body.System = strings.TrimSpace(body.System + "\n\nRespond with a single JSON object and nothing else.")
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, anthropicMessage(m))
+	}
+
+	header := http.Header{}
+	header.Set("x-api-key", p.apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+	var out anthropicResponse
+	if err := postJSON(ctx, p.client, ProviderAnthropic, p.base+"/v1/messages", header, body, &out, anthropicErrorMessage); err != nil {
+		return Response{}, err
+	}
+
+	var text strings.Builder
+	for _, c := range out.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	return Response{
+		Content:      text.String(),
+		Model:        out.Model,
+		StopReason:   out.StopReason,
+		InputTokens:  out.Usage.InputTokens,
+		OutputTokens: out.Usage.OutputTokens,
+	}, nil
+}
+
+func anthropicErrorMessage(raw []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &e)
+	return e.Error.Message
+}
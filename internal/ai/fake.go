@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrFakeExhausted is returned by Fake once every scripted reply is used.
+var ErrFakeExhausted = errors.New("ai: fake provider has no replies left")
+
+// FakeReply is one scripted Fake answer: either Content or Err.
+type FakeReply struct {
+	Content string
+	Err     error
+}
+
+// Fake is a deterministic AIProvider for tests. It returns its replies in
+// order and records every request it receives.
+type Fake struct {
+	mu       sync.Mutex
+	replies  []FakeReply
+	requests []Request
+}
+
+// NewFake creates a Fake that answers with replies in order.
+func NewFake(replies ...FakeReply) *Fake {
+	return &Fake{replies: replies}
+}
+
+// Name implements AIProvider.
+func (f *Fake) Name() string { return "fake" }
+
+// Complete implements AIProvider.
+func (f *Fake) Complete(_ context.Context, req Request) (Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if len(f.replies) == 0 {
+		return Response{}, ErrFakeExhausted
+	}
+	r := f.replies[0]
+	f.replies = f.replies[1:]
+	if r.Err != nil {
+		return Response{}, r.Err
+	}
+	return Response{Content: r.Content, Model: "fake"}, nil
+}
+
+// Requests returns the requests received so far.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseBytes caps how much of a provider response is read.
+const maxResponseBytes = 4 << 20
+
+// postJSON sends body to url and decodes a 2xx JSON answer into out. Non-2xx
+// answers become *APIError, with the provider's error message extracted by
+// errMessage when possible.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, header http.Header, body, out any, errMessage func([]byte) string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("ai: encode %s request: %w", provider, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("ai: build %s request: %w", provider, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ai: %s request: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("ai: read %s response: %w", provider, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := errMessage(raw)
+		if msg == "" {
+			msg = strings.TrimSpace(string(raw))
+		}
+		return &APIError{
+			Provider:   provider,
+			StatusCode: resp.StatusCode,
+			Message:    msg,
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("ai: decode %s response: %w", provider, err)
+	}
+	return nil
+}
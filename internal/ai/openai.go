@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// OpenAI defaults, used when AI_API_BASE or AI_MODEL is unset.
+const (
+	DefaultOpenAIBase  = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "gpt-4o-mini"
+)
+
+// OpenAI talks to any OpenAI-compatible chat-completions endpoint
+// (OpenAI, Azure OpenAI proxies, Ollama, vLLM, ...).
+type OpenAI struct {
+	client    *http.Client
+	base      string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// NewOpenAI creates an OpenAI-compatible provider. An empty apiKey sends no
+// Authorization header, for local servers that do not need one.
+func NewOpenAI(client *http.Client, base, apiKey, model string, maxTokens int) *OpenAI {
+	if base == "" {
+		base = DefaultOpenAIBase
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAI{
+		client:    client,
+		base:      strings.TrimRight(base, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string          `json:"model"`
+	Messages       []openAIMessage `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Name implements AIProvider.
+func (p *OpenAI) Name() string { return ProviderOpenAI }
+
+// Complete implements AIProvider.
+func (p *OpenAI) Complete(ctx context.Context, req Request) (Response, error) {
+	body := openAIRequest{Model: p.model, MaxTokens: req.MaxTokens}
+	if req.Model != "" {
+		body.Model = req.Model
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = p.maxTokens
+	}
+	if req.System != "" {
+		body.Messages = append(body.Messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, openAIMessage(m))
+	}
+	if req.JSON {
+		body.ResponseFormat = &struct {
+			Type string `json:"type"`
+		}{Type: "json_object"}
+	}
+
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	var out openAIResponse
+	if err := postJSON(ctx, p.client, ProviderOpenAI, p.base+"/chat/completions", header, body, &out, openAIErrorMessage); err != nil {
+		return Response{}, err
+	}
+	if len(out.Choices) == 0 {
+		return Response{}, errors.New("ai: openai response contained no choices")
+	}
+	return Response{
+		Content:      out.Choices[0].Message.Content,
+		Model:        out.Model,
+		StopReason:   out.Choices[0].FinishReason,
+		InputTokens:  out.Usage.PromptTokens,
+		OutputTokens: out.Usage.CompletionTokens,
+	}, nil
+}
+
+func openAIErrorMessage(raw []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &e)
+	return e.Error.Message
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// alertSortColumns maps the sort keys accepted by GET /api/v1/alerts to
+// columns. Each list is keyset-paginated on (column, id).
+var alertSortColumns = map[string]string{
+	"created_at":   "created_at",
+	"last_seen_at": "last_seen_at",
+	"occurred_at":  "occurred_at",
+}
+
+const defaultAlertSort = "-last_seen_at"
+
+// AlertHandler handles /api/v1/alerts routes.
+type AlertHandler struct {
+	db *gorm.DB
+}
+
+// NewAlertHandler creates an AlertHandler.
+func NewAlertHandler(db *gorm.DB) *AlertHandler {
+	return &AlertHandler{db: db}
+}
+
+type alertAttrs struct {
+	Source          string             `json:"source"`
+	Fingerprint     string             `json:"fingerprint"`
+	Title           string             `json:"title"`
+	Description     string             `json:"description"`
+	SeverityHint    string             `json:"severity_hint"`
+	Status          string             `json:"status"`
+	Labels          map[string]string  `json:"labels"`
+	Annotations     map[string]string  `json:"annotations"`
+	GeneratorURL    string             `json:"generator_url,omitempty"`
+	RuleUID         string             `json:"rule_uid,omitempty"`
+	Values          map[string]float64 `json:"values,omitempty"`
+	DashboardURL    string             `json:"dashboard_url,omitempty"`
+	PanelURL        string             `json:"panel_url,omitempty"`
+	SilenceURL      string             `json:"silence_url,omitempty"`
+	OccurrenceCount int                `json:"occurrence_count"`
+	OccurredAt      time.Time          `json:"occurred_at"`
+	ReceivedAt      time.Time          `json:"received_at"`
+	LastSeenAt      time.Time          `json:"last_seen_at"`
+	AcknowledgedAt  *time.Time         `json:"acknowledged_at"`
+	AcknowledgedBy  *string            `json:"acknowledged_by"`
+	ResolvedAt      *time.Time         `json:"resolved_at"`
+	ResolvedBy      *string            `json:"resolved_by"`
+	Suppressed      bool               `json:"suppressed"`
+	SilenceID       *string            `json:"silence_id,omitempty"`
+	TriageStatus    *string            `json:"triage_status"`
+	IncidentID      *string            `json:"incident_id"`
+}
+
+func alertResource(a *model.Alert) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "alert",
+		ID:   a.ID,
+		Attributes: alertAttrs{
+			Source:          a.Source,
+			Fingerprint:     a.Fingerprint,
+			Title:           a.Title,
+			Description:     a.Description,
+			SeverityHint:    a.SeverityHint,
+			Status:          a.Status,
+			Labels:          a.Labels,
+			Annotations:     a.Annotations,
+			GeneratorURL:    a.GeneratorURL,
+			RuleUID:         a.RuleUID,
+			Values:          a.Values,
+			DashboardURL:    a.DashboardURL,
+			PanelURL:        a.PanelURL,
+			SilenceURL:      a.SilenceURL,
+			OccurrenceCount: a.OccurrenceCount,
+			OccurredAt:      a.OccurredAt,
+			ReceivedAt:      a.ReceivedAt,
+			LastSeenAt:      a.LastSeenAt,
+			AcknowledgedAt:  a.AcknowledgedAt,
+			AcknowledgedBy:  a.AcknowledgedBy,
+			ResolvedAt:      a.ResolvedAt,
+			ResolvedBy:      a.ResolvedBy,
+			Suppressed:      a.Suppressed,
+			SilenceID:       a.SilenceID,
+			TriageStatus:    a.TriageStatus,
+			IncidentID:      a.IncidentID,
+		},
+	}
+}
+
+// List handles GET /api/v1/alerts.
+//
+// Supported query parameters: filter[status], filter[source] and
+// filter[severity] (comma-separated), filter[suppressed] (true or false),
+// filter[since] (RFC 3339, matched
+// against last_seen_at), sort (created_at, last_seen_at or occurred_at,
+// prefixed with '-' for descending; default -last_seen_at), page[size] and
+// page[cursor].
+func (h *AlertHandler) List(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	sort, column, desc, err := parseSort(r, alertSortColumns, defaultAlertSort)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	page, err := parsePageFor(r, sort, now)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	query, err := h.filtered(r)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	alerts, pagination, links, err := keysetPage(r, query, page, sort, column, desc, now,
+		func(a *model.Alert) (time.Time, string) { return alertSortKey(a, column), a.ID })
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list alerts")
+		return
+	}
+
+	data := make([]any, 0, len(alerts))
+	for i := range alerts {
+		data = append(data, alertResource(&alerts[i]))
+	}
+	jsonapi.RenderPage(w, http.StatusOK, data, pagination, links)
+}
+
+// filtered applies the filter[...] query parameters to an alert query.
+func (h *AlertHandler) filtered(r *http.Request) (*gorm.DB, error) {
+	q := r.URL.Query()
+	query := h.db.WithContext(r.Context()).Model(&model.Alert{})
+
+	if v := q.Get("filter[status]"); v != "" {
+		statuses := splitFilter(v)
+		for _, s := range statuses {
+			if !slices.Contains(model.AlertStatuses, s) {
+				return nil, jsonapi.ParamError("filter[status]", "unknown alert status "+s)
+			}
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if v := q.Get("filter[source]"); v != "" {
+		query = query.Where("source IN ?", splitFilter(v))
+	}
+	if v := q.Get("filter[severity]"); v != "" {
+		query = query.Where("severity_hint IN ?", splitFilter(strings.ToLower(v)))
+	}
+	if v := q.Get("filter[suppressed]"); v != "" {
+		suppressed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, jsonapi.ParamError("filter[suppressed]", "filter[suppressed] must be true or false")
+		}
+		query = query.Where("suppressed = ?", suppressed)
+	}
+	if v := q.Get("filter[since]"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, jsonapi.ParamError("filter[since]", "filter[since] must be an RFC 3339 timestamp")
+		}
+		query = query.Where("last_seen_at >= ?", since)
+	}
+	return query, nil
+}
+
+func splitFilter(v string) []string {
+	parts := strings.Split(v, ",")
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func alertSortKey(a *model.Alert, column string) time.Time {
+	switch column {
+	case "created_at":
+		return a.CreatedAt
+	case "occurred_at":
+		return a.OccurredAt
+	default:
+		return a.LastSeenAt
+	}
+}
+
+// Get handles GET /api/v1/alerts/{id}.
+func (h *AlertHandler) Get(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, alertResource(a))
+}
+
+// Acknowledge handles POST /api/v1/alerts/{id}/acknowledge.
+// Acknowledging an acknowledged alert is a no-op; a resolved alert cannot be
+// acknowledged.
+func (h *AlertHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	h.transition(w, r, []string{model.AlertStatusFiring}, model.AlertStatusAcknowledged, map[string]any{
+		"status":          model.AlertStatusAcknowledged,
+		"acknowledged_at": now,
+		"acknowledged_by": claimsUserID(r),
+		"updated_at":      now,
+	})
+}
+
+// Resolve handles POST /api/v1/alerts/{id}/resolve.
+// Resolving a resolved alert is a no-op. Once resolved, the alert no longer
+// absorbs repeats; the next delivery of its fingerprint opens a new alert.
+func (h *AlertHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	h.transition(w, r, model.OpenAlertStatuses, model.AlertStatusResolved, map[string]any{
+		"status":      model.AlertStatusResolved,
+		"resolved_at": now,
+		"resolved_by": claimsUserID(r),
+		"dedup_key":   nil,
+		"updated_at":  now,
+	})
+}
+
+// transition applies updates to the alert if its status is one of from, and
+// renders the alert afterwards. The status check is part of the UPDATE so
+// concurrent actions cannot both succeed.
+func (h *AlertHandler) transition(w http.ResponseWriter, r *http.Request, from []string, to string, updates map[string]any) {
+	a, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	if a.Status == to {
+		jsonapi.RenderOne(w, http.StatusOK, alertResource(a))
+		return
+	}
+
+	res := h.db.WithContext(r.Context()).Model(&model.Alert{}).
+		Where("id = ? AND status IN ?", a.ID, from).
+		Updates(updates)
+	if res.Error != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update alert")
+		return
+	}
+
+	if a, ok = h.load(w, r); !ok {
+		return
+	}
+	if res.RowsAffected == 0 && a.Status != to {
+		jsonapi.RenderError(w, http.StatusConflict, "invalid_transition", "Conflict",
+			"alert is "+a.Status+" and cannot become "+to)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, alertResource(a))
+}
+
+func (h *AlertHandler) load(w http.ResponseWriter, r *http.Request) (*model.Alert, bool) {
+	var a model.Alert
+	err := h.db.WithContext(r.Context()).Where("id = ?", r.PathValue("id")).First(&a).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "alert does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load alert")
+		return nil, false
+	}
+	return &a, true
+}
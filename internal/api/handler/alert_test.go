@@ -0,0 +1,216 @@
+package handler_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/handler"
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// document is a JSON:API response as clients see it.
+type document struct {
+	Data   json.RawMessage       `json:"data"`
+	Links  jsonapi.Links         `json:"links"`
+	Page   jsonapi.Pagination    `json:"page"`
+	Errors []jsonapi.ErrorObject `json:"errors"`
+}
+
+// ids returns the IDs of a collection document's resources.
+func (d *document) ids(t *testing.T) []string {
+	t.Helper()
+	var data []jsonapi.ResourceObject
+	require.NoError(t, json.Unmarshal(d.Data, &data))
+	ids := make([]string, len(data))
+	for i, res := range data {
+		ids[i] = res.ID
+	}
+	return ids
+}
+
+// serve passes r to fn and decodes the response.
+func serve(t *testing.T, fn http.HandlerFunc, r *http.Request) (int, *document) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	fn(w, r)
+	var doc document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc), w.Body.String())
+	return w.Code, &doc
+}
+
+// seedAlerts stores five alerts. a2 and a3 were last seen at the same time,
+// so paging by last_seen_at has to break the tie by ID.
+func seedAlerts(t *testing.T, gormDB *gorm.DB) {
+	t.Helper()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, a := range []model.Alert{
+		{ID: "a1", Source: "grafana", LastSeenAt: t0},
+		{ID: "a2", Source: "grafana", LastSeenAt: t0.Add(time.Minute)},
+		{ID: "a3", Source: "grafana", LastSeenAt: t0.Add(time.Minute)},
+		{ID: "a4", Source: "prometheus", LastSeenAt: t0.Add(2 * time.Minute)},
+		{ID: "a5", Source: "grafana", LastSeenAt: t0.Add(3 * time.Minute), Suppressed: true},
+	} {
+		a.SourceID, a.Fingerprint, a.Title = "src-"+a.Source, a.ID, "Alert "+a.ID
+		a.Status = model.AlertStatusFiring
+		a.OccurredAt, a.ReceivedAt = a.LastSeenAt, a.LastSeenAt
+		require.NoError(t, gormDB.Create(&a).Error)
+	}
+	require.NoError(t, gormDB.Model(&model.Alert{}).Where("id = ?", "a1").Update("status", model.AlertStatusResolved).Error)
+}
+
+func listAlerts(params url.Values) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/api/v1/alerts?"+params.Encode(), nil)
+}
+
+func TestAlertList_PagesForwardAndBack(t *testing.T) {
+	gormDB := dbtest.New(t)
+	seedAlerts(t, gormDB)
+	h := handler.NewAlertHandler(gormDB)
+
+	var (
+		pages [][]string
+		links []jsonapi.Links
+	)
+	r := listAlerts(url.Values{"page[size]": {"2"}})
+	for {
+		code, doc := serve(t, h.List, r)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 5, doc.Page.Total)
+		pages = append(pages, doc.ids(t))
+		links = append(links, doc.Links)
+		if doc.Links.Next == "" {
+			break
+		}
+		r = httptest.NewRequest(http.MethodGet, doc.Links.Next, nil)
+	}
+	assert.Equal(t, [][]string{{"a5", "a4"}, {"a3", "a2"}, {"a1"}}, pages, "ties are ordered by ID")
+	assert.Empty(t, links[0].Prev, "the first page has no previous page")
+	assert.NotEmpty(t, links[1].Prev)
+
+	code, doc := serve(t, h.List, httptest.NewRequest(http.MethodGet, links[2].Prev, nil))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a3", "a2"}, doc.ids(t))
+	code, doc = serve(t, h.List, httptest.NewRequest(http.MethodGet, doc.Links.Prev, nil))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a5", "a4"}, doc.ids(t))
+	assert.Empty(t, doc.Links.Prev)
+	assert.NotEmpty(t, doc.Links.Next)
+}
+
+func TestAlertList_CursorEncoding(t *testing.T) {
+	gormDB := dbtest.New(t)
+	seedAlerts(t, gormDB)
+	h := handler.NewAlertHandler(gormDB)
+
+	code, doc := serve(t, h.List, listAlerts(url.Values{"page[size]": {"3"}, "sort": {"last_seen_at"}}))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"a1", "a2", "a3"}, doc.ids(t))
+
+	raw, err := base64.RawURLEncoding.DecodeString(doc.Page.Cursor)
+	require.NoError(t, err)
+	var c jsonapi.Cursor
+	require.NoError(t, json.Unmarshal(raw, &c))
+	assert.Equal(t, "last_seen_at", c.Sort)
+	assert.Equal(t, "a3", c.ID)
+	assert.True(t, time.Date(2026, 3, 1, 10, 1, 0, 0, time.UTC).Equal(c.Key))
+	assert.False(t, c.Before)
+	assert.WithinDuration(t, time.Now(), time.Unix(c.IssuedAt, 0), time.Minute)
+
+	next, err := url.Parse(doc.Links.Next)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/alerts", next.Path)
+	assert.Equal(t, doc.Page.Cursor, next.Query().Get("page[cursor]"))
+	assert.Equal(t, "last_seen_at", next.Query().Get("sort"), "links keep the other parameters")
+}
+
+func TestAlertList_RejectsBadCursors(t *testing.T) {
+	gormDB := dbtest.New(t)
+	seedAlerts(t, gormDB)
+	h := handler.NewAlertHandler(gormDB)
+	now := time.Now()
+
+	for name, params := range map[string]url.Values{
+		"expired": {"page[cursor]": {jsonapi.Cursor{
+			Sort: "-last_seen_at", ID: "a3", IssuedAt: now.Add(-jsonapi.CursorTTL - time.Hour).Unix(),
+		}.Encode()}},
+		"other sort": {"sort": {"created_at"}, "page[cursor]": {jsonapi.Cursor{
+			Sort: "-last_seen_at", ID: "a3", IssuedAt: now.Unix(),
+		}.Encode()}},
+		"tampered": {"page[cursor]": {"not-a-cursor"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, doc := serve(t, h.List, listAlerts(params))
+			assert.Equal(t, http.StatusBadRequest, code)
+			require.Len(t, doc.Errors, 1)
+			require.NotNil(t, doc.Errors[0].Source)
+			assert.Equal(t, "page[cursor]", doc.Errors[0].Source.Parameter)
+		})
+	}
+}
+
+func TestAlertList_Filters(t *testing.T) {
+	gormDB := dbtest.New(t)
+	seedAlerts(t, gormDB)
+	h := handler.NewAlertHandler(gormDB)
+
+	for name, tc := range map[string]struct {
+		params url.Values
+		ids    []string
+	}{
+		"status": {url.Values{"filter[status]": {"resolved"}}, []string{"a1"}},
+		"source and suppressed": {
+			url.Values{"filter[source]": {"grafana"}, "filter[suppressed]": {"false"}},
+			[]string{"a3", "a2", "a1"},
+		},
+		"all combined": {
+			url.Values{
+				"filter[status]":     {"firing"},
+				"filter[source]":     {"grafana, prometheus"},
+				"filter[suppressed]": {"false"},
+				"filter[since]":      {"2026-03-01T10:01:00Z"},
+			},
+			[]string{"a4", "a3", "a2"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, doc := serve(t, h.List, listAlerts(tc.params))
+			require.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tc.ids, doc.ids(t))
+			assert.Equal(t, len(tc.ids), doc.Page.Total)
+		})
+	}
+
+	t.Run("paged", func(t *testing.T) {
+		params := url.Values{"filter[source]": {"grafana"}, "filter[suppressed]": {"false"}, "page[size]": {"2"}}
+		code, doc := serve(t, h.List, listAlerts(params))
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"a3", "a2"}, doc.ids(t))
+		assert.Equal(t, 3, doc.Page.Total)
+
+		code, doc = serve(t, h.List, httptest.NewRequest(http.MethodGet, doc.Links.Next, nil))
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"a1"}, doc.ids(t), "the next link keeps the filters")
+	})
+
+	for param, value := range map[string]string{
+		"filter[status]":     "bogus",
+		"filter[suppressed]": "maybe",
+		"filter[since]":      "yesterday",
+		"sort":               "title",
+	} {
+		code, doc := serve(t, h.List, listAlerts(url.Values{param: {value}}))
+		assert.Equal(t, http.StatusBadRequest, code, param)
+		require.Len(t, doc.Errors, 1, param)
+		assert.Equal(t, param, doc.Errors[0].Source.Parameter)
+	}
+}
@@ -2,7 +2,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"time"
 
@@ -47,8 +46,8 @@ type tokenAttrs struct {
 // Login handles POST /api/v1/auth/login.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		jsonapi.RenderError(w, http.StatusBadRequest, "invalid_body", "Bad Request", "request body must be valid JSON")
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
 		return
 	}
 	if req.Email == "" || req.Password == "" {
@@ -106,7 +105,11 @@ type refreshRequest struct {
 // Refresh handles POST /api/v1/auth/refresh.
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req refreshRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if req.RefreshToken == "" {
 		jsonapi.RenderError(w, http.StatusBadRequest, "invalid_body", "Bad Request", "refresh_token is required")
 		return
 	}
@@ -155,7 +158,11 @@ type logoutRequest struct {
 // Logout handles POST /api/v1/auth/logout.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	var req logoutRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if req.RefreshToken == "" {
 		jsonapi.RenderError(w, http.StatusBadRequest, "invalid_body", "Bad Request", "refresh_token is required")
 		return
 	}
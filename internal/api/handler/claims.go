@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/d9705996/autopsy/internal/api/middleware"
+	"gorm.io/gorm"
+)
+
+// claimsOrgID returns the caller's organisation ID, or "" when the request is
+// unauthenticated or the user belongs to no organisation.
+func claimsOrgID(r *http.Request) string {
+	if c := middleware.ClaimsFromContext(r.Context()); c != nil {
+		return c.OrganizationID
+	}
+	return ""
+}
+
+// claimsUserID returns the caller's user ID, or nil when the request is
+// unauthenticated.
+func claimsUserID(r *http.Request) *string {
+	if c := middleware.ClaimsFromContext(r.Context()); c != nil && c.UserID != "" {
+		return &c.UserID
+	}
+	return nil
+}
+
+// orgScoped limits q to rows of the caller's organisation, or to rows of no
+// organisation when the caller belongs to none.
+func orgScoped(q *gorm.DB, r *http.Request) *gorm.DB {
+	if orgID := claimsOrgID(r); orgID != "" {
+		return q.Where("organization_id = ?", orgID)
+	}
+	return q.Where("organization_id IS NULL")
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+const maxComponentNameLength = 100
+
+// ComponentHandler handles /api/v1/components routes.
+type ComponentHandler struct {
+	db *gorm.DB
+}
+
+// NewComponentHandler creates a ComponentHandler.
+func NewComponentHandler(db *gorm.DB) *ComponentHandler {
+	return &ComponentHandler{db: db}
+}
+
+type componentAttrs struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func componentResource(c *model.Component) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "component",
+		ID:   c.ID,
+		Attributes: componentAttrs{
+			Name:        c.Name,
+			Description: c.Description,
+			CreatedAt:   c.CreatedAt,
+			UpdatedAt:   c.UpdatedAt,
+		},
+	}
+}
+
+type componentRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// apply validates the request and copies the editable fields onto c.
+func (req *componentRequest) apply(c *model.Component) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fieldError("/name", "name must not be empty"))
+		case len(name) > maxComponentNameLength:
+			errs = append(errs, fieldError("/name", "name must be at most 100 characters"))
+		}
+		c.Name = name
+	}
+	if req.Description != nil {
+		c.Description = *req.Description
+	}
+	return errs
+}
+
+// Create handles POST /api/v1/components.
+func (h *ComponentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req componentRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	var c model.Component
+	errs := req.apply(&c)
+	if req.Name == nil {
+		errs = append(errs, fieldError("/name", "name is required"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if orgID := claimsOrgID(r); orgID != "" {
+		c.OrganizationID = &orgID
+	}
+	err := h.db.WithContext(r.Context()).Create(&c).Error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a component with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create component")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, componentResource(&c))
+}
+
+// List handles GET /api/v1/components, ordered by name.
+func (h *ComponentHandler) List(w http.ResponseWriter, r *http.Request) {
+	var components []model.Component
+	if err := h.db.WithContext(r.Context()).Order("name").Find(&components).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list components")
+		return
+	}
+	data := make([]any, 0, len(components))
+	for i := range components {
+		data = append(data, componentResource(&components[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Get handles GET /api/v1/components/{id}.
+func (h *ComponentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, componentResource(c))
+}
+
+// Update handles PATCH /api/v1/components/{id}.
+func (h *ComponentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req componentRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if errs := req.apply(c); len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	err := h.db.WithContext(r.Context()).Save(c).Error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a component with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update component")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, componentResource(c))
+}
+
+func (h *ComponentHandler) load(w http.ResponseWriter, r *http.Request) (*model.Component, bool) {
+	var c model.Component
+	err := h.db.WithContext(r.Context()).Where("id = ?", r.PathValue("id")).First(&c).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "component does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load component")
+		return nil, false
+	}
+	return &c, true
+}
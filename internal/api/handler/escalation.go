@@ -0,0 +1,477 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/worker"
+	"gorm.io/gorm"
+)
+
+const (
+	maxPolicyNameLength = 100
+	maxEscalationTiers  = 20
+	maxTierTargets      = 20
+	// maxTierTimeoutMinutes caps a tier's acknowledgement timeout at a day.
+	maxTierTimeoutMinutes = 24 * 60
+	maxRepeatCount        = 10
+)
+
+// EscalationHandler handles /api/v1/escalation-policies and page routes.
+type EscalationHandler struct {
+	db         *gorm.DB
+	escalation *escalation.Service
+}
+
+// NewEscalationHandler creates an EscalationHandler. Escalation steps and
+// notifications are staged for the worker queue.
+func NewEscalationHandler(db *gorm.DB, escalation *escalation.Service) *EscalationHandler {
+	return &EscalationHandler{db: db, escalation: escalation}
+}
+
+type policyAttrs struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Tiers       []model.EscalationTier `json:"tiers"`
+	RepeatCount int                    `json:"repeat_count"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+func policyResource(p *model.EscalationPolicy) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "escalation_policy",
+		ID:   p.ID,
+		Attributes: policyAttrs{
+			Name:        p.Name,
+			Description: p.Description,
+			Tiers:       p.Tiers,
+			RepeatCount: p.RepeatCount,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+		},
+	}
+}
+
+type pageAttrs struct {
+	IncidentID       string     `json:"incident_id"`
+	PolicyID         string     `json:"policy_id"`
+	Status           string     `json:"status"`
+	Tier             int        `json:"tier"`
+	Cycle            int        `json:"cycle"`
+	NextEscalationAt *time.Time `json:"next_escalation_at"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy   *string    `json:"acknowledged_by"`
+	CreatedBy        *string    `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func pageResource(p *model.Page) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "page",
+		ID:   p.ID,
+		Attributes: pageAttrs{
+			IncidentID:       p.IncidentID,
+			PolicyID:         p.PolicyID,
+			Status:           p.Status,
+			Tier:             p.Tier,
+			Cycle:            p.Cycle,
+			NextEscalationAt: p.NextEscalationAt,
+			AcknowledgedAt:   p.AcknowledgedAt,
+			AcknowledgedBy:   p.AcknowledgedBy,
+			CreatedBy:        p.CreatedBy,
+			CreatedAt:        p.CreatedAt,
+			UpdatedAt:        p.UpdatedAt,
+		},
+	}
+}
+
+type policyRequest struct {
+	Name        *string                 `json:"name"`
+	Description *string                 `json:"description"`
+	Tiers       *[]model.EscalationTier `json:"tiers"`
+	RepeatCount *int                    `json:"repeat_count"`
+}
+
+// apply validates the request and copies the editable fields onto p. Tier
+// targets are checked against the database separately, by unknownTargets.
+func (req *policyRequest) apply(p *model.EscalationPolicy) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fieldError("/name", "name must not be empty"))
+		case len(name) > maxPolicyNameLength:
+			errs = append(errs, fieldError("/name", "name must be at most 100 characters"))
+		}
+		p.Name = name
+	}
+	if req.Description != nil {
+		p.Description = *req.Description
+	}
+	if req.Tiers != nil {
+		tiers := *req.Tiers
+		switch {
+		case len(tiers) == 0:
+			errs = append(errs, fieldError("/tiers", "a policy needs at least one tier"))
+		case len(tiers) > maxEscalationTiers:
+			errs = append(errs, fieldError("/tiers", "a policy has at most 20 tiers"))
+		}
+		for i, tier := range tiers {
+			errs = append(errs, validateTier(fmt.Sprintf("/tiers/%d", i), tier)...)
+		}
+		p.Tiers = tiers
+	}
+	if req.RepeatCount != nil {
+		if *req.RepeatCount < 0 || *req.RepeatCount > maxRepeatCount {
+			errs = append(errs, fieldError("/repeat_count", "repeat_count must be between 0 and 10"))
+		}
+		p.RepeatCount = *req.RepeatCount
+	}
+	return errs
+}
+
+func validateTier(pointer string, tier model.EscalationTier) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	switch {
+	case len(tier.Targets) == 0:
+		errs = append(errs, fieldError(pointer+"/targets", "a tier needs at least one target"))
+	case len(tier.Targets) > maxTierTargets:
+		errs = append(errs, fieldError(pointer+"/targets", "a tier has at most 20 targets"))
+	}
+	for j, target := range tier.Targets {
+		if !slices.Contains(model.EscalationTargetTypes, target.Type) {
+			errs = append(errs, fieldError(fmt.Sprintf("%s/targets/%d/type", pointer, j),
+				"type must be one of "+strings.Join(model.EscalationTargetTypes, ", ")))
+		}
+		if target.ID == "" {
+			errs = append(errs, fieldError(fmt.Sprintf("%s/targets/%d/id", pointer, j), "id is required"))
+		}
+	}
+	if tier.TimeoutMinutes < 1 || tier.TimeoutMinutes > maxTierTimeoutMinutes {
+		errs = append(errs, fieldError(pointer+"/timeout_minutes", "timeout_minutes must be between 1 and 1440"))
+	}
+	return errs
+}
+
+// Create handles POST /api/v1/escalation-policies.
+func (h *EscalationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req policyRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	var p model.EscalationPolicy
+	errs := req.apply(&p)
+	if req.Name == nil {
+		errs = append(errs, fieldError("/name", "name is required"))
+	}
+	if req.Tiers == nil {
+		errs = append(errs, fieldError("/tiers", "tiers is required"))
+	}
+	if !h.validTargets(w, r, &p, errs) {
+		return
+	}
+
+	if orgID := claimsOrgID(r); orgID != "" {
+		p.OrganizationID = &orgID
+	}
+	err := h.db.WithContext(r.Context()).Create(&p).Error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "an escalation policy with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create escalation policy")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, policyResource(&p))
+}
+
+// List handles GET /api/v1/escalation-policies, ordered by name.
+func (h *EscalationHandler) List(w http.ResponseWriter, r *http.Request) {
+	var policies []model.EscalationPolicy
+	if err := h.db.WithContext(r.Context()).Order("name").Find(&policies).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list escalation policies")
+		return
+	}
+	data := make([]any, 0, len(policies))
+	for i := range policies {
+		data = append(data, policyResource(&policies[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Get handles GET /api/v1/escalation-policies/{id}.
+func (h *EscalationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	p, err := h.escalation.Policy(r.Context(), r.PathValue("id"))
+	if err != nil {
+		renderEscalationError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, policyResource(p))
+}
+
+// Update handles PATCH /api/v1/escalation-policies/{id}. Pages already
+// under way continue with the new tiers from their current position.
+func (h *EscalationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	p, err := h.escalation.Policy(r.Context(), r.PathValue("id"))
+	if err != nil {
+		renderEscalationError(w, err)
+		return
+	}
+	var req policyRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if !h.validTargets(w, r, p, req.apply(p)) {
+		return
+	}
+	err = h.db.WithContext(r.Context()).Model(p).
+		Select("name", "description", "tiers", "repeat_count", "updated_at").Updates(p).Error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "an escalation policy with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update escalation policy")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, policyResource(p))
+}
+
+// validTargets renders errs together with errors for p's targets that do
+// not exist, and reports whether there were none.
+func (h *EscalationHandler) validTargets(w http.ResponseWriter, r *http.Request, p *model.EscalationPolicy, errs []jsonapi.ErrorObject) bool {
+	if len(errs) == 0 {
+		unknown, err := h.unknownTargets(r.Context(), p.Tiers)
+		if err != nil {
+			jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load escalation targets")
+			return false
+		}
+		errs = unknown
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return false
+	}
+	return true
+}
+
+// unknownTargets returns an error for each target in tiers that is not an
+// active user or an existing schedule.
+func (h *EscalationHandler) unknownTargets(ctx context.Context, tiers []model.EscalationTier) ([]jsonapi.ErrorObject, error) {
+	var userIDs, scheduleIDs []string
+	for _, tier := range tiers {
+		for _, target := range tier.Targets {
+			if target.Type == model.EscalationTargetUser {
+				userIDs = append(userIDs, target.ID)
+			} else {
+				scheduleIDs = append(scheduleIDs, target.ID)
+			}
+		}
+	}
+	db := h.db.WithContext(ctx)
+	var users, schedules []string
+	if len(userIDs) > 0 {
+		if err := db.Model(&model.User{}).
+			Where("id IN ? AND deactivated_at IS NULL", userIDs).
+			Pluck("id", &users).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(scheduleIDs) > 0 {
+		if err := db.Model(&model.OnCallSchedule{}).
+			Where("id IN ?", scheduleIDs).
+			Pluck("id", &schedules).Error; err != nil {
+			return nil, err
+		}
+	}
+	var errs []jsonapi.ErrorObject
+	for i, tier := range tiers {
+		for j, target := range tier.Targets {
+			pointer := fmt.Sprintf("/tiers/%d/targets/%d/id", i, j)
+			switch {
+			case target.Type == model.EscalationTargetUser && !slices.Contains(users, target.ID):
+				errs = append(errs, fieldError(pointer, "user "+target.ID+" does not exist or is deactivated"))
+			case target.Type == model.EscalationTargetSchedule && !slices.Contains(schedules, target.ID):
+				errs = append(errs, fieldError(pointer, "schedule "+target.ID+" does not exist"))
+			}
+		}
+	}
+	return errs, nil
+}
+
+type pageRequest struct {
+	PolicyID *string `json:"policy_id"`
+}
+
+// CreatePage handles POST /api/v1/incidents/{id}/pages, paging the first
+// tier of policy_id and scheduling the escalation to the next.
+func (h *EscalationHandler) CreatePage(w http.ResponseWriter, r *http.Request) {
+	var req pageRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if req.PolicyID == nil || *req.PolicyID == "" {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/policy_id", "policy_id is required")})
+		return
+	}
+
+	page, _, err := h.escalation.Start(r.Context(), r.PathValue("id"), *req.PolicyID, claimsUserID(r), worker.Schedule)
+	switch {
+	case errors.Is(err, escalation.ErrPolicyNotFound):
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/policy_id", "escalation policy does not exist")})
+		return
+	case err != nil:
+		renderEscalationError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, pageResource(page))
+}
+
+// ListPages handles GET /api/v1/incidents/{id}/pages, oldest first.
+func (h *EscalationHandler) ListPages(w http.ResponseWriter, r *http.Request) {
+	db := h.db.WithContext(r.Context())
+	var n int64
+	if err := db.Model(&model.Incident{}).Where("id = ?", r.PathValue("id")).Count(&n).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load incident")
+		return
+	}
+	if n == 0 {
+		renderEscalationError(w, incident.ErrNotFound)
+		return
+	}
+	var pages []model.Page
+	if err := db.Where("incident_id = ?", r.PathValue("id")).Order("created_at, id").Find(&pages).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list pages")
+		return
+	}
+	data := make([]any, 0, len(pages))
+	for i := range pages {
+		data = append(data, pageResource(&pages[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Acknowledge handles POST /api/v1/pages/{id}/acknowledge, which stops the
+// page's escalation.
+func (h *EscalationHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	page, err := h.escalation.Acknowledge(r.Context(), r.PathValue("id"), claimsUserID(r))
+	if err != nil {
+		renderEscalationError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, pageResource(page))
+}
+
+type deliveryAttemptAttrs struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type deliveryAttrs struct {
+	PageID      string                 `json:"page_id"`
+	UserID      string                 `json:"user_id"`
+	Channel     string                 `json:"channel"`
+	Tier        int                    `json:"tier"`
+	Cycle       int                    `json:"cycle"`
+	Status      string                 `json:"status"`
+	Attempts    []deliveryAttemptAttrs `json:"attempts"`
+	LastError   string                 `json:"last_error"`
+	DeliveredAt *time.Time             `json:"delivered_at"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// deliveryResource renders d without its target, which may hold a webhook
+// secret.
+func deliveryResource(d *model.NotificationDelivery) jsonapi.ResourceObject {
+	attempts := make([]deliveryAttemptAttrs, 0, len(d.AttemptLog))
+	for _, a := range d.AttemptLog {
+		attempts = append(attempts, deliveryAttemptAttrs{
+			Attempt:    a.Attempt,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMS: a.DurationMS,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+	return jsonapi.ResourceObject{
+		Type: "notification_delivery",
+		ID:   d.ID,
+		Attributes: deliveryAttrs{
+			PageID:      d.PageID,
+			UserID:      d.UserID,
+			Channel:     d.Channel,
+			Tier:        d.Tier,
+			Cycle:       d.Cycle,
+			Status:      d.Status,
+			Attempts:    attempts,
+			LastError:   d.LastError,
+			DeliveredAt: d.DeliveredAt,
+			CreatedAt:   d.CreatedAt,
+			UpdatedAt:   d.UpdatedAt,
+		},
+	}
+}
+
+// Deliveries handles GET /api/v1/pages/{id}/deliveries, listing the page's
+// notifications oldest first, each with its attempt log.
+func (h *EscalationHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	page, err := h.escalation.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		renderEscalationError(w, err)
+		return
+	}
+	var deliveries []model.NotificationDelivery
+	err = h.db.WithContext(r.Context()).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		Where("page_id = ?", page.ID).Order("created_at, id").Find(&deliveries).Error
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list deliveries")
+		return
+	}
+	data := make([]any, 0, len(deliveries))
+	for i := range deliveries {
+		data = append(data, deliveryResource(&deliveries[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// renderEscalationError maps escalation service errors to JSON:API errors.
+func renderEscalationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, escalation.ErrPolicyNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "escalation policy does not exist")
+	case errors.Is(err, escalation.ErrNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "page does not exist")
+	case errors.Is(err, incident.ErrNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "incident does not exist")
+	case errors.Is(err, escalation.ErrIncidentResolved):
+		jsonapi.RenderError(w, http.StatusConflict, "incident_resolved", "Conflict", "incident is resolved and cannot be paged")
+	case errors.Is(err, escalation.ErrAlreadyPaging):
+		jsonapi.RenderError(w, http.StatusConflict, "already_paging", "Conflict", "incident is already being paged with this policy")
+	case errors.Is(err, escalation.ErrPageClosed):
+		jsonapi.RenderError(w, http.StatusConflict, "page_closed", "Conflict", "page stopped because its incident was resolved")
+	default:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update page")
+	}
+}
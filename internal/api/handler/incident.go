@@ -0,0 +1,477 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// incidentSortColumns maps the sort keys accepted by GET /api/v1/incidents to
+// columns.
+var incidentSortColumns = map[string]string{
+	"created_at":  "created_at",
+	"declared_at": "declared_at",
+	"updated_at":  "updated_at",
+}
+
+const defaultIncidentSort = "-declared_at"
+
+const maxIncidentTitleLength = 200
+
+// IncidentHandler handles /api/v1/incidents routes.
+type IncidentHandler struct {
+	db        *gorm.DB
+	incidents *incident.Service
+}
+
+// NewIncidentHandler creates an IncidentHandler.
+func NewIncidentHandler(db *gorm.DB, incidents *incident.Service) *IncidentHandler {
+	return &IncidentHandler{db: db, incidents: incidents}
+}
+
+type incidentAttrs struct {
+	Title           string          `json:"title"`
+	Summary         string          `json:"summary"`
+	Severity        string          `json:"severity"`
+	Status          string          `json:"status"`
+	DeclaredBy      *string         `json:"declared_by"`
+	DeclaredAt      time.Time       `json:"declared_at"`
+	ResolvedAt      *time.Time      `json:"resolved_at"`
+	ResolvedBy      *string         `json:"resolved_by"`
+	CommanderID     *string         `json:"commander_id"`
+	ScribeID        *string         `json:"scribe_id"`
+	CommsLeadID     *string         `json:"comms_lead_id"`
+	ImpactStartedAt *time.Time      `json:"impact_started_at"`
+	ImpactEndedAt   *time.Time      `json:"impact_ended_at"`
+	DetectedAt      *time.Time      `json:"detected_at"`
+	AcknowledgedAt  *time.Time      `json:"acknowledged_at"`
+	MitigatedAt     *time.Time      `json:"mitigated_at"`
+	Metrics         incidentMetrics `json:"metrics"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// incidentMetrics renders model.IncidentMetrics in whole seconds; a measure
+// that cannot be computed yet is null.
+type incidentMetrics struct {
+	TimeToDetect      *int64 `json:"time_to_detect_seconds"`
+	TimeToAcknowledge *int64 `json:"time_to_acknowledge_seconds"`
+	TimeToMitigate    *int64 `json:"time_to_mitigate_seconds"`
+	TimeToResolve     *int64 `json:"time_to_resolve_seconds"`
+}
+
+func seconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	s := int64(d.Seconds())
+	return &s
+}
+
+func incidentResource(i *model.Incident) jsonapi.ResourceObject {
+	m := i.Metrics()
+	components := make([]jsonapi.ResourceIdentifier, 0, len(i.Components))
+	for _, c := range i.Components {
+		components = append(components, jsonapi.ResourceIdentifier{Type: "component", ID: c.ID})
+	}
+	return jsonapi.ResourceObject{
+		Type: "incident",
+		ID:   i.ID,
+		Attributes: incidentAttrs{
+			Title:           i.Title,
+			Summary:         i.Summary,
+			Severity:        i.Severity,
+			Status:          i.Status,
+			DeclaredBy:      i.DeclaredBy,
+			DeclaredAt:      i.DeclaredAt,
+			ResolvedAt:      i.ResolvedAt,
+			ResolvedBy:      i.ResolvedBy,
+			CommanderID:     i.CommanderID,
+			ScribeID:        i.ScribeID,
+			CommsLeadID:     i.CommsLeadID,
+			ImpactStartedAt: i.ImpactStartedAt,
+			ImpactEndedAt:   i.ImpactEndedAt,
+			DetectedAt:      i.DetectedAt,
+			AcknowledgedAt:  i.AcknowledgedAt,
+			MitigatedAt:     i.MitigatedAt,
+			Metrics: incidentMetrics{
+				TimeToDetect:      seconds(m.TimeToDetect),
+				TimeToAcknowledge: seconds(m.TimeToAcknowledge),
+				TimeToMitigate:    seconds(m.TimeToMitigate),
+				TimeToResolve:     seconds(m.TimeToResolve),
+			},
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.UpdatedAt,
+		},
+		Relationships: map[string]jsonapi.Relationship{
+			"components": {Data: components},
+		},
+	}
+}
+
+// incidentRequest is the body of POST and PATCH /api/v1/incidents requests.
+// Status is only accepted by PATCH. ComponentIDs replaces the affected
+// components; an empty list clears them.
+type incidentRequest struct {
+	Title           *string    `json:"title"`
+	Summary         *string    `json:"summary"`
+	Severity        *string    `json:"severity"`
+	Status          *string    `json:"status"`
+	ImpactStartedAt *time.Time `json:"impact_started_at"`
+	ImpactEndedAt   *time.Time `json:"impact_ended_at"`
+	DetectedAt      *time.Time `json:"detected_at"`
+	MitigatedAt     *time.Time `json:"mitigated_at"`
+	ComponentIDs    *[]string  `json:"component_ids"`
+}
+
+// updatesFields reports whether the request changes anything besides the
+// status.
+func (req *incidentRequest) updatesFields() bool {
+	return req.Title != nil || req.Summary != nil || req.Severity != nil ||
+		req.ImpactStartedAt != nil || req.ImpactEndedAt != nil || req.DetectedAt != nil ||
+		req.MitigatedAt != nil || req.ComponentIDs != nil
+}
+
+// apply validates the request and copies the editable fields onto inc.
+func (req *incidentRequest) apply(inc *model.Incident) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		switch {
+		case title == "":
+			errs = append(errs, fieldError("/title", "title must not be empty"))
+		case len(title) > maxIncidentTitleLength:
+			errs = append(errs, fieldError("/title", "title must be at most 200 characters"))
+		}
+		inc.Title = title
+	}
+	if req.Summary != nil {
+		inc.Summary = *req.Summary
+	}
+	if req.Severity != nil {
+		if !slices.Contains(model.Severities, *req.Severity) {
+			errs = append(errs, fieldError("/severity", "severity must be one of "+strings.Join(model.Severities, ", ")))
+		}
+		inc.Severity = *req.Severity
+	}
+	if req.Status != nil && !slices.Contains(model.IncidentStatuses, *req.Status) {
+		errs = append(errs, fieldError("/status", "status must be one of "+strings.Join(model.IncidentStatuses, ", ")))
+	}
+	if req.ImpactStartedAt != nil {
+		inc.ImpactStartedAt = req.ImpactStartedAt
+	}
+	if req.ImpactEndedAt != nil {
+		inc.ImpactEndedAt = req.ImpactEndedAt
+	}
+	if req.DetectedAt != nil {
+		inc.DetectedAt = req.DetectedAt
+	}
+	if req.MitigatedAt != nil {
+		inc.MitigatedAt = req.MitigatedAt
+	}
+	if inc.ImpactStartedAt != nil && inc.ImpactEndedAt != nil && inc.ImpactEndedAt.Before(*inc.ImpactStartedAt) {
+		errs = append(errs, fieldError("/impact_ended_at", "impact_ended_at must not be before impact_started_at"))
+	}
+	return errs
+}
+
+// components loads the catalog entries named by ids, in order. Unknown IDs
+// are reported as a field error.
+func (h *IncidentHandler) components(r *http.Request, ids []string) ([]model.Component, []jsonapi.ErrorObject, error) {
+	components := make([]model.Component, 0, len(ids))
+	if len(ids) == 0 {
+		return components, nil, nil
+	}
+	if err := h.db.WithContext(r.Context()).Where("id IN ?", ids).Order("name").Find(&components).Error; err != nil {
+		return nil, nil, err
+	}
+	for i, id := range ids {
+		if !slices.ContainsFunc(components, func(c model.Component) bool { return c.ID == id }) {
+			return nil, []jsonapi.ErrorObject{fieldError("/component_ids/"+strconv.Itoa(i), "component "+id+" does not exist")}, nil
+		}
+	}
+	return components, nil, nil
+}
+
+// Create handles POST /api/v1/incidents. New incidents start declared.
+func (h *IncidentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req incidentRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	var inc model.Incident
+	errs := req.apply(&inc)
+	if req.Title == nil {
+		errs = append(errs, fieldError("/title", "title is required"))
+	}
+	if req.Severity == nil {
+		errs = append(errs, fieldError("/severity", "severity is required"))
+	}
+	if req.Status != nil {
+		errs = append(errs, fieldError("/status", "new incidents are always declared; change the status afterwards"))
+	}
+	if req.MitigatedAt != nil {
+		errs = append(errs, fieldError("/mitigated_at", "new incidents are not mitigated yet"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	if !h.resolveComponents(w, r, &req, &inc) {
+		return
+	}
+
+	if orgID := claimsOrgID(r); orgID != "" {
+		inc.OrganizationID = &orgID
+	}
+	if err := h.incidents.Declare(r.Context(), &inc, claimsUserID(r)); err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create incident")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, incidentResource(&inc))
+}
+
+// List handles GET /api/v1/incidents.
+//
+// Supported query parameters: filter[status] and filter[severity]
+// (comma-separated), filter[open] (true or false), filter[commander_id] (a
+// user ID, or "me" for the caller, so filter[commander_id]=me&filter[open]=true
+// lists the incidents the caller currently commands), sort (created_at, declared_at or updated_at, prefixed
+// with '-' for descending; default -declared_at), page[size] and
+// page[cursor].
+func (h *IncidentHandler) List(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	sort, column, desc, err := parseSort(r, incidentSortColumns, defaultIncidentSort)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	page, err := parsePageFor(r, sort, now)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	query, err := h.filtered(r)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	incidents, pagination, links, err := keysetPage(r, query, page, sort, column, desc, now,
+		func(i *model.Incident) (time.Time, string) { return incidentSortKey(i, column), i.ID })
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list incidents")
+		return
+	}
+
+	data := make([]any, 0, len(incidents))
+	for i := range incidents {
+		data = append(data, incidentResource(&incidents[i]))
+	}
+	jsonapi.RenderPage(w, http.StatusOK, data, pagination, links)
+}
+
+// filtered applies the filter[...] query parameters to an incident query.
+func (h *IncidentHandler) filtered(r *http.Request) (*gorm.DB, error) {
+	q := r.URL.Query()
+	query := h.db.WithContext(r.Context()).Model(&model.Incident{}).Preload("Components", incident.OrderComponents)
+
+	if v := q.Get("filter[status]"); v != "" {
+		statuses := splitFilter(v)
+		for _, s := range statuses {
+			if !slices.Contains(model.IncidentStatuses, s) {
+				return nil, jsonapi.ParamError("filter[status]", "unknown incident status "+s)
+			}
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if v := q.Get("filter[severity]"); v != "" {
+		query = query.Where("severity IN ?", splitFilter(strings.ToUpper(v)))
+	}
+	if v := q.Get("filter[open]"); v != "" {
+		open, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, jsonapi.ParamError("filter[open]", "filter[open] must be true or false")
+		}
+		if open {
+			query = query.Where("status <> ?", model.IncidentStatusResolved)
+		} else {
+			query = query.Where("status = ?", model.IncidentStatusResolved)
+		}
+	}
+	if v := q.Get("filter[commander_id]"); v != "" {
+		if v == "me" {
+			me := claimsUserID(r)
+			if me == nil {
+				return nil, jsonapi.ParamError("filter[commander_id]", "filter[commander_id]=me needs an authenticated user")
+			}
+			v = *me
+		}
+		query = query.Where("commander_id = ?", v)
+	}
+	if v := q.Get("filter[component_id]"); v != "" {
+		query = query.Where("id IN (?)", h.db.Table("incident_components").
+			Select("incident_id").Where("component_id IN ?", splitFilter(v)))
+	}
+	return query, nil
+}
+
+func incidentSortKey(i *model.Incident, column string) time.Time {
+	switch column {
+	case "created_at":
+		return i.CreatedAt
+	case "updated_at":
+		return i.UpdatedAt
+	default:
+		return i.DeclaredAt
+	}
+}
+
+// Get handles GET /api/v1/incidents/{id}.
+func (h *IncidentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	inc, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, incidentResource(inc))
+}
+
+// Update handles PATCH /api/v1/incidents/{id}. A status change must follow
+// the incident lifecycle; an illegal one is rejected with 409 and nothing is
+// changed. Resolved incidents are reopened with POST .../reopen.
+func (h *IncidentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	inc, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req incidentRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if errs := req.apply(inc); len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	if !h.resolveComponents(w, r, &req, inc) {
+		return
+	}
+
+	var to string
+	if req.Status != nil {
+		to = *req.Status
+	}
+	if req.updatesFields() || (to != "" && to != inc.Status) {
+		if err := h.incidents.Update(r.Context(), inc, to, claimsUserID(r)); err != nil {
+			renderIncidentError(w, err)
+			return
+		}
+	}
+	jsonapi.RenderOne(w, http.StatusOK, incidentResource(inc))
+}
+
+// resolveComponents replaces inc.Components with the catalog entries named
+// by req.ComponentIDs, if given, rendering a 422 for unknown IDs.
+func (h *IncidentHandler) resolveComponents(w http.ResponseWriter, r *http.Request, req *incidentRequest, inc *model.Incident) bool {
+	if req.ComponentIDs == nil {
+		return true
+	}
+	components, errs, err := h.components(r, *req.ComponentIDs)
+	switch {
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load components")
+		return false
+	case len(errs) > 0:
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return false
+	}
+	inc.Components = components
+	return true
+}
+
+// Reopen handles POST /api/v1/incidents/{id}/reopen, moving a resolved
+// incident back to investigating.
+func (h *IncidentHandler) Reopen(w http.ResponseWriter, r *http.Request) {
+	inc, err := h.incidents.Reopen(r.Context(), r.PathValue("id"), claimsUserID(r))
+	if err != nil {
+		renderIncidentError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, incidentResource(inc))
+}
+
+type roleRequest struct {
+	UserID *string `json:"user_id"`
+}
+
+// AssignRole handles PUT /api/v1/incidents/{id}/roles/{role}, handing the
+// commander, scribe or comms_lead role to user_id. The previous holder is
+// kept on the timeline.
+func (h *IncidentHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if req.UserID == nil || *req.UserID == "" {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/user_id", "user_id is required")})
+		return
+	}
+	h.assignRole(w, r, req.UserID)
+}
+
+// UnassignRole handles DELETE /api/v1/incidents/{id}/roles/{role}.
+func (h *IncidentHandler) UnassignRole(w http.ResponseWriter, r *http.Request) {
+	h.assignRole(w, r, nil)
+}
+
+func (h *IncidentHandler) assignRole(w http.ResponseWriter, r *http.Request, userID *string) {
+	inc, err := h.incidents.AssignRole(r.Context(), r.PathValue("id"), r.PathValue("role"), userID, claimsUserID(r))
+	if err != nil {
+		renderIncidentError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, incidentResource(inc))
+}
+
+// renderIncidentError maps incident service errors to JSON:API errors.
+func renderIncidentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, incident.ErrNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "incident does not exist")
+	case errors.Is(err, incident.ErrUnknownRole):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found",
+			"incident role must be one of "+strings.Join(model.IncidentRoles, ", "))
+	case errors.Is(err, incident.ErrUserNotFound):
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/user_id", "user does not exist or is deactivated")})
+	case errors.Is(err, incident.ErrRoleChanged):
+		jsonapi.RenderError(w, http.StatusConflict, "role_changed", "Conflict", "the role was reassigned concurrently; reload the incident and retry")
+	case errors.Is(err, incident.ErrInvalidTransition):
+		jsonapi.RenderError(w, http.StatusConflict, "invalid_transition", "Conflict", err.Error())
+	default:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update incident")
+	}
+}
+
+func (h *IncidentHandler) load(w http.ResponseWriter, r *http.Request) (*model.Incident, bool) {
+	inc, err := h.incidents.Get(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, incident.ErrNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "incident does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load incident")
+		return nil, false
+	}
+	return inc, true
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"gorm.io/gorm"
+)
+
+// parseSort reads the sort query parameter, defaulting to def. columns maps
+// the accepted sort keys to timestamp columns; a leading '-' sorts
+// descending.
+func parseSort(r *http.Request, columns map[string]string, def string) (sort, column string, desc bool, err error) {
+	sort = r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = def
+	}
+	column, ok := columns[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		keys := make([]string, 0, len(columns))
+		for k := range columns {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		return "", "", false, jsonapi.ParamError("sort",
+			"sort must be one of "+strings.Join(keys, ", ")+", optionally prefixed with '-'")
+	}
+	return sort, column, strings.HasPrefix(sort, "-"), nil
+}
+
+// keysetPage loads one page of query ordered by (column, id) and builds the
+// pagination block and next/prev links. key returns a row's sort-column value
+// and ID. The cursor in page must have been issued for sort.
+func keysetPage[T any](r *http.Request, query *gorm.DB, page jsonapi.PageParams, sort, column string, desc bool, now time.Time, key func(*T) (time.Time, string)) ([]T, *jsonapi.Pagination, *jsonapi.Links, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Paging backwards walks the index in the opposite direction and
+	// reverses the rows afterwards.
+	backward := page.Cursor != nil && page.Cursor.Before
+	walkDesc := desc != backward
+	cmp, dir := ">", "ASC"
+	if walkDesc {
+		cmp, dir = "<", "DESC"
+	}
+	if c := page.Cursor; c != nil {
+		query = query.Where("("+column+" "+cmp+" ?) OR ("+column+" = ? AND id "+cmp+" ?)", c.Key, c.Key, c.ID)
+	}
+
+	var rows []T
+	if err := query.
+		Order(column + " " + dir).Order("id " + dir).
+		Limit(page.Size + 1).
+		Find(&rows).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	more := len(rows) > page.Size
+	if more {
+		rows = rows[:page.Size]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	links := &jsonapi.Links{Self: r.URL.RequestURI()}
+	pagination := &jsonapi.Pagination{PageSize: page.Size, Total: int(total)}
+	if len(rows) > 0 {
+		if more || backward {
+			k, id := key(&rows[len(rows)-1])
+			next := jsonapi.Cursor{Sort: sort, Key: k, ID: id, IssuedAt: now.Unix()}.Encode()
+			links.Next = jsonapi.PageLink(r, next)
+			pagination.Cursor = next
+		}
+		if (more && backward) || (page.Cursor != nil && !backward) {
+			k, id := key(&rows[0])
+			prev := jsonapi.Cursor{Sort: sort, Key: k, ID: id, Before: true, IssuedAt: now.Unix()}.Encode()
+			links.Prev = jsonapi.PageLink(r, prev)
+		}
+	}
+	return rows, pagination, links, nil
+}
+
+// parsePageFor reads the page parameters and rejects cursors issued for a
+// different sort.
+func parsePageFor(r *http.Request, sort string, now time.Time) (jsonapi.PageParams, error) {
+	page, err := jsonapi.ParsePage(r, now)
+	if err != nil {
+		return page, err
+	}
+	if page.Cursor != nil && page.Cursor.Sort != sort {
+		return page, jsonapi.ParamError("page[cursor]", "page[cursor] was issued for a different sort")
+	}
+	return page, nil
+}
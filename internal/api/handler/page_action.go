@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/api/middleware"
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// pageActionPermission is the permission a link's user needs, the same as
+// for acknowledging a page or resolving an incident through the API.
+const pageActionPermission = "incident:update"
+
+// CanUsePageLinks reports whether a user with roles may act on pages through
+// notification links.
+func CanUsePageLinks(roles []string) bool {
+	return middleware.RolesGrant(roles, pageActionPermission)
+}
+
+// PageActionHandler handles the links in page notifications. They carry a
+// single-use page token instead of an access token, so responders can act
+// straight from a chat message or email. Opening a link only shows a
+// confirmation page, which posts back to the same URL to use the token, so
+// link scanners and previews fetching it change nothing.
+type PageActionHandler struct {
+	db         *gorm.DB
+	tokens     *auth.PageTokenStore
+	escalation *escalation.Service
+	incidents  *incident.Service
+}
+
+// NewPageActionHandler creates a PageActionHandler.
+func NewPageActionHandler(db *gorm.DB, tokens *auth.PageTokenStore, esc *escalation.Service, incidents *incident.Service) *PageActionHandler {
+	return &PageActionHandler{db: db, tokens: tokens, escalation: esc, incidents: incidents}
+}
+
+// confirmPage asks the user to confirm a page action. The form posts back to
+// the URL the page was served from.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.}} · Autopsy</title>
+</head>
+<body>
+<form method="post"><button type="submit">{{.}}</button></form>
+</body>
+</html>
+`))
+
+// ConfirmAck handles GET /api/v1/pages/{token}/ack.
+func (h *PageActionHandler) ConfirmAck(w http.ResponseWriter, r *http.Request) {
+	confirm(w, "Acknowledge page")
+}
+
+// ConfirmResolve handles GET /api/v1/pages/{token}/resolve.
+func (h *PageActionHandler) ConfirmResolve(w http.ResponseWriter, r *http.Request) {
+	confirm(w, "Resolve incident")
+}
+
+// confirm renders confirmPage for action without touching the token.
+func confirm(w http.ResponseWriter, action string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// The URL holds the token.
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := confirmPage.Execute(w, action); err != nil {
+		slog.Error("render page action confirmation", "err", err)
+	}
+}
+
+// Ack handles POST /api/v1/pages/{token}/ack, acknowledging the page on
+// behalf of the user the link was sent to.
+func (h *PageActionHandler) Ack(w http.ResponseWriter, r *http.Request) {
+	pt, ok := h.redeem(w, r, model.PageActionAck)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	page, err := h.escalation.Acknowledge(ctx, pt.PageID, &pt.UserID)
+	if err != nil {
+		h.release(ctx, pt)
+		renderEscalationError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, pageResource(page))
+}
+
+// Resolve handles POST /api/v1/pages/{token}/resolve, acknowledging the page
+// if it still is unacknowledged and resolving its incident on behalf of the
+// user the link was sent to.
+func (h *PageActionHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	pt, ok := h.redeem(w, r, model.PageActionResolve)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	page, err := h.escalation.Acknowledge(ctx, pt.PageID, &pt.UserID)
+	if errors.Is(err, escalation.ErrPageClosed) {
+		// The incident is resolved already.
+		page, err = h.escalation.Get(ctx, pt.PageID)
+	}
+	if err != nil {
+		h.release(ctx, pt)
+		renderEscalationError(w, err)
+		return
+	}
+	if _, err := h.incidents.Transition(ctx, page.IncidentID, model.IncidentStatusResolved, &pt.UserID); err != nil {
+		h.release(ctx, pt)
+		renderIncidentError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, pageResource(page))
+}
+
+// redeem uses up the request's token for action and returns it. Tokens of
+// deactivated users are refused, and so are those of users whose roles do
+// not allow acknowledging pages and resolving incidents through the API. A
+// token whose action then fails must be given back with release, so the link
+// can be tried again.
+func (h *PageActionHandler) redeem(w http.ResponseWriter, r *http.Request, action string) (*model.PageToken, bool) {
+	ctx := r.Context()
+	pt, err := h.tokens.Redeem(ctx, r.PathValue("token"), action)
+	switch {
+	case errors.Is(err, auth.ErrPageTokenInvalid):
+		jsonapi.RenderError(w, http.StatusNotFound, "invalid_token", "Not Found", "link is invalid")
+		return nil, false
+	case errors.Is(err, auth.ErrPageTokenUsed):
+		jsonapi.RenderError(w, http.StatusGone, "token_used", "Gone", "link has already been used")
+		return nil, false
+	case errors.Is(err, auth.ErrPageTokenExpired):
+		jsonapi.RenderError(w, http.StatusGone, "token_expired", "Gone", "link has expired")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to check link")
+		return nil, false
+	}
+	user, err := h.activeUser(ctx, pt.UserID)
+	switch {
+	case err != nil:
+		h.release(ctx, pt)
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load user")
+		return nil, false
+	case user == nil:
+		jsonapi.RenderError(w, http.StatusNotFound, "invalid_token", "Not Found", "link is invalid")
+		return nil, false
+	case !CanUsePageLinks(user.Roles):
+		jsonapi.RenderError(w, http.StatusForbidden, "forbidden", "Forbidden",
+			"your roles do not grant the '"+pageActionPermission+"' permission")
+		return nil, false
+	}
+	return pt, true
+}
+
+// release gives pt back after its action failed. Failing to do so only
+// costs the user the link, so it is logged rather than reported.
+func (h *PageActionHandler) release(ctx context.Context, pt *model.PageToken) {
+	// Release even if the client has gone away.
+	if err := h.tokens.Release(context.WithoutCancel(ctx), pt); err != nil {
+		slog.ErrorContext(ctx, "release page token", "page_id", pt.PageID, "err", err)
+	}
+}
+
+// activeUser loads user id, or returns nil if it does not exist or is
+// deactivated.
+func (h *PageActionHandler) activeUser(ctx context.Context, id string) (*model.User, error) {
+	var users []model.User
+	err := h.db.WithContext(ctx).
+		Where("id = ? AND deactivated_at IS NULL", id).
+		Limit(1).Find(&users).Error
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	return &users[0], nil
+}
@@ -0,0 +1,185 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/handler"
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type pageActionFixture struct {
+	db     *gorm.DB
+	tokens *auth.PageTokenStore
+	h      *handler.PageActionHandler
+	page   *model.Page
+}
+
+// setupPageAction pages alice for a new incident.
+func setupPageAction(t *testing.T) *pageActionFixture {
+	t.Helper()
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	dbtest.CreateAlice(t, gormDB)
+	policy := &model.EscalationPolicy{Name: "Checkout", Tiers: []model.EscalationTier{
+		{Targets: []model.EscalationTarget{{Type: model.EscalationTargetUser, ID: "alice"}}, TimeoutMinutes: 5},
+	}}
+	require.NoError(t, gormDB.Create(policy).Error)
+	incidents := incident.NewService(gormDB)
+	inc := &model.Incident{Title: "Checkout errors", Severity: model.SeveritySEV2}
+	require.NoError(t, incidents.Declare(ctx, inc, nil))
+
+	pages := escalation.NewService(gormDB, oncall.NewService(gormDB))
+	page, _, err := pages.Start(ctx, inc.ID, policy.ID, nil, nil)
+	require.NoError(t, err)
+
+	tokens := auth.NewPageTokenStore(gormDB, testSecret)
+	return &pageActionFixture{
+		db:     gormDB,
+		tokens: tokens,
+		h:      handler.NewPageActionHandler(gormDB, tokens, pages, incidents),
+		page:   page,
+	}
+}
+
+// issue returns a token for action on pageID on behalf of alice.
+func (f *pageActionFixture) issue(t *testing.T, pageID, action string, ttl time.Duration) string {
+	t.Helper()
+	token, err := f.tokens.Issue(context.Background(), pageID, "alice", action, ttl)
+	require.NoError(t, err)
+	return token
+}
+
+// follow confirms the link for token.
+func (f *pageActionFixture) follow(t *testing.T, fn http.HandlerFunc, token, action string) (int, *document) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/pages/"+token+"/"+action, nil)
+	r.SetPathValue("token", token)
+	return serve(t, fn, r)
+}
+
+func pageStatus(t *testing.T, doc *document) string {
+	t.Helper()
+	var res struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status         string  `json:"status"`
+			AcknowledgedBy *string `json:"acknowledged_by"`
+		} `json:"attributes"`
+	}
+	require.NoError(t, json.Unmarshal(doc.Data, &res))
+	require.NotNil(t, res.Attributes.AcknowledgedBy)
+	assert.Equal(t, "alice", *res.Attributes.AcknowledgedBy)
+	return res.Attributes.Status
+}
+
+func TestPageAction_Ack(t *testing.T) {
+	f := setupPageAction(t)
+	token := f.issue(t, f.page.ID, model.PageActionAck, time.Hour)
+
+	code, doc := f.follow(t, f.h.Ack, token, "ack")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, model.PageStatusAcknowledged, pageStatus(t, doc))
+
+	code, doc = f.follow(t, f.h.Ack, token, "ack")
+	assert.Equal(t, http.StatusGone, code)
+	require.Len(t, doc.Errors, 1)
+	assert.Equal(t, "token_used", doc.Errors[0].Code)
+}
+
+func TestPageAction_OpeningLinkOnlyConfirms(t *testing.T) {
+	f := setupPageAction(t)
+	token := f.issue(t, f.page.ID, model.PageActionAck, time.Hour)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/pages/"+token+"/ack", nil)
+	r.SetPathValue("token", token)
+	f.h.ConfirmAck(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+
+	var pt model.PageToken
+	require.NoError(t, f.db.First(&pt, "page_id = ?", f.page.ID).Error)
+	assert.Nil(t, pt.UsedAt, "fetching the link does not use the token")
+
+	code, doc := f.follow(t, f.h.Ack, token, "ack")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, model.PageStatusAcknowledged, pageStatus(t, doc))
+}
+
+func TestPageAction_Resolve(t *testing.T) {
+	f := setupPageAction(t)
+	token := f.issue(t, f.page.ID, model.PageActionResolve, time.Hour)
+
+	code, doc := f.follow(t, f.h.Resolve, token, "resolve")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, model.PageStatusAcknowledged, pageStatus(t, doc))
+
+	var inc model.Incident
+	require.NoError(t, f.db.First(&inc, "id = ?", f.page.IncidentID).Error)
+	assert.Equal(t, model.IncidentStatusResolved, inc.Status)
+}
+
+func TestPageAction_RejectsBadLinks(t *testing.T) {
+	f := setupPageAction(t)
+	ack := f.issue(t, f.page.ID, model.PageActionAck, time.Hour)
+
+	for name, tc := range map[string]struct {
+		fn     http.HandlerFunc
+		token  string
+		status int
+		code   string
+	}{
+		"invalid":      {f.h.Ack, "not-a-token", http.StatusNotFound, "invalid_token"},
+		"wrong action": {f.h.Resolve, ack, http.StatusNotFound, "invalid_token"},
+		"expired":      {f.h.Ack, f.issue(t, f.page.ID, model.PageActionAck, -time.Second), http.StatusGone, "token_expired"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, doc := f.follow(t, tc.fn, tc.token, "ack")
+			assert.Equal(t, tc.status, code)
+			require.Len(t, doc.Errors, 1)
+			assert.Equal(t, tc.code, doc.Errors[0].Code)
+		})
+	}
+
+	require.NoError(t, f.db.Model(&model.User{}).Where("id = ?", "alice").Update("roles", `["Viewer"]`).Error)
+	code, doc := f.follow(t, f.h.Resolve, f.issue(t, f.page.ID, model.PageActionResolve, time.Hour), "resolve")
+	assert.Equal(t, http.StatusForbidden, code, "viewers cannot act on pages")
+	require.Len(t, doc.Errors, 1)
+	assert.Equal(t, "forbidden", doc.Errors[0].Code)
+
+	require.NoError(t, f.db.Model(&model.User{}).Where("id = ?", "alice").Update("deactivated_at", time.Now()).Error)
+	code, doc = f.follow(t, f.h.Ack, ack, "ack")
+	assert.Equal(t, http.StatusNotFound, code, "links of deactivated users stop working")
+	require.Len(t, doc.Errors, 1)
+	assert.Equal(t, "invalid_token", doc.Errors[0].Code)
+}
+
+func TestPageAction_FailureReleasesToken(t *testing.T) {
+	f := setupPageAction(t)
+	token := f.issue(t, "missing", model.PageActionAck, time.Hour)
+
+	for range 2 {
+		code, doc := f.follow(t, f.h.Ack, token, "ack")
+		assert.Equal(t, http.StatusNotFound, code)
+		require.Len(t, doc.Errors, 1)
+		assert.Equal(t, "not_found", doc.Errors[0].Code, "the link can be tried again")
+	}
+
+	var pt model.PageToken
+	require.NoError(t, f.db.First(&pt, "page_id = ?", "missing").Error)
+	assert.Nil(t, pt.UsedAt)
+}
@@ -0,0 +1,682 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"gorm.io/gorm"
+)
+
+const (
+	maxScheduleNameLength = 100
+	// maxShiftLengthHours caps custom rotations at four weeks per shift.
+	maxShiftLengthHours = 4 * 7 * 24
+	maxRotationLayer    = 100
+	maxRestrictions     = 50
+	// defaultShiftRange and maxShiftRange bound GET .../shifts.
+	defaultShiftRange = 7 * 24 * time.Hour
+	maxShiftRange     = 92 * 24 * time.Hour
+)
+
+// ScheduleHandler handles /api/v1/schedules routes.
+type ScheduleHandler struct {
+	db     *gorm.DB
+	oncall *oncall.Service
+}
+
+// NewScheduleHandler creates a ScheduleHandler.
+func NewScheduleHandler(db *gorm.DB, oncall *oncall.Service) *ScheduleHandler {
+	return &ScheduleHandler{db: db, oncall: oncall}
+}
+
+type scheduleAttrs struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Timezone    string          `json:"timezone"`
+	Rotations   []rotationAttrs `json:"rotations"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type rotationAttrs struct {
+	// ID is only set when the rotation is embedded in a schedule.
+	ID               string                  `json:"id,omitempty"`
+	ScheduleID       string                  `json:"schedule_id"`
+	Name             string                  `json:"name"`
+	Type             string                  `json:"type"`
+	ShiftLengthHours int                     `json:"shift_length_hours"`
+	StartAt          time.Time               `json:"start_at"`
+	ParticipantIDs   []string                `json:"participant_ids"`
+	Layer            int                     `json:"layer"`
+	Restrictions     []model.TimeRestriction `json:"restrictions"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+}
+
+func newRotationAttrs(r *model.Rotation) rotationAttrs {
+	restrictions := r.Restrictions
+	if restrictions == nil {
+		restrictions = []model.TimeRestriction{}
+	}
+	return rotationAttrs{
+		ScheduleID:       r.ScheduleID,
+		Name:             r.Name,
+		Type:             r.Type,
+		ShiftLengthHours: r.ShiftHours(),
+		StartAt:          r.StartAt,
+		ParticipantIDs:   r.ParticipantIDs,
+		Layer:            r.Layer,
+		Restrictions:     restrictions,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
+}
+
+func scheduleResource(s *model.OnCallSchedule) jsonapi.ResourceObject {
+	rotations := make([]rotationAttrs, 0, len(s.Rotations))
+	for i := range s.Rotations {
+		attrs := newRotationAttrs(&s.Rotations[i])
+		attrs.ID = s.Rotations[i].ID
+		rotations = append(rotations, attrs)
+	}
+	return jsonapi.ResourceObject{
+		Type: "schedule",
+		ID:   s.ID,
+		Attributes: scheduleAttrs{
+			Name:        s.Name,
+			Description: s.Description,
+			Timezone:    s.Timezone,
+			Rotations:   rotations,
+			CreatedAt:   s.CreatedAt,
+			UpdatedAt:   s.UpdatedAt,
+		},
+	}
+}
+
+func rotationResource(r *model.Rotation) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type:       "rotation",
+		ID:         r.ID,
+		Attributes: newRotationAttrs(r),
+	}
+}
+
+type overrideAttrs struct {
+	ScheduleID string    `json:"schedule_id"`
+	UserID     string    `json:"user_id"`
+	CreatedBy  *string   `json:"created_by"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func overrideResource(o *model.Override) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "override",
+		ID:   o.ID,
+		Attributes: overrideAttrs{
+			ScheduleID: o.ScheduleID,
+			UserID:     o.UserID,
+			CreatedBy:  o.CreatedBy,
+			StartAt:    o.StartAt,
+			EndAt:      o.EndAt,
+			CreatedAt:  o.CreatedAt,
+		},
+	}
+}
+
+type shiftAttrs struct {
+	UserID     string    `json:"user_id"`
+	RotationID *string   `json:"rotation_id"`
+	OverrideID *string   `json:"override_id"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+}
+
+func shiftResource(s *oncall.Shift) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "oncall_shift",
+		ID:   s.ID,
+		Attributes: shiftAttrs{
+			UserID:     s.UserID,
+			RotationID: s.RotationID,
+			OverrideID: s.OverrideID,
+			StartAt:    s.Start,
+			EndAt:      s.End,
+		},
+	}
+}
+
+type scheduleRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Timezone    *string `json:"timezone"`
+}
+
+// apply validates the request and copies the editable fields onto s.
+func (req *scheduleRequest) apply(s *model.OnCallSchedule) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fieldError("/name", "name must not be empty"))
+		case len(name) > maxScheduleNameLength:
+			errs = append(errs, fieldError("/name", "name must be at most 100 characters"))
+		}
+		s.Name = name
+	}
+	if req.Description != nil {
+		s.Description = *req.Description
+	}
+	if req.Timezone != nil {
+		if _, err := oncall.LoadLocation(*req.Timezone); err != nil {
+			errs = append(errs, fieldError("/timezone", "timezone must be an IANA time zone name such as Europe/London"))
+		}
+		s.Timezone = *req.Timezone
+	}
+	return errs
+}
+
+// Create handles POST /api/v1/schedules. timezone defaults to UTC.
+func (h *ScheduleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req scheduleRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	sched := model.OnCallSchedule{Timezone: "UTC"}
+	errs := req.apply(&sched)
+	if req.Name == nil {
+		errs = append(errs, fieldError("/name", "name is required"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if orgID := claimsOrgID(r); orgID != "" {
+		sched.OrganizationID = &orgID
+	}
+	err := h.db.WithContext(r.Context()).Create(&sched).Error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a schedule with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create schedule")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, scheduleResource(&sched))
+}
+
+// List handles GET /api/v1/schedules, ordered by name.
+func (h *ScheduleHandler) List(w http.ResponseWriter, r *http.Request) {
+	var schedules []model.OnCallSchedule
+	err := h.db.WithContext(r.Context()).
+		Preload("Rotations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Order("name").Find(&schedules).Error
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list schedules")
+		return
+	}
+	data := make([]any, 0, len(schedules))
+	for i := range schedules {
+		data = append(data, scheduleResource(&schedules[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Get handles GET /api/v1/schedules/{id}.
+func (h *ScheduleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, scheduleResource(sched))
+}
+
+// Update handles PATCH /api/v1/schedules/{id}. Changing the time zone moves
+// every rotation's handoffs to the same wall-clock time in the new zone.
+func (h *ScheduleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req scheduleRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	oldTimezone := sched.Timezone
+	if errs := req.apply(sched); len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	err := h.db.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(sched).Select("name", "description", "timezone", "updated_at").Updates(sched).Error; err != nil {
+			return err
+		}
+		if sched.Timezone == oldTimezone {
+			return nil
+		}
+		if err := oncall.Rezone(sched, oldTimezone); err != nil {
+			return err
+		}
+		for i := range sched.Rotations {
+			rot := &sched.Rotations[i]
+			if err := tx.Model(rot).Select("start_at", "updated_at").Updates(rot).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a schedule with this name already exists")
+		return
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update schedule")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, scheduleResource(sched))
+}
+
+// OnCall handles GET /api/v1/schedules/{id}/oncall, listing the shifts that
+// cover the instant given by at (RFC 3339, default now).
+func (h *ScheduleHandler) OnCall(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			jsonapi.RenderDecodeError(w, jsonapi.ParamError("at", "at must be an RFC 3339 timestamp"))
+			return
+		}
+		at = t
+	}
+
+	sched, shifts, err := h.oncall.OnCall(r.Context(), r.PathValue("id"), at.UTC())
+	if err != nil {
+		renderScheduleError(w, err)
+		return
+	}
+	data := make([]any, 0, len(shifts))
+	for i := range shifts {
+		data = append(data, shiftResource(&shifts[i]))
+	}
+	jsonapi.Render(w, http.StatusOK, jsonapi.ListDocument{
+		Data: data,
+		Meta: jsonapi.Meta{"at": at, "timezone": sched.Timezone},
+	})
+}
+
+// Shifts handles GET /api/v1/schedules/{id}/shifts, rendering the final
+// shifts between from and to (RFC 3339; default now and a week later, at
+// most 92 days apart) after layers, restrictions and overrides. meta.gaps
+// lists the periods with nobody on call.
+func (h *ScheduleHandler) Shifts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from := time.Now()
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			jsonapi.RenderDecodeError(w, jsonapi.ParamError("from", "from must be an RFC 3339 timestamp"))
+			return
+		}
+		from = t
+	}
+	to := from.Add(defaultShiftRange)
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			jsonapi.RenderDecodeError(w, jsonapi.ParamError("to", "to must be an RFC 3339 timestamp"))
+			return
+		}
+		to = t
+	}
+	switch {
+	case !to.After(from):
+		jsonapi.RenderDecodeError(w, jsonapi.ParamError("to", "to must be after from"))
+		return
+	case to.Sub(from) > maxShiftRange:
+		jsonapi.RenderDecodeError(w, jsonapi.ParamError("to", "from and to must be at most 92 days apart"))
+		return
+	}
+
+	sched, shifts, gaps, err := h.oncall.Shifts(r.Context(), r.PathValue("id"), from.UTC(), to.UTC())
+	if err != nil {
+		renderScheduleError(w, err)
+		return
+	}
+	data := make([]any, 0, len(shifts))
+	for i := range shifts {
+		data = append(data, shiftResource(&shifts[i]))
+	}
+	gapList := make([]map[string]time.Time, 0, len(gaps))
+	for _, g := range gaps {
+		gapList = append(gapList, map[string]time.Time{"start_at": g.Start, "end_at": g.End})
+	}
+	jsonapi.Render(w, http.StatusOK, jsonapi.ListDocument{
+		Data: data,
+		Meta: jsonapi.Meta{"from": from, "to": to, "timezone": sched.Timezone, "gaps": gapList},
+	})
+}
+
+type rotationRequest struct {
+	Name             *string                  `json:"name"`
+	Type             *string                  `json:"type"`
+	ShiftLengthHours *int                     `json:"shift_length_hours"`
+	StartAt          *time.Time               `json:"start_at"`
+	ParticipantIDs   *[]string                `json:"participant_ids"`
+	Layer            *int                     `json:"layer"`
+	Restrictions     *[]model.TimeRestriction `json:"restrictions"`
+}
+
+// apply validates the request and copies the editable fields onto rot.
+func (req *rotationRequest) apply(rot *model.Rotation) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		rot.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil {
+		if !slices.Contains(model.RotationTypes, *req.Type) {
+			errs = append(errs, fieldError("/type", "type must be one of "+strings.Join(model.RotationTypes, ", ")))
+		}
+		rot.Type = *req.Type
+		if rot.Type != model.RotationCustom {
+			rot.ShiftLengthHours = 0
+		}
+	}
+	if req.ShiftLengthHours != nil {
+		rot.ShiftLengthHours = *req.ShiftLengthHours
+	}
+	switch {
+	case rot.Type == model.RotationCustom && (rot.ShiftLengthHours < 1 || rot.ShiftLengthHours > maxShiftLengthHours):
+		errs = append(errs, fieldError("/shift_length_hours", fmt.Sprintf("custom rotations need shift_length_hours between 1 and %d", maxShiftLengthHours)))
+	case rot.Type != model.RotationCustom && req.ShiftLengthHours != nil:
+		errs = append(errs, fieldError("/shift_length_hours", "shift_length_hours only applies to custom rotations"))
+	}
+	if req.StartAt != nil {
+		rot.StartAt = req.StartAt.UTC()
+	}
+	if req.ParticipantIDs != nil {
+		if len(*req.ParticipantIDs) == 0 {
+			errs = append(errs, fieldError("/participant_ids", "a rotation needs at least one participant"))
+		}
+		rot.ParticipantIDs = *req.ParticipantIDs
+	}
+	if req.Layer != nil {
+		if *req.Layer < 0 || *req.Layer > maxRotationLayer {
+			errs = append(errs, fieldError("/layer", fmt.Sprintf("layer must be between 0 and %d", maxRotationLayer)))
+		}
+		rot.Layer = *req.Layer
+	}
+	if req.Restrictions != nil {
+		if len(*req.Restrictions) > maxRestrictions {
+			errs = append(errs, fieldError("/restrictions", fmt.Sprintf("a rotation takes at most %d restrictions", maxRestrictions)))
+		}
+		for i, r := range *req.Restrictions {
+			if err := oncall.ValidateRestriction(r); err != nil {
+				errs = append(errs, fieldError(fmt.Sprintf("/restrictions/%d", i), err.Error()))
+			}
+			if r.Days == nil {
+				(*req.Restrictions)[i].Days = []string{}
+			}
+		}
+		rot.Restrictions = *req.Restrictions
+	}
+	return errs
+}
+
+// CreateRotation handles POST /api/v1/schedules/{id}/rotations. type,
+// start_at and participant_ids are required; participants take shifts in
+// the order given, starting at start_at. layer (default 0) and
+// restrictions layer rotations: at any instant the highest layer with
+// someone on call wins.
+func (h *ScheduleHandler) CreateRotation(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req rotationRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	rot := model.Rotation{ScheduleID: sched.ID}
+	errs := req.apply(&rot)
+	if req.Type == nil {
+		errs = append(errs, fieldError("/type", "type is required"))
+	}
+	if req.StartAt == nil {
+		errs = append(errs, fieldError("/start_at", "start_at is required"))
+	}
+	if req.ParticipantIDs == nil {
+		errs = append(errs, fieldError("/participant_ids", "participant_ids is required"))
+	}
+	if !h.validateRotation(w, r, errs, rot.ParticipantIDs) {
+		return
+	}
+
+	if err := h.db.WithContext(r.Context()).Create(&rot).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create rotation")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, rotationResource(&rot))
+}
+
+// UpdateRotation handles PATCH /api/v1/schedules/{id}/rotations/{rotation_id}.
+func (h *ScheduleHandler) UpdateRotation(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+	var req rotationRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if !h.validateRotation(w, r, req.apply(rot), rot.ParticipantIDs) {
+		return
+	}
+	if err := h.db.WithContext(r.Context()).Save(rot).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update rotation")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, rotationResource(rot))
+}
+
+// DeleteRotation handles DELETE /api/v1/schedules/{id}/rotations/{rotation_id}.
+func (h *ScheduleHandler) DeleteRotation(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+	if err := h.db.WithContext(r.Context()).Delete(rot).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to delete rotation")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateRotation renders errs, plus an error for each participant that is
+// not an active user, as a 422. It reports whether the rotation is valid.
+func (h *ScheduleHandler) validateRotation(w http.ResponseWriter, r *http.Request, errs []jsonapi.ErrorObject, participantIDs []string) bool {
+	unknown, err := h.unknownUsers(r.Context(), participantIDs)
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load users")
+		return false
+	}
+	for _, i := range unknown {
+		errs = append(errs, fieldError(fmt.Sprintf("/participant_ids/%d", i), "user "+participantIDs[i]+" does not exist or is deactivated"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return false
+	}
+	return true
+}
+
+// unknownUsers returns the indexes of ids that are not active users.
+func (h *ScheduleHandler) unknownUsers(ctx context.Context, ids []string) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []string
+	if err := h.db.WithContext(ctx).Model(&model.User{}).
+		Where("id IN ? AND deactivated_at IS NULL", ids).
+		Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	var unknown []int
+	for i, id := range ids {
+		if !slices.Contains(found, id) {
+			unknown = append(unknown, i)
+		}
+	}
+	return unknown, nil
+}
+
+type overrideRequest struct {
+	UserID  *string    `json:"user_id"`
+	StartAt *time.Time `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+// ListOverrides handles GET /api/v1/schedules/{id}/overrides, listing the
+// overrides that have not ended yet by start time.
+func (h *ScheduleHandler) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var overrides []model.Override
+	if err := h.db.WithContext(r.Context()).
+		Where("schedule_id = ? AND end_at > ?", sched.ID, time.Now().UTC()).
+		Order("start_at, id").Find(&overrides).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list overrides")
+		return
+	}
+	data := make([]any, 0, len(overrides))
+	for i := range overrides {
+		data = append(data, overrideResource(&overrides[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// CreateOverride handles POST /api/v1/schedules/{id}/overrides, putting
+// user_id on call from start_at until end_at.
+func (h *ScheduleHandler) CreateOverride(w http.ResponseWriter, r *http.Request) {
+	sched, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req overrideRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	var errs []jsonapi.ErrorObject
+	if req.UserID == nil || *req.UserID == "" {
+		errs = append(errs, fieldError("/user_id", "user_id is required"))
+	} else {
+		unknown, err := h.unknownUsers(r.Context(), []string{*req.UserID})
+		if err != nil {
+			jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load users")
+			return
+		}
+		if len(unknown) > 0 {
+			errs = append(errs, fieldError("/user_id", "user does not exist or is deactivated"))
+		}
+	}
+	if req.StartAt == nil {
+		errs = append(errs, fieldError("/start_at", "start_at is required"))
+	}
+	switch {
+	case req.EndAt == nil:
+		errs = append(errs, fieldError("/end_at", "end_at is required"))
+	case req.StartAt != nil && !req.EndAt.After(*req.StartAt):
+		errs = append(errs, fieldError("/end_at", "end_at must be after start_at"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	o := model.Override{
+		ScheduleID: sched.ID,
+		UserID:     *req.UserID,
+		CreatedBy:  claimsUserID(r),
+		StartAt:    req.StartAt.UTC(),
+		EndAt:      req.EndAt.UTC(),
+	}
+	if err := h.db.WithContext(r.Context()).Create(&o).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create override")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, overrideResource(&o))
+}
+
+// DeleteOverride handles DELETE /api/v1/schedules/{id}/overrides/{override_id}.
+func (h *ScheduleHandler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	res := h.db.WithContext(r.Context()).
+		Where("id = ? AND schedule_id = ?", r.PathValue("override_id"), r.PathValue("id")).
+		Delete(&model.Override{})
+	switch {
+	case res.Error != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to delete override")
+	case res.RowsAffected == 0:
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "override does not exist")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// renderScheduleError maps oncall service errors to JSON:API errors.
+func renderScheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, oncall.ErrNotFound) {
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "schedule does not exist")
+		return
+	}
+	jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to resolve on-call users")
+}
+
+func (h *ScheduleHandler) load(w http.ResponseWriter, r *http.Request) (*model.OnCallSchedule, bool) {
+	sched, err := h.oncall.Get(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, oncall.ErrNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "schedule does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load schedule")
+		return nil, false
+	}
+	return sched, true
+}
+
+func (h *ScheduleHandler) loadRotation(w http.ResponseWriter, r *http.Request) (*model.Rotation, bool) {
+	var rot model.Rotation
+	err := h.db.WithContext(r.Context()).
+		Where("id = ? AND schedule_id = ?", r.PathValue("rotation_id"), r.PathValue("id")).
+		First(&rot).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "rotation does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load rotation")
+		return nil, false
+	}
+	return &rot, true
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// SilenceHandler handles /api/v1/silences routes.
+type SilenceHandler struct {
+	db *gorm.DB
+}
+
+// NewSilenceHandler creates a SilenceHandler.
+func NewSilenceHandler(db *gorm.DB) *SilenceHandler {
+	return &SilenceHandler{db: db}
+}
+
+type silenceAttrs struct {
+	Matchers  []model.LabelMatcher `json:"matchers"`
+	Comment   string               `json:"comment"`
+	CreatedBy *string              `json:"created_by"`
+	StartsAt  time.Time            `json:"starts_at"`
+	EndsAt    time.Time            `json:"ends_at"`
+	Active    bool                 `json:"active"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func silenceResource(s *model.Silence, now time.Time) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "silence",
+		ID:   s.ID,
+		Attributes: silenceAttrs{
+			Matchers:  s.Matchers,
+			Comment:   s.Comment,
+			CreatedBy: s.CreatedBy,
+			StartsAt:  s.StartsAt,
+			EndsAt:    s.EndsAt,
+			Active:    s.Active(now),
+			CreatedAt: s.CreatedAt,
+			UpdatedAt: s.UpdatedAt,
+		},
+	}
+}
+
+type silenceRequest struct {
+	Matchers []model.LabelMatcher `json:"matchers"`
+	Comment  string               `json:"comment"`
+	StartsAt *time.Time           `json:"starts_at"`
+	EndsAt   *time.Time           `json:"ends_at"`
+}
+
+// Create handles POST /api/v1/silences.
+// starts_at defaults to now; ends_at is required and must be in the future.
+func (h *SilenceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req silenceRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	now := time.Now()
+	s := model.Silence{
+		Matchers:  req.Matchers,
+		Comment:   req.Comment,
+		CreatedBy: claimsUserID(r),
+		StartsAt:  now,
+	}
+	if req.StartsAt != nil {
+		s.StartsAt = *req.StartsAt
+	}
+
+	var errs []jsonapi.ErrorObject
+	if len(req.Matchers) == 0 {
+		errs = append(errs, fieldError("/matchers", "at least one matcher is required"))
+	}
+	for i, m := range req.Matchers {
+		if err := ingest.ValidateMatcher(m); err != nil {
+			errs = append(errs, fieldError(fmt.Sprintf("/matchers/%d", i), err.Error()))
+		}
+	}
+	switch {
+	case req.EndsAt == nil:
+		errs = append(errs, fieldError("/ends_at", "ends_at is required"))
+	case !req.EndsAt.After(now) || !req.EndsAt.After(s.StartsAt):
+		errs = append(errs, fieldError("/ends_at", "ends_at must be in the future and after starts_at"))
+	default:
+		s.EndsAt = *req.EndsAt
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	if orgID := claimsOrgID(r); orgID != "" {
+		s.OrganizationID = &orgID
+	}
+	if err := h.db.WithContext(r.Context()).Create(&s).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create silence")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, silenceResource(&s, now))
+}
+
+// List handles GET /api/v1/silences, listing the caller's organisation's
+// silences. filter[active]=true limits the result to silences in effect now.
+func (h *SilenceHandler) List(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	query := orgScoped(h.db.WithContext(r.Context()), r).Order("ends_at DESC")
+	if v := r.URL.Query().Get("filter[active]"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			jsonapi.RenderDecodeError(w, jsonapi.ParamError("filter[active]", "filter[active] must be true or false"))
+			return
+		}
+		if active {
+			query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+		} else {
+			query = query.Where("starts_at > ? OR ends_at <= ?", now, now)
+		}
+	}
+
+	var silences []model.Silence
+	if err := query.Find(&silences).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list silences")
+		return
+	}
+	data := make([]any, 0, len(silences))
+	for i := range silences {
+		data = append(data, silenceResource(&silences[i], now))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Get handles GET /api/v1/silences/{id}.
+func (h *SilenceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, silenceResource(s, time.Now()))
+}
+
+// Expire handles DELETE /api/v1/silences/{id}. The silence is kept for the
+// record and ends immediately; alerts it already suppressed stay suppressed,
+// but their repeats arrive as new alerts.
+func (h *SilenceHandler) Expire(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if s.EndsAt.After(now) {
+		s.EndsAt = now
+		if s.StartsAt.After(now) {
+			s.StartsAt = now
+		}
+		if err := h.db.WithContext(r.Context()).Save(s).Error; err != nil {
+			jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to expire silence")
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// load loads the silence named in the path if it belongs to the caller's
+// organisation.
+func (h *SilenceHandler) load(w http.ResponseWriter, r *http.Request) (*model.Silence, bool) {
+	var s model.Silence
+	err := orgScoped(h.db.WithContext(r.Context()), r).Where("id = ?", r.PathValue("id")).First(&s).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "silence does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load silence")
+		return nil, false
+	}
+	return &s, true
+}
@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/handler"
+	"github.com/d9705996/autopsy/internal/api/middleware"
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret-at-least-32-bytes-long"
+
+// asMemberOf runs fn for a Responder of organization orgID, or of none if
+// orgID is empty.
+func asMemberOf(t *testing.T, orgID string, fn http.HandlerFunc) http.HandlerFunc {
+	t.Helper()
+	token, err := auth.IssueAccessToken("alice", "alice@example.com", []string{"Responder"}, orgID, testSecret, time.Hour)
+	require.NoError(t, err)
+	authed := middleware.RequireAuth(testSecret)(fn)
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+		authed.ServeHTTP(w, r)
+	}
+}
+
+func TestSilences_ScopedToOrganization(t *testing.T) {
+	gormDB := dbtest.New(t)
+	h := handler.NewSilenceHandler(gormDB)
+	org := "org-a"
+	now := time.Now()
+	ours := model.Silence{OrganizationID: &org, StartsAt: now, EndsAt: now.Add(time.Hour),
+		Matchers: []model.LabelMatcher{{Label: "env", Op: "=", Value: "staging"}}}
+	theirs := model.Silence{StartsAt: now, EndsAt: now.Add(time.Hour),
+		Matchers: []model.LabelMatcher{{Label: "env", Op: "=", Value: "production"}}}
+	require.NoError(t, gormDB.Create(&ours).Error)
+	require.NoError(t, gormDB.Create(&theirs).Error)
+
+	code, doc := serve(t, asMemberOf(t, org, h.List), httptest.NewRequest(http.MethodGet, "/api/v1/silences", nil))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{ours.ID}, doc.ids(t))
+
+	for name, fn := range map[string]http.HandlerFunc{"get": h.Get, "expire": h.Expire} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/silences/"+theirs.ID, nil)
+		r.SetPathValue("id", theirs.ID)
+		code, _ := serve(t, asMemberOf(t, org, fn), r)
+		assert.Equal(t, http.StatusNotFound, code, name)
+	}
+	var stored model.Silence
+	require.NoError(t, gormDB.First(&stored, "id = ?", theirs.ID).Error)
+	assert.True(t, stored.Active(time.Now()), "other organizations' silences cannot be expired")
+
+	code, doc = serve(t, asMemberOf(t, "", h.List), httptest.NewRequest(http.MethodGet, "/api/v1/silences", nil))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{theirs.ID}, doc.ids(t))
+}
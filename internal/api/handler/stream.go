@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/api/middleware"
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// streamHeartbeat is how often an idle stream sends a comment line so
+	// proxies keep the connection open.
+	streamHeartbeat = 15 * time.Second
+	// streamReplayBatch is how many missed events are loaded at a time when
+	// a client resumes.
+	streamReplayBatch = 500
+)
+
+// StreamHandler handles GET /api/v1/stream.
+type StreamHandler struct {
+	db     *gorm.DB
+	broker *events.Broker
+}
+
+// NewStreamHandler creates a StreamHandler.
+func NewStreamHandler(db *gorm.DB, broker *events.Broker) *StreamHandler {
+	return &StreamHandler{db: db, broker: broker}
+}
+
+// Stream handles GET /api/v1/stream, a Server-Sent Events stream of the
+// caller's organization's changes: incident.created, incident.updated,
+// timeline_entry.created and alert.created. Each event's data is a JSON:API
+// document holding the resource as it is when the event is sent, and its ID
+// can be sent back as Last-Event-ID to resume after a disconnect; events are
+// kept for events.Retention. A resumed stream also replays events recorded
+// within events.Lookback before that ID, so clients may see those twice.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var lastID int64
+	resume := r.Header.Get("Last-Event-ID")
+	if resume != "" {
+		var err error
+		if lastID, err = strconv.ParseInt(resume, 10, 64); err != nil || lastID < 0 {
+			jsonapi.RenderError(w, http.StatusBadRequest, "invalid_last_event_id", "Bad Request", "Last-Event-ID must be an event ID from this stream")
+			return
+		}
+	}
+
+	// Streams stay open far longer than the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "stream_failed", "Internal Server Error", "failed to open stream")
+		return
+	}
+
+	ctx := r.Context()
+	orgID := claimsOrgID(r)
+	// Subscribe before replaying so nothing recorded in between is lost.
+	sub := h.broker.Subscribe(orgID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// Events can commit out of ID order, so replayed events are told apart
+	// from live ones by ID rather than by position.
+	replayed := map[int64]struct{}{}
+	if resume != "" {
+		from, err := h.broker.Rewind(ctx, lastID)
+		if err != nil {
+			return
+		}
+		for {
+			evs, err := h.broker.Since(ctx, orgID, from, streamReplayBatch)
+			if err != nil {
+				return
+			}
+			for _, ev := range evs {
+				if err := h.send(w, r, ev); err != nil {
+					return
+				}
+				replayed[ev.ID] = struct{}{}
+				from = ev.ID
+			}
+			if len(evs) < streamReplayBatch {
+				break
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if _, ok := replayed[ev.ID]; ok {
+				continue
+			}
+			if err := h.send(w, r, ev); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventPermissions is the permission needed to receive events about each
+// resource type, matching the permission of the endpoint that lists it.
+var eventPermissions = map[string]string{
+	"incident":       "incident:read",
+	"timeline_entry": "incident:comment",
+	"alert":          "alert:read",
+}
+
+// send writes ev as one SSE message. Events the caller may not read, and
+// events whose resource no longer exists, are skipped.
+func (h *StreamHandler) send(w http.ResponseWriter, r *http.Request, ev model.Event) error {
+	perm, ok := eventPermissions[ev.ResourceType]
+	if !ok || !middleware.HasPermission(r.Context(), perm) {
+		return nil
+	}
+	res, err := h.resource(r, ev)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil
+	case err != nil:
+		return err
+	}
+	data, err := json.Marshal(jsonapi.Document{Data: res, Meta: jsonapi.Meta{"event": ev.Type}})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
+
+// resource loads the resource ev refers to.
+func (h *StreamHandler) resource(r *http.Request, ev model.Event) (jsonapi.ResourceObject, error) {
+	q := h.db.WithContext(r.Context()).Where("id = ?", ev.ResourceID)
+	switch ev.ResourceType {
+	case "incident":
+		var inc model.Incident
+		err := q.Preload("Components", incident.OrderComponents).First(&inc).Error
+		return incidentResource(&inc), err
+	case "timeline_entry":
+		var e model.TimelineEntry
+		err := q.First(&e).Error
+		return timelineEntryResource(&e), err
+	default:
+		var a model.Alert
+		err := q.First(&a).Error
+		return alertResource(&a), err
+	}
+}
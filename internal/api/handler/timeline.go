@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// timelineSortColumns maps the sort keys accepted by
+// GET /api/v1/incidents/{id}/timeline to columns.
+var timelineSortColumns = map[string]string{
+	"occurred_at": "occurred_at",
+}
+
+const defaultTimelineSort = "occurred_at"
+
+const maxCommentBytes = 64 << 10
+
+type timelineEntryAttrs struct {
+	Kind       string            `json:"kind"`
+	AuthorID   *string           `json:"author_id"`
+	Body       string            `json:"body"`
+	Details    map[string]string `json:"details"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+func timelineEntryResource(e *model.TimelineEntry) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "timeline_entry",
+		ID:   e.ID,
+		Attributes: timelineEntryAttrs{
+			Kind:       e.Kind,
+			AuthorID:   e.AuthorID,
+			Body:       e.Body,
+			Details:    e.Details,
+			OccurredAt: e.OccurredAt,
+			CreatedAt:  e.CreatedAt,
+		},
+	}
+}
+
+// Timeline handles GET /api/v1/incidents/{id}/timeline, oldest entry first.
+// sort=-occurred_at lists newest first; page[size] and page[cursor] page
+// through long timelines.
+func (h *IncidentHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	inc, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	sort, column, desc, err := parseSort(r, timelineSortColumns, defaultTimelineSort)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	page, err := parsePageFor(r, sort, now)
+	if err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	query := h.db.WithContext(r.Context()).Model(&model.TimelineEntry{}).Where("incident_id = ?", inc.ID)
+	entries, pagination, links, err := keysetPage(r, query, page, sort, column, desc, now,
+		func(e *model.TimelineEntry) (time.Time, string) { return e.OccurredAt, e.ID })
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list timeline")
+		return
+	}
+
+	data := make([]any, 0, len(entries))
+	for i := range entries {
+		data = append(data, timelineEntryResource(&entries[i]))
+	}
+	jsonapi.RenderPage(w, http.StatusOK, data, pagination, links)
+}
+
+type commentRequest struct {
+	Body *string `json:"body"`
+}
+
+// Comment handles POST /api/v1/incidents/{id}/timeline, appending a comment
+// by the caller. Other entry kinds are written by the system as the
+// incident progresses.
+func (h *IncidentHandler) Comment(w http.ResponseWriter, r *http.Request) {
+	var req commentRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	switch {
+	case req.Body == nil || strings.TrimSpace(*req.Body) == "":
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/body", "body is required")})
+		return
+	case len(*req.Body) > maxCommentBytes:
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/body", "body must be at most 65536 bytes")})
+		return
+	}
+
+	e := model.TimelineEntry{
+		IncidentID: r.PathValue("id"),
+		Kind:       model.TimelineKindComment,
+		AuthorID:   claimsUserID(r),
+		Body:       *req.Body,
+		Details:    map[string]string{},
+	}
+	if err := h.incidents.Append(r.Context(), &e); err != nil {
+		renderIncidentError(w, err)
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, timelineEntryResource(&e))
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+type triageResultAttrs struct {
+	AlertID            string                    `json:"alert_id"`
+	Attempt            int                       `json:"attempt"`
+	Status             string                    `json:"status"`
+	Provider           string                    `json:"provider"`
+	Model              string                    `json:"model"`
+	Severity           string                    `json:"ai_severity"`
+	Summary            string                    `json:"ai_summary"`
+	ProbableCause      string                    `json:"probable_cause"`
+	SuggestedActions   []string                  `json:"suggested_actions"`
+	ConfidenceScore    float64                   `json:"confidence_score"`
+	Error              string                    `json:"error,omitempty"`
+	SystemPrompt       string                    `json:"system_prompt"`
+	Prompt             string                    `json:"prompt"`
+	Response           string                    `json:"response"`
+	LatencyMS          int64                     `json:"latency_ms"`
+	InputTokens        int                       `json:"input_tokens"`
+	OutputTokens       int                       `json:"output_tokens"`
+	RelatedPostmortems []model.RelatedPostmortem `json:"related_postmortems"`
+	CreatedAt          time.Time                 `json:"created_at"`
+}
+
+func triageResultResource(t *model.TriageResult) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "triage_result",
+		ID:   t.ID,
+		Attributes: triageResultAttrs{
+			AlertID:            t.AlertID,
+			Attempt:            t.Attempt,
+			Status:             t.Status,
+			Provider:           t.Provider,
+			Model:              t.Model,
+			Severity:           t.Severity,
+			Summary:            t.Summary,
+			ProbableCause:      t.ProbableCause,
+			SuggestedActions:   t.SuggestedActions,
+			ConfidenceScore:    t.ConfidenceScore,
+			Error:              t.Error,
+			SystemPrompt:       t.SystemPrompt,
+			Prompt:             t.Prompt,
+			Response:           t.Response,
+			LatencyMS:          t.LatencyMS,
+			InputTokens:        t.InputTokens,
+			OutputTokens:       t.OutputTokens,
+			RelatedPostmortems: t.RelatedPostmortems,
+			CreatedAt:          t.CreatedAt,
+		},
+	}
+}
+
+// Triage handles GET /api/v1/alerts/{id}/triage. It lists every triage run
+// for the alert, newest first, with the exact prompt and raw model answer.
+// Alerts that were never triaged yield an empty list.
+func (h *AlertHandler) Triage(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var runs []model.TriageResult
+	if err := h.db.WithContext(r.Context()).
+		Where("alert_id = ?", a.ID).
+		Order("created_at DESC, attempt DESC").
+		Find(&runs).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list triage results")
+		return
+	}
+	data := make([]any, 0, len(runs))
+	for i := range runs {
+		data = append(data, triageResultResource(&runs[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
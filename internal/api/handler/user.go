@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/notify"
+	"gorm.io/gorm"
+)
+
+const maxNotificationChannels = 10
+
+// UserHandler handles /api/v1/users routes.
+type UserHandler struct {
+	db *gorm.DB
+}
+
+// NewUserHandler creates a UserHandler.
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+type userAttrs struct {
+	Email                string                      `json:"email"`
+	Name                 string                      `json:"name"`
+	Roles                []string                    `json:"roles"`
+	NotificationChannels []model.NotificationChannel `json:"notification_channels"`
+	CreatedAt            time.Time                   `json:"created_at"`
+	UpdatedAt            time.Time                   `json:"updated_at"`
+}
+
+func userResource(u *model.User) jsonapi.ResourceObject {
+	channels := u.NotificationChannels
+	if channels == nil {
+		channels = []model.NotificationChannel{}
+	}
+	roles := []string(u.Roles)
+	if roles == nil {
+		roles = []string{}
+	}
+	return jsonapi.ResourceObject{
+		Type: "user",
+		ID:   u.ID,
+		Attributes: userAttrs{
+			Email:                u.Email,
+			Name:                 u.Name,
+			Roles:                roles,
+			NotificationChannels: channels,
+			CreatedAt:            u.CreatedAt,
+			UpdatedAt:            u.UpdatedAt,
+		},
+	}
+}
+
+type userRequest struct {
+	Name                 *string                      `json:"name"`
+	NotificationChannels *[]model.NotificationChannel `json:"notification_channels"`
+}
+
+// apply validates the request and copies the editable fields onto u.
+func (req *userRequest) apply(u *model.User) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		u.Name = *req.Name
+	}
+	if req.NotificationChannels != nil {
+		channels := *req.NotificationChannels
+		if len(channels) > maxNotificationChannels {
+			errs = append(errs, fieldError("/notification_channels", "at most 10 notification channels are allowed"))
+		}
+		for i, c := range channels {
+			if err := notify.ValidateChannel(c); err != nil {
+				errs = append(errs, fieldError(fmt.Sprintf("/notification_channels/%d", i), err.Error()))
+			}
+		}
+		u.NotificationChannels = channels
+	}
+	return errs
+}
+
+// Me handles GET /api/v1/users/me, the caller's own profile.
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.loadMe(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, userResource(u))
+}
+
+// UpdateMe handles PATCH /api/v1/users/me, where users set their name and
+// the channels their pages are sent to.
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.loadMe(w, r)
+	if !ok {
+		return
+	}
+	var req userRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if errs := req.apply(u); len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	err := h.db.WithContext(r.Context()).Model(u).Select("name", "notification_channels", "updated_at").Updates(u).Error
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update user")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, userResource(u))
+}
+
+func (h *UserHandler) loadMe(w http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	id := claimsUserID(r)
+	if id == nil {
+		jsonapi.RenderError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized", "authentication required")
+		return nil, false
+	}
+	var u model.User
+	err := h.db.WithContext(r.Context()).Where("id = ? AND deactivated_at IS NULL", *id).First(&u).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "user does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load user")
+		return nil, false
+	}
+	return &u, true
+}
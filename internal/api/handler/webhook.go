@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/worker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+)
+
+// sourceCacheTTL is how long RateLimitKey reuses a source lookup, so a
+// change to a source's rate limit or enabled flag reaches the limiter
+// within this long.
+const sourceCacheTTL = 10 * time.Second
+
+// maxCachedSources bounds the lookup cache, which requests for unknown
+// source names would otherwise grow without limit.
+const maxCachedSources = 1024
+
+// WebhookHandler handles POST /api/v1/webhooks/{source}.
+type WebhookHandler struct {
+	db           *gorm.DB
+	store        *ingest.Store
+	replayWindow time.Duration
+	maxBodyBytes int64
+	tooLarge     metric.Int64Counter
+
+	mu     sync.Mutex
+	limits map[string]sourceLimit
+}
+
+// sourceLimit is a cached RateLimitKey result for one source name.
+type sourceLimit struct {
+	id      string
+	rps     int
+	ok      bool
+	expires time.Time
+}
+
+// NewWebhookHandler creates a WebhookHandler. Signed requests older (or newer)
+// than replayWindow are rejected, as are payloads over maxBodyBytes. New
+// alerts are staged for AI triage with the worker queue.
+func NewWebhookHandler(db *gorm.DB, replayWindow time.Duration, maxBodyBytes int64) *WebhookHandler {
+	tooLarge, _ := otel.Meter("github.com/d9705996/autopsy/internal/api/handler").
+		Int64Counter("webhook_body_too_large",
+			metric.WithDescription("Webhook requests rejected for exceeding the body size limit."))
+	return &WebhookHandler{
+		db:           db,
+		store:        ingest.NewStore(db, worker.StageTriage),
+		replayWindow: replayWindow,
+		maxBodyBytes: maxBodyBytes,
+		tooLarge:     tooLarge,
+		limits:       make(map[string]sourceLimit),
+	}
+}
+
+// RateLimitKey implements middleware.RateLimitKeyFunc for the ingest route.
+// Unknown and disabled sources are not limited; Receive rejects them.
+// Lookups are cached for sourceCacheTTL.
+func (h *WebhookHandler) RateLimitKey(r *http.Request) (string, int, bool) {
+	name := r.PathValue("source")
+	now := time.Now()
+	h.mu.Lock()
+	l, ok := h.limits[name]
+	h.mu.Unlock()
+	if ok && now.Before(l.expires) {
+		return l.id, l.rps, l.ok
+	}
+
+	var src model.WebhookSource
+	err := h.db.WithContext(r.Context()).
+		Select("id", "enabled", "rate_limit_rps").
+		Where("name = ?", name).
+		First(&src).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", 0, false
+	}
+	l = sourceLimit{id: src.ID, rps: src.RateLimitRPS, ok: err == nil && src.Enabled, expires: now.Add(sourceCacheTTL)}
+	h.mu.Lock()
+	if len(h.limits) >= maxCachedSources {
+		clear(h.limits)
+	}
+	h.limits[name] = l
+	h.mu.Unlock()
+	return l.id, l.rps, l.ok
+}
+
+// Receive handles POST /api/v1/webhooks/{source}.
+// The signature is verified before the payload is parsed.
+func (h *WebhookHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+
+	var src model.WebhookSource
+	if err := h.db.WithContext(ctx).
+		Where("name = ?", r.PathValue("source")).
+		First(&src).Error; err != nil || !src.Enabled {
+		jsonapi.RenderError(w, http.StatusNotFound, "unknown_source", "Not Found", "webhook source does not exist or is disabled")
+		return
+	}
+
+	if err := jsonapi.CheckContentType(r); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.tooLarge.Add(ctx, 1, metric.WithAttributes(attribute.String("source_id", src.ID)))
+			jsonapi.RenderError(w, http.StatusRequestEntityTooLarge, "body_too_large", "Request Entity Too Large", "webhook payload exceeds the size limit")
+			return
+		}
+		jsonapi.RenderError(w, http.StatusBadRequest, "invalid_body", "Bad Request", "failed to read request body")
+		return
+	}
+
+	if err := ingest.VerifySignature(src.HMACSecret, r.Header, body, now, h.replayWindow); err != nil {
+		jsonapi.RenderError(w, http.StatusUnauthorized, "invalid_signature", "Unauthorized", err.Error())
+		return
+	}
+
+	parser, err := ingest.ParserFor(&src)
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "source_misconfigured", "Internal Server Error", err.Error())
+		return
+	}
+	alerts, err := parser.Parse(body)
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusUnprocessableEntity, "invalid_payload", "Unprocessable Entity", err.Error())
+		return
+	}
+
+	saved, err := h.store.Save(ctx, &src, alerts, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "store webhook alerts", "source", src.Name, "err", err)
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to store alerts")
+		return
+	}
+
+	data := make([]any, 0, len(saved))
+	for i := range saved {
+		data = append(data, alertResource(&saved[i]))
+	}
+	jsonapi.RenderList(w, http.StatusAccepted, data, nil)
+}
@@ -0,0 +1,427 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/triage"
+	"gorm.io/gorm"
+)
+
+// sourceNamePattern restricts source names to values that are safe in a URL
+// path segment.
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// maxDedupWindowSeconds caps a source's dedup window at one day.
+const maxDedupWindowSeconds = 24 * 60 * 60
+
+// maxRateLimitRPS caps a source's configurable request rate.
+const maxRateLimitRPS = 10000
+
+// Limits on a source's AI triage overrides.
+const (
+	maxPromptTemplateBytes = 16 << 10
+	maxAIModelLength       = 200
+	maxAIMaxTokens         = 32768
+)
+
+// WebhookSourceHandler handles /api/v1/webhook-sources routes.
+type WebhookSourceHandler struct {
+	db     *gorm.DB
+	triage *triage.Service
+}
+
+// NewWebhookSourceHandler creates a WebhookSourceHandler. triage renders
+// prompt previews.
+func NewWebhookSourceHandler(db *gorm.DB, triage *triage.Service) *WebhookSourceHandler {
+	return &WebhookSourceHandler{db: db, triage: triage}
+}
+
+// webhookSourceAttrs deliberately omits the HMAC secret: it is write-only.
+type webhookSourceAttrs struct {
+	Name               string             `json:"name"`
+	SourceType         string             `json:"source_type"`
+	Enabled            bool               `json:"enabled"`
+	FieldMapping       model.FieldMapping `json:"field_mapping"`
+	DedupWindowSeconds int                `json:"dedup_window_seconds"`
+	RateLimitRPS       int                `json:"rate_limit_rps"`
+	PromptTemplate     string             `json:"prompt_template"`
+	AIModel            string             `json:"ai_model"`
+	AIMaxTokens        int                `json:"ai_max_tokens"`
+	// AutoDeclareSeverity is empty when automatic incidents are off.
+	AutoDeclareSeverity string    `json:"auto_declare_severity"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func webhookSourceResource(s *model.WebhookSource) jsonapi.ResourceObject {
+	return jsonapi.ResourceObject{
+		Type: "webhook_source",
+		ID:   s.ID,
+		Attributes: webhookSourceAttrs{
+			Name:                s.Name,
+			SourceType:          s.SourceType,
+			Enabled:             s.Enabled,
+			FieldMapping:        s.FieldMapping,
+			DedupWindowSeconds:  s.DedupWindowSeconds,
+			RateLimitRPS:        s.RateLimitRPS,
+			PromptTemplate:      s.PromptTemplate,
+			AIModel:             s.AIModel,
+			AIMaxTokens:         s.AIMaxTokens,
+			AutoDeclareSeverity: s.AutoDeclareSeverity,
+			CreatedAt:           s.CreatedAt,
+			UpdatedAt:           s.UpdatedAt,
+		},
+	}
+}
+
+type webhookSourceRequest struct {
+	Name                *string             `json:"name"`
+	SourceType          *string             `json:"source_type"`
+	HMACSecret          *string             `json:"hmac_secret"`
+	Enabled             *bool               `json:"enabled"`
+	FieldMapping        *model.FieldMapping `json:"field_mapping"`
+	DedupWindowSeconds  *int                `json:"dedup_window_seconds"`
+	RateLimitRPS        *int                `json:"rate_limit_rps"`
+	PromptTemplate      *string             `json:"prompt_template"`
+	AIModel             *string             `json:"ai_model"`
+	AIMaxTokens         *int                `json:"ai_max_tokens"`
+	AutoDeclareSeverity *string             `json:"auto_declare_severity"`
+}
+
+// apply copies the supplied fields onto s and returns any validation errors.
+func (req *webhookSourceRequest) apply(s *model.WebhookSource) []jsonapi.ErrorObject {
+	var errs []jsonapi.ErrorObject
+	if req.Name != nil {
+		if !sourceNamePattern.MatchString(*req.Name) {
+			errs = append(errs, fieldError("/name", "name must be 1-63 lowercase letters, digits, '-' or '_'"))
+		}
+		s.Name = *req.Name
+	}
+	if req.SourceType != nil {
+		if !ingest.SupportedSourceType(*req.SourceType) {
+			errs = append(errs, fieldError("/source_type", "unsupported source type"))
+		}
+		s.SourceType = *req.SourceType
+	}
+	if req.HMACSecret != nil {
+		if *req.HMACSecret == "" {
+			errs = append(errs, fieldError("/hmac_secret", "hmac_secret must not be empty"))
+		}
+		s.HMACSecret = *req.HMACSecret
+	}
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+	}
+	if req.FieldMapping != nil {
+		s.FieldMapping = *req.FieldMapping
+	}
+	if req.DedupWindowSeconds != nil {
+		if *req.DedupWindowSeconds < 1 || *req.DedupWindowSeconds > maxDedupWindowSeconds {
+			errs = append(errs, fieldError("/dedup_window_seconds", "dedup_window_seconds must be between 1 and 86400"))
+		}
+		s.DedupWindowSeconds = *req.DedupWindowSeconds
+	}
+	if req.RateLimitRPS != nil {
+		if *req.RateLimitRPS < 1 || *req.RateLimitRPS > maxRateLimitRPS {
+			errs = append(errs, fieldError("/rate_limit_rps", "rate_limit_rps must be between 1 and 10000"))
+		}
+		s.RateLimitRPS = *req.RateLimitRPS
+	}
+	if req.PromptTemplate != nil {
+		if err := validatePromptTemplate(*req.PromptTemplate); err != nil {
+			errs = append(errs, fieldError("/prompt_template", err.Error()))
+		}
+		s.PromptTemplate = *req.PromptTemplate
+	}
+	if req.AIModel != nil {
+		if len(*req.AIModel) > maxAIModelLength {
+			errs = append(errs, fieldError("/ai_model", "ai_model must be at most 200 characters"))
+		}
+		s.AIModel = *req.AIModel
+	}
+	if req.AIMaxTokens != nil {
+		if *req.AIMaxTokens < 0 || *req.AIMaxTokens > maxAIMaxTokens {
+			errs = append(errs, fieldError("/ai_max_tokens", "ai_max_tokens must be between 0 (use AI_MAX_TOKENS) and 32768"))
+		}
+		s.AIMaxTokens = *req.AIMaxTokens
+	}
+	if req.AutoDeclareSeverity != nil {
+		if v := *req.AutoDeclareSeverity; v != "" && !slices.Contains(model.Severities, v) {
+			errs = append(errs, fieldError("/auto_declare_severity",
+				"auto_declare_severity must be one of "+strings.Join(model.Severities, ", ")+", or empty to disable automatic incidents"))
+		}
+		s.AutoDeclareSeverity = *req.AutoDeclareSeverity
+	}
+	return errs
+}
+
+// validatePromptTemplate checks a prompt template's size and that it renders
+// for a sample alert. An empty template restores the built-in one.
+func validatePromptTemplate(text string) error {
+	if len(text) > maxPromptTemplateBytes {
+		return errors.New("prompt_template must be at most 16384 bytes")
+	}
+	_, err := triage.ParseTemplate(text)
+	return err
+}
+
+// fieldError builds a 422 error object pointing at a request body member.
+func fieldError(pointer, detail string) jsonapi.ErrorObject {
+	return jsonapi.ErrorObject{
+		Status: http.StatusText(http.StatusUnprocessableEntity),
+		Code:   "invalid_field",
+		Title:  "Unprocessable Entity",
+		Detail: detail,
+		Source: &jsonapi.ErrorSource{Pointer: pointer},
+	}
+}
+
+// Create handles POST /api/v1/webhook-sources.
+func (h *WebhookSourceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req webhookSourceRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+
+	src := model.WebhookSource{
+		SourceType:          model.SourceTypeGeneric,
+		Enabled:             true,
+		DedupWindowSeconds:  int(model.DefaultDedupWindow / time.Second),
+		RateLimitRPS:        model.DefaultRateLimitRPS,
+		AutoDeclareSeverity: model.DefaultAutoDeclareSeverity,
+	}
+	errs := req.apply(&src)
+	if req.Name == nil {
+		errs = append(errs, fieldError("/name", "name is required"))
+	}
+	if req.HMACSecret == nil {
+		errs = append(errs, fieldError("/hmac_secret", "hmac_secret is required"))
+	}
+	if len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	ctx := r.Context()
+	if h.nameTaken(r, src.Name, "") {
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a webhook source with this name already exists")
+		return
+	}
+	if orgID := claimsOrgID(r); orgID != "" {
+		src.OrganizationID = &orgID
+	}
+	if err := h.db.WithContext(ctx).Create(&src).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to create webhook source")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusCreated, webhookSourceResource(&src))
+}
+
+// List handles GET /api/v1/webhook-sources.
+func (h *WebhookSourceHandler) List(w http.ResponseWriter, r *http.Request) {
+	var sources []model.WebhookSource
+	if err := h.db.WithContext(r.Context()).Order("name").Find(&sources).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to list webhook sources")
+		return
+	}
+	data := make([]any, 0, len(sources))
+	for i := range sources {
+		data = append(data, webhookSourceResource(&sources[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+// Get handles GET /api/v1/webhook-sources/{id}.
+func (h *WebhookSourceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	src, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, webhookSourceResource(src))
+}
+
+// Update handles PATCH /api/v1/webhook-sources/{id}.
+func (h *WebhookSourceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	src, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req webhookSourceRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if errs := req.apply(src); len(errs) > 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+	if req.Name != nil && h.nameTaken(r, src.Name, src.ID) {
+		jsonapi.RenderError(w, http.StatusConflict, "name_taken", "Conflict", "a webhook source with this name already exists")
+		return
+	}
+	if err := h.db.WithContext(r.Context()).Save(src).Error; err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "store_failed", "Internal Server Error", "failed to update webhook source")
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, webhookSourceResource(src))
+}
+
+type dryRunRequest struct {
+	Payload      json.RawMessage     `json:"payload"`
+	FieldMapping *model.FieldMapping `json:"field_mapping"`
+}
+
+// DryRun handles POST /api/v1/webhook-sources/{id}/dry-run.
+// It parses a sample payload with the source's adapter and returns the alerts
+// that would be stored, without storing them. An optional field_mapping
+// replaces the saved mapping so a new mapping can be tried before saving it.
+func (h *WebhookSourceHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	src, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req dryRunRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if len(req.Payload) == 0 {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/payload", "payload is required")})
+		return
+	}
+	if req.FieldMapping != nil {
+		src.FieldMapping = *req.FieldMapping
+	}
+
+	parser, err := ingest.ParserFor(src)
+	if err != nil {
+		jsonapi.RenderError(w, http.StatusInternalServerError, "source_misconfigured", "Internal Server Error", err.Error())
+		return
+	}
+	alerts, err := parser.Parse(req.Payload)
+	if err != nil {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/payload", err.Error())})
+		return
+	}
+
+	rows := ingest.Preview(src, alerts, time.Now())
+	data := make([]any, 0, len(rows))
+	for i := range rows {
+		data = append(data, alertResource(&rows[i]))
+	}
+	jsonapi.RenderList(w, http.StatusOK, data, nil)
+}
+
+type promptPreviewRequest struct {
+	PromptTemplate *string             `json:"prompt_template"`
+	Alert          *promptPreviewAlert `json:"alert"`
+}
+
+type promptPreviewAlert struct {
+	Title        *string           `json:"title"`
+	Description  *string           `json:"description"`
+	SeverityHint *string           `json:"severity_hint"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+}
+
+type promptPreviewAttrs struct {
+	SystemPrompt string `json:"system_prompt"`
+	Prompt       string `json:"prompt"`
+	Model        string `json:"model"`
+	MaxTokens    int    `json:"max_tokens"`
+}
+
+// PromptPreview handles POST /api/v1/webhook-sources/{id}/prompt-preview.
+// It renders the triage prompt the source would send for a sample alert,
+// along with the effective model and token budget. An optional
+// prompt_template replaces the saved one, and fields given in alert replace
+// those of the built-in sample alert. Nothing is saved and the AI provider is
+// not called.
+func (h *WebhookSourceHandler) PromptPreview(w http.ResponseWriter, r *http.Request) {
+	src, ok := h.load(w, r)
+	if !ok {
+		return
+	}
+	var req promptPreviewRequest
+	if err := jsonapi.Decode(r, &req); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+		return
+	}
+	if req.PromptTemplate != nil {
+		if err := validatePromptTemplate(*req.PromptTemplate); err != nil {
+			jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/prompt_template", err.Error())})
+			return
+		}
+		src.PromptTemplate = *req.PromptTemplate
+	}
+
+	sample := triage.SampleAlert()
+	sample.Source = src.Name
+	if a := req.Alert; a != nil {
+		if a.Title != nil {
+			sample.Title = *a.Title
+		}
+		if a.Description != nil {
+			sample.Description = *a.Description
+		}
+		if a.SeverityHint != nil {
+			sample.SeverityHint = *a.SeverityHint
+		}
+		if a.Labels != nil {
+			sample.Labels = a.Labels
+		}
+		if a.Annotations != nil {
+			sample.Annotations = a.Annotations
+		}
+	}
+
+	prompt, err := h.triage.BuildPrompt(r.Context(), src, &sample)
+	if err != nil {
+		jsonapi.RenderErrors(w, http.StatusUnprocessableEntity, []jsonapi.ErrorObject{fieldError("/prompt_template", err.Error())})
+		return
+	}
+	jsonapi.RenderOne(w, http.StatusOK, jsonapi.ResourceObject{
+		Type: "prompt_preview",
+		ID:   src.ID,
+		Attributes: promptPreviewAttrs{
+			SystemPrompt: prompt.System,
+			Prompt:       prompt.User,
+			Model:        prompt.Model,
+			MaxTokens:    prompt.MaxTokens,
+		},
+	})
+}
+
+func (h *WebhookSourceHandler) load(w http.ResponseWriter, r *http.Request) (*model.WebhookSource, bool) {
+	var src model.WebhookSource
+	err := h.db.WithContext(r.Context()).Where("id = ?", r.PathValue("id")).First(&src).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		jsonapi.RenderError(w, http.StatusNotFound, "not_found", "Not Found", "webhook source does not exist")
+		return nil, false
+	case err != nil:
+		jsonapi.RenderError(w, http.StatusInternalServerError, "query_failed", "Internal Server Error", "failed to load webhook source")
+		return nil, false
+	}
+	return &src, true
+}
+
+func (h *WebhookSourceHandler) nameTaken(r *http.Request, name, exceptID string) bool {
+	var count int64
+	q := h.db.WithContext(r.Context()).Model(&model.WebhookSource{}).Where("name = ?", name)
+	if exceptID != "" {
+		q = q.Where("id <> ?", exceptID)
+	}
+	return q.Count(&count).Error == nil && count > 0
+}
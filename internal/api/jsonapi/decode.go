@@ -0,0 +1,132 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DecodeError is returned by the request decoding helpers (Decode,
+// CheckContentType, ParsePage). It carries the HTTP status and JSON:API error
+// object to send back to the client.
+type DecodeError struct {
+	Status int
+	Object ErrorObject
+}
+
+func (e *DecodeError) Error() string { return e.Object.Detail }
+
+func decodeError(status int, code, pointer, detail string) *DecodeError {
+	obj := ErrorObject{
+		Status: http.StatusText(status),
+		Code:   code,
+		Title:  http.StatusText(status),
+		Detail: detail,
+	}
+	if pointer != "" {
+		obj.Source = &ErrorSource{Pointer: pointer}
+	}
+	return &DecodeError{Status: status, Object: obj}
+}
+
+// CheckContentType reports a 415 DecodeError unless the request declares an
+// application/json or application/vnd.api+json body.
+func CheckContentType(r *http.Request) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && mediaType != contentType) {
+		return decodeError(http.StatusUnsupportedMediaType, "unsupported_media_type", "",
+			"Content-Type must be application/json or "+contentType)
+	}
+	return nil
+}
+
+// Decode reads a single JSON object from the request body into dst.
+// Unknown members are rejected, and errors about a specific member point at
+// it via source.pointer. Body size is capped by middleware.LimitBody; a
+// body over the cap yields 413.
+func Decode(r *http.Request, dst any) error {
+	if err := CheckContentType(r); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return translateDecodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return translateDecodeError(err)
+		}
+		return decodeError(http.StatusBadRequest, "invalid_json", "", "request body must contain a single JSON object")
+	}
+	return nil
+}
+
+func translateDecodeError(err error) *DecodeError {
+	var (
+		tooLarge  *http.MaxBytesError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return decodeError(http.StatusRequestEntityTooLarge, "body_too_large", "",
+			fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF):
+		return decodeError(http.StatusBadRequest, "missing_body", "", "request body is required")
+	case errors.As(err, &syntaxErr):
+		return decodeError(http.StatusBadRequest, "invalid_json", "",
+			fmt.Sprintf("request body is not valid JSON (offset %d)", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return decodeError(http.StatusBadRequest, "invalid_json", "", "request body is truncated")
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return decodeError(http.StatusBadRequest, "invalid_json", "", "request body must be a JSON object")
+		}
+		return decodeError(http.StatusBadRequest, "invalid_type", fieldPointer(typeErr.Field),
+			fmt.Sprintf("%s must be of type %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String())))
+	}
+	// encoding/json reports unknown members only as a formatted string.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name = strings.Trim(name, `"`)
+		return decodeError(http.StatusBadRequest, "unknown_field", fieldPointer(name),
+			fmt.Sprintf("unknown field %q", name))
+	}
+	return decodeError(http.StatusBadRequest, "invalid_json", "", "request body must be valid JSON")
+}
+
+// fieldPointer converts an encoding/json dotted field path into a JSON
+// pointer.
+func fieldPointer(field string) string {
+	return "/" + strings.ReplaceAll(field, ".", "/")
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "map", kind == "struct":
+		return "object"
+	}
+	return kind
+}
+
+// RenderDecodeError writes err, as returned by one of the decoding helpers.
+// Any other error is rendered as a generic 400.
+func RenderDecodeError(w http.ResponseWriter, err error) {
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		de = decodeError(http.StatusBadRequest, "invalid_body", "", "request body could not be read")
+	}
+	RenderErrors(w, de.Status, []ErrorObject{de.Object})
+}
@@ -0,0 +1,104 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTarget struct {
+	Name    string `json:"name"`
+	Enabled *bool  `json:"enabled"`
+	Mapping struct {
+		Title string `json:"title"`
+		Count int    `json:"count"`
+	} `json:"mapping"`
+}
+
+func decodeRequest(t *testing.T, contentType, body string, maxBytes int64) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	if maxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	var dst decodeTarget
+	if err := jsonapi.Decode(r, &dst); err != nil {
+		jsonapi.RenderDecodeError(w, err)
+	}
+	return w
+}
+
+func firstError(t *testing.T, w *httptest.ResponseRecorder) jsonapi.ErrorObject {
+	t.Helper()
+	var doc jsonapi.ErrorDocument
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Len(t, doc.Errors, 1)
+	return doc.Errors[0]
+}
+
+func TestDecode_Valid(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "a", "mapping": {"title": "t"}}`))
+	r.Header.Set("Content-Type", "application/vnd.api+json")
+	var dst decodeTarget
+	require.NoError(t, jsonapi.Decode(r, &dst))
+	assert.Equal(t, "a", dst.Name)
+	assert.Equal(t, "t", dst.Mapping.Title)
+}
+
+func TestDecode_ContentType(t *testing.T) {
+	for _, ct := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		w := decodeRequest(t, ct, `{}`, 0)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, ct)
+	}
+	w := decodeRequest(t, "application/json; charset=utf-8", `{}`, 0)
+	assert.Equal(t, http.StatusOK, w.Code, "charset parameter is accepted")
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		code    string
+		pointer string
+	}{
+		{"empty body", ``, http.StatusBadRequest, "missing_body", ""},
+		{"syntax error", `{"name": }`, http.StatusBadRequest, "invalid_json", ""},
+		{"truncated", `{"name": "a"`, http.StatusBadRequest, "invalid_json", ""},
+		{"not an object", `[1, 2]`, http.StatusBadRequest, "invalid_json", ""},
+		{"trailing data", `{"name": "a"} {"name": "b"}`, http.StatusBadRequest, "invalid_json", ""},
+		{"unknown field", `{"name": "a", "colour": "red"}`, http.StatusBadRequest, "unknown_field", "/colour"},
+		{"wrong type", `{"enabled": "yes"}`, http.StatusBadRequest, "invalid_type", "/enabled"},
+		{"nested wrong type", `{"mapping": {"count": "many"}}`, http.StatusBadRequest, "invalid_type", "/mapping/count"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := decodeRequest(t, "application/json", tc.body, 0)
+			assert.Equal(t, tc.status, w.Code)
+			e := firstError(t, w)
+			assert.Equal(t, tc.code, e.Code)
+			if tc.pointer == "" {
+				assert.Nil(t, e.Source)
+			} else {
+				require.NotNil(t, e.Source)
+				assert.Equal(t, tc.pointer, e.Source.Pointer)
+			}
+		})
+	}
+}
+
+func TestDecode_BodyTooLarge(t *testing.T) {
+	w := decodeRequest(t, "application/json", `{"name": "`+strings.Repeat("x", 100)+`"}`, 32)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "body_too_large", firstError(t, w).Code)
+}
@@ -44,6 +44,12 @@ type Relationship struct {
 	Links *Links `json:"links,omitempty"`
 }
 
+// ResourceIdentifier identifies a related resource in relationship data.
+type ResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
 // Links holds JSON:API link objects.
 type Links struct {
 	Self    string `json:"self,omitempty"`
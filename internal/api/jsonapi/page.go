@@ -0,0 +1,101 @@
+package jsonapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Paging defaults for collection endpoints.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+	// CursorTTL bounds how long a page[cursor] value stays valid.
+	CursorTTL = 24 * time.Hour
+)
+
+// Cursor is the decoded form of an opaque page[cursor] value. It marks a
+// position in a keyset-paginated collection: the sort key and ID of the row
+// at the page boundary.
+type Cursor struct {
+	Sort     string    `json:"s"`
+	Key      time.Time `json:"k"`
+	ID       string    `json:"i"`
+	Before   bool      `json:"b,omitempty"` // page backwards from the position
+	IssuedAt int64     `json:"t"`
+}
+
+// Encode returns the opaque base64url form of c.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// PageParams holds the parsed page[size] and page[cursor] query parameters.
+type PageParams struct {
+	Size   int
+	Cursor *Cursor
+}
+
+// ParsePage reads page[size] and page[cursor] from r. Invalid, expired or
+// tampered cursors and out-of-range sizes are reported as 400 errors with
+// source.parameter set.
+func ParsePage(r *http.Request, now time.Time) (PageParams, error) {
+	q := r.URL.Query()
+	p := PageParams{Size: DefaultPageSize}
+
+	if v := q.Get("page[size]"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxPageSize {
+			return p, ParamError("page[size]", fmt.Sprintf("page[size] must be between 1 and %d", MaxPageSize))
+		}
+		p.Size = n
+	}
+
+	if v := q.Get("page[cursor]"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		var c Cursor
+		if err != nil || json.Unmarshal(raw, &c) != nil || c.ID == "" || c.IssuedAt == 0 {
+			return p, ParamError("page[cursor]", "page[cursor] is not a valid cursor")
+		}
+		issued := time.Unix(c.IssuedAt, 0)
+		if now.Sub(issued) > CursorTTL || issued.After(now.Add(time.Minute)) {
+			return p, ParamError("page[cursor]", "page[cursor] has expired; restart from the first page")
+		}
+		p.Cursor = &c
+	}
+	return p, nil
+}
+
+// ParamError builds a 400 DecodeError pointing at a query parameter.
+func ParamError(param, detail string) error {
+	return &DecodeError{
+		Status: http.StatusBadRequest,
+		Object: ErrorObject{
+			Status: http.StatusText(http.StatusBadRequest),
+			Code:   "invalid_parameter",
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: detail,
+			Source: &ErrorSource{Parameter: param},
+		},
+	}
+}
+
+// PageLink returns the request URI of r with page[cursor] replaced by cursor,
+// keeping every other query parameter.
+func PageLink(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Set("page[cursor]", cursor)
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// RenderPage writes a collection document with pagination info and links.
+func RenderPage(w http.ResponseWriter, status int, data []any, pagination *Pagination, links *Links) {
+	if data == nil {
+		data = []any{}
+	}
+	Render(w, status, ListDocument{Data: data, Paging: pagination, Links: links})
+}
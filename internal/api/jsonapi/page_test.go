@@ -0,0 +1,75 @@
+package jsonapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pageRequest(params url.Values) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/api/v1/alerts?"+params.Encode(), nil)
+}
+
+func TestParsePage_Defaults(t *testing.T) {
+	p, err := jsonapi.ParsePage(pageRequest(nil), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, jsonapi.DefaultPageSize, p.Size)
+	assert.Nil(t, p.Cursor)
+}
+
+func TestParsePage_CursorRoundTrip(t *testing.T) {
+	now := time.Now()
+	key := time.Date(2026, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	c := jsonapi.Cursor{Sort: "-last_seen_at", Key: key, ID: "a1", Before: true, IssuedAt: now.Unix()}
+
+	p, err := jsonapi.ParsePage(pageRequest(url.Values{"page[cursor]": {c.Encode()}, "page[size]": {"10"}}), now)
+	require.NoError(t, err)
+	assert.Equal(t, 10, p.Size)
+	require.NotNil(t, p.Cursor)
+	assert.Equal(t, "-last_seen_at", p.Cursor.Sort)
+	assert.True(t, key.Equal(p.Cursor.Key))
+	assert.Equal(t, "a1", p.Cursor.ID)
+	assert.True(t, p.Cursor.Before)
+}
+
+func TestParsePage_Invalid(t *testing.T) {
+	now := time.Now()
+	expired := jsonapi.Cursor{Sort: "created_at", ID: "a1", IssuedAt: now.Add(-25 * time.Hour).Unix()}.Encode()
+
+	for name, tc := range map[string]struct {
+		params url.Values
+		param  string
+	}{
+		"size zero":       {url.Values{"page[size]": {"0"}}, "page[size]"},
+		"size too large":  {url.Values{"page[size]": {"201"}}, "page[size]"},
+		"size not number": {url.Values{"page[size]": {"ten"}}, "page[size]"},
+		"garbage cursor":  {url.Values{"page[cursor]": {"not-a-cursor"}}, "page[cursor]"},
+		"expired cursor":  {url.Values{"page[cursor]": {expired}}, "page[cursor]"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonapi.ParsePage(pageRequest(tc.params), now)
+			var de *jsonapi.DecodeError
+			require.True(t, errors.As(err, &de))
+			assert.Equal(t, http.StatusBadRequest, de.Status)
+			assert.Equal(t, tc.param, de.Object.Source.Parameter)
+		})
+	}
+}
+
+func TestPageLink_KeepsFilters(t *testing.T) {
+	r := pageRequest(url.Values{"filter[status]": {"firing"}, "page[cursor]": {"old"}})
+	link := jsonapi.PageLink(r, "new")
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/alerts", u.Path)
+	assert.Equal(t, "firing", u.Query().Get("filter[status]"))
+	assert.Equal(t, "new", u.Query().Get("page[cursor]"))
+}
@@ -51,6 +51,12 @@ func ClaimsFromContext(ctx context.Context) *auth.Claims {
 	return c
 }
 
+// HasPermission reports whether the authenticated caller's roles grant perm.
+func HasPermission(ctx context.Context, perm string) bool {
+	claims := ClaimsFromContext(ctx)
+	return claims != nil && RolesGrant(claims.Roles, perm)
+}
+
 // RequirePermission checks that the authenticated user's roles grant the
 // given permission string. Must be chained after RequireAuth.
 func RequirePermission(perm string) func(http.Handler) http.Handler {
@@ -62,7 +68,7 @@ func RequirePermission(perm string) func(http.Handler) http.Handler {
 					"missing_token", "Unauthorized", "authentication required")
 				return
 			}
-			if !hasPermission(claims.Roles, perm) {
+			if !RolesGrant(claims.Roles, perm) {
 				jsonapi.RenderError(w, http.StatusForbidden,
 					"forbidden", "Forbidden",
 					"your roles do not grant the '"+perm+"' permission")
@@ -98,7 +104,7 @@ var rolePermissions = map[string][]string{
 	},
 	"Responder": {
 		"health:read",
-		"alert:read",
+		"alert:read", "alert:update",
 		"incident:read", "incident:create", "incident:update", "incident:comment",
 		"postmortem:read",
 		"slo:read",
@@ -107,7 +113,7 @@ var rolePermissions = map[string][]string{
 	},
 	"IncidentCommander": {
 		"health:read",
-		"alert:read",
+		"alert:read", "alert:update",
 		"incident:read", "incident:create", "incident:update", "incident:reopen", "incident:comment",
 		"postmortem:read", "postmortem:update", "postmortem:publish",
 		"slo:read",
@@ -117,7 +123,9 @@ var rolePermissions = map[string][]string{
 	"Admin": {"*"}, // wildcard — grants all permissions
 }
 
-func hasPermission(roles []string, perm string) bool {
+// RolesGrant reports whether any of roles grants perm. Use it to check the
+// permissions of a user other than the authenticated caller.
+func RolesGrant(roles []string, perm string) bool {
 	for _, role := range roles {
 		perms := rolePermissions[role]
 		for _, p := range perms {
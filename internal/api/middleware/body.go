@@ -0,0 +1,16 @@
+package middleware
+
+import "net/http"
+
+// LimitBody caps every request body at maxBytes. Reads past the cap fail with
+// *http.MaxBytesError, which jsonapi.Decode reports as 413.
+func LimitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
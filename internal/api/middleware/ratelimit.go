@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/jsonapi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// rejectLogInterval is how often rejections of one source are logged at
+// most; a source over its limit would otherwise log every request.
+const rejectLogInterval = time.Minute
+
+// RateLimitKeyFunc resolves the webhook source a request belongs to and that
+// source's sustained limit in requests per second. Returning ok=false lets
+// the request through unlimited, e.g. when the source does not exist and the
+// handler will answer 404 anyway.
+type RateLimitKeyFunc func(r *http.Request) (sourceID string, rps int, ok bool)
+
+// RateLimiter holds one token bucket per webhook source. Each bucket refills
+// at the source's rate and holds at most one second's worth of tokens.
+type RateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	rejected metric.Int64Counter
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// rejected counts the rejections since they were last logged, at
+	// loggedAt.
+	rejected int
+	loggedAt time.Time
+}
+
+// NewRateLimiter creates a RateLimiter that reports rejections as
+// webhook_rate_limited_total through the global OTel meter provider.
+func NewRateLimiter() *RateLimiter {
+	// Instrument creation only fails for invalid names; fall back to a no-op.
+	rejected, _ := otel.Meter("github.com/d9705996/autopsy/internal/api/middleware").
+		Int64Counter("webhook_rate_limited",
+			metric.WithDescription("Webhook requests rejected by the per-source rate limit."))
+	return &RateLimiter{
+		buckets:  make(map[string]*tokenBucket),
+		rejected: rejected,
+	}
+}
+
+// Allow takes a token from sourceID's bucket at time now. When the bucket is
+// empty it returns false and how long until the next token is available.
+func (l *RateLimiter) Allow(sourceID string, rps int, now time.Time) (bool, time.Duration) {
+	if rps <= 0 {
+		return true, 0
+	}
+	rate := float64(rps)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[sourceID]
+	if !ok {
+		b = &tokenBucket{tokens: rate, last: now}
+		l.buckets[sourceID] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(rate, b.tokens+elapsed*rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait
+}
+
+// logRejection counts a rejection of sourceID at now. It reports whether to
+// log it and, if so, how many rejections the log line covers.
+func (l *RateLimiter) logRejection(sourceID string, now time.Time) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[sourceID]
+	if !ok {
+		return 1, true
+	}
+	b.rejected++
+	if now.Sub(b.loggedAt) < rejectLogInterval {
+		return 0, false
+	}
+	n := b.rejected
+	b.rejected, b.loggedAt = 0, now
+	return n, true
+}
+
+// RateLimit rejects requests over their source's limit with 429 Too Many
+// Requests and a Retry-After header. Rejections are logged at most once a
+// minute per source.
+func RateLimit(l *RateLimiter, keyFn RateLimitKeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sourceID, rps, ok := keyFn(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			now := time.Now()
+			allowed, wait := l.Allow(sourceID, rps, now)
+			if !allowed {
+				l.rejected.Add(r.Context(), 1, metric.WithAttributes(attribute.String("source_id", sourceID)))
+				if n, ok := l.logRejection(sourceID, now); ok {
+					slog.WarnContext(r.Context(), "webhook rate limited", "source_id", sourceID, "rps", rps, "rejected", n)
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+				jsonapi.RenderError(w, http.StatusTooManyRequests,
+					"rate_limited", "Too Many Requests",
+					"webhook source exceeded its limit of "+strconv.Itoa(rps)+" requests per second")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, as Retry-After requires.
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	l := middleware.NewRateLimiter()
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := range 4 {
+		ok, _ := l.Allow("src-1", 4, now)
+		assert.True(t, ok, "request %d is within the burst", i)
+	}
+	ok, wait := l.Allow("src-1", 4, now)
+	assert.False(t, ok)
+	assert.Equal(t, 250*time.Millisecond, wait)
+
+	ok, _ = l.Allow("src-1", 4, now.Add(250*time.Millisecond))
+	assert.True(t, ok, "one token refills after 1/rps seconds")
+}
+
+func TestRateLimiter_SourcesAreIndependent(t *testing.T) {
+	l := middleware.NewRateLimiter()
+	now := time.Now()
+
+	ok, _ := l.Allow("src-1", 1, now)
+	assert.True(t, ok)
+	ok, _ = l.Allow("src-1", 1, now)
+	assert.False(t, ok)
+
+	ok, _ = l.Allow("src-2", 1, now)
+	assert.True(t, ok)
+}
+
+func TestRateLimit_Returns429WithRetryAfter(t *testing.T) {
+	keyFn := func(r *http.Request) (string, int, bool) {
+		return r.Header.Get("X-Source"), 1, r.Header.Get("X-Source") != ""
+	}
+	h := middleware.RateLimit(middleware.NewRateLimiter(), keyFn)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	send := func(source string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/x", nil)
+		if source != "" {
+			req.Header.Set("X-Source", source)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusAccepted, send("src-1").Code)
+
+	w := send("src-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/vnd.api+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"rate_limited"`)
+
+	assert.Equal(t, http.StatusAccepted, send("").Code, "unresolved sources are not limited")
+}
+
+func TestRateLimit_LogsRejectionsOncePerInterval(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	keyFn := func(*http.Request) (string, int, bool) { return "src-1", 1, true }
+	h := middleware.RateLimit(middleware.NewRateLimiter(), keyFn)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	codes := map[int]int{}
+	for range 5 {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/x", nil))
+		codes[w.Code]++
+	}
+	require.Equal(t, map[int]int{http.StatusAccepted: 1, http.StatusTooManyRequests: 4}, codes)
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("webhook rate limited")))
+	assert.Contains(t, logs.String(), "rejected=1")
+}
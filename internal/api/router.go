@@ -9,22 +9,123 @@ import (
 "github.com/d9705996/autopsy/internal/health"
 )
 
+// Handlers groups the resource handlers mounted by RegisterRoutes.
+type Handlers struct {
+Health         *health.Handler
+Auth           *handler.AuthHandler
+Webhook        *handler.WebhookHandler
+Alerts         *handler.AlertHandler
+Silences       *handler.SilenceHandler
+WebhookSources *handler.WebhookSourceHandler
+Incidents      *handler.IncidentHandler
+Components     *handler.ComponentHandler
+Schedules      *handler.ScheduleHandler
+Escalation     *handler.EscalationHandler
+Users          *handler.UserHandler
+PageActions    *handler.PageActionHandler
+Stream         *handler.StreamHandler
+}
+
 // RegisterRoutes registers all application routes on mux.
-func RegisterRoutes(mux *http.ServeMux, h *health.Handler, auth *handler.AuthHandler, jwtSecret string) {
+func RegisterRoutes(mux *http.ServeMux, h Handlers, jwtSecret string) {
 // Public health endpoints (no auth required)
-mux.HandleFunc("GET /api/v1/health", h.ServeHealth)
-mux.HandleFunc("GET /api/v1/ready", h.ServeReady)
+mux.HandleFunc("GET /api/v1/health", h.Health.ServeHealth)
+mux.HandleFunc("GET /api/v1/ready", h.Health.ServeReady)
 
 // Auth endpoints (no auth required)
-mux.HandleFunc("POST /api/v1/auth/login", auth.Login)
-mux.HandleFunc("POST /api/v1/auth/refresh", auth.Refresh)
+mux.HandleFunc("POST /api/v1/auth/login", h.Auth.Login)
+mux.HandleFunc("POST /api/v1/auth/refresh", h.Auth.Refresh)
+
+// Webhook ingest (authenticated by per-source HMAC signature, not JWT)
+webhookLimit := middleware.RateLimit(middleware.NewRateLimiter(), h.Webhook.RateLimitKey)
+mux.Handle("POST /api/v1/webhooks/{source}", webhookLimit(http.HandlerFunc(h.Webhook.Receive)))
+
+// Page notification links (authenticated by a single-use page token, not JWT)
+mux.HandleFunc("GET /api/v1/pages/{token}/ack", h.PageActions.ConfirmAck)
+mux.HandleFunc("POST /api/v1/pages/{token}/ack", h.PageActions.Ack)
+mux.HandleFunc("GET /api/v1/pages/{token}/resolve", h.PageActions.ConfirmResolve)
+mux.HandleFunc("POST /api/v1/pages/{token}/resolve", h.PageActions.Resolve)
 
 // Auth-required routes — wrap with RequireAuth middleware.
 protected := middleware.RequireAuth(jwtSecret)
-mux.Handle("POST /api/v1/auth/logout", protected(http.HandlerFunc(auth.Logout)))
+mux.Handle("POST /api/v1/auth/logout", protected(http.HandlerFunc(h.Auth.Logout)))
+
+// The caller's own profile and notification channels
+mux.Handle("GET /api/v1/users/me", protected(http.HandlerFunc(h.Users.Me)))
+mux.Handle("PATCH /api/v1/users/me", protected(http.HandlerFunc(h.Users.UpdateMe)))
+
+// Webhook source administration
+mux.Handle("GET /api/v1/webhook-sources", withPermission(protected, "webhook_source:read", h.WebhookSources.List))
+mux.Handle("POST /api/v1/webhook-sources", withPermission(protected, "webhook_source:update", h.WebhookSources.Create))
+mux.Handle("GET /api/v1/webhook-sources/{id}", withPermission(protected, "webhook_source:read", h.WebhookSources.Get))
+mux.Handle("PATCH /api/v1/webhook-sources/{id}", withPermission(protected, "webhook_source:update", h.WebhookSources.Update))
+mux.Handle("POST /api/v1/webhook-sources/{id}/dry-run", withPermission(protected, "webhook_source:read", h.WebhookSources.DryRun))
+mux.Handle("POST /api/v1/webhook-sources/{id}/prompt-preview", withPermission(protected, "webhook_source:read", h.WebhookSources.PromptPreview))
+
+// Alerts
+mux.Handle("GET /api/v1/alerts", withPermission(protected, "alert:read", h.Alerts.List))
+mux.Handle("GET /api/v1/alerts/{id}", withPermission(protected, "alert:read", h.Alerts.Get))
+mux.Handle("GET /api/v1/alerts/{id}/triage", withPermission(protected, "alert:read", h.Alerts.Triage))
+mux.Handle("POST /api/v1/alerts/{id}/acknowledge", withPermission(protected, "alert:update", h.Alerts.Acknowledge))
+mux.Handle("POST /api/v1/alerts/{id}/resolve", withPermission(protected, "alert:update", h.Alerts.Resolve))
+
+// Silences
+mux.Handle("GET /api/v1/silences", withPermission(protected, "alert:read", h.Silences.List))
+mux.Handle("POST /api/v1/silences", withPermission(protected, "alert:update", h.Silences.Create))
+mux.Handle("GET /api/v1/silences/{id}", withPermission(protected, "alert:read", h.Silences.Get))
+mux.Handle("DELETE /api/v1/silences/{id}", withPermission(protected, "alert:update", h.Silences.Expire))
+
+// Incidents
+mux.Handle("GET /api/v1/incidents", withPermission(protected, "incident:read", h.Incidents.List))
+mux.Handle("POST /api/v1/incidents", withPermission(protected, "incident:create", h.Incidents.Create))
+mux.Handle("GET /api/v1/incidents/{id}", withPermission(protected, "incident:read", h.Incidents.Get))
+mux.Handle("PATCH /api/v1/incidents/{id}", withPermission(protected, "incident:update", h.Incidents.Update))
+mux.Handle("POST /api/v1/incidents/{id}/reopen", withPermission(protected, "incident:reopen", h.Incidents.Reopen))
+mux.Handle("PUT /api/v1/incidents/{id}/roles/{role}", withPermission(protected, "incident:update", h.Incidents.AssignRole))
+mux.Handle("DELETE /api/v1/incidents/{id}/roles/{role}", withPermission(protected, "incident:update", h.Incidents.UnassignRole))
+mux.Handle("GET /api/v1/incidents/{id}/timeline", withPermission(protected, "incident:comment", h.Incidents.Timeline))
+mux.Handle("POST /api/v1/incidents/{id}/timeline", withPermission(protected, "incident:comment", h.Incidents.Comment))
+
+// Component catalog
+mux.Handle("GET /api/v1/components", withPermission(protected, "incident:read", h.Components.List))
+mux.Handle("POST /api/v1/components", withPermission(protected, "component:update", h.Components.Create))
+mux.Handle("GET /api/v1/components/{id}", withPermission(protected, "incident:read", h.Components.Get))
+mux.Handle("PATCH /api/v1/components/{id}", withPermission(protected, "component:update", h.Components.Update))
+
+// On-call schedules
+mux.Handle("GET /api/v1/schedules", withPermission(protected, "oncall:read", h.Schedules.List))
+mux.Handle("POST /api/v1/schedules", withPermission(protected, "oncall:update", h.Schedules.Create))
+mux.Handle("GET /api/v1/schedules/{id}", withPermission(protected, "oncall:read", h.Schedules.Get))
+mux.Handle("PATCH /api/v1/schedules/{id}", withPermission(protected, "oncall:update", h.Schedules.Update))
+mux.Handle("GET /api/v1/schedules/{id}/oncall", withPermission(protected, "oncall:read", h.Schedules.OnCall))
+mux.Handle("GET /api/v1/schedules/{id}/shifts", withPermission(protected, "oncall:read", h.Schedules.Shifts))
+mux.Handle("POST /api/v1/schedules/{id}/rotations", withPermission(protected, "oncall:update", h.Schedules.CreateRotation))
+mux.Handle("PATCH /api/v1/schedules/{id}/rotations/{rotation_id}", withPermission(protected, "oncall:update", h.Schedules.UpdateRotation))
+mux.Handle("DELETE /api/v1/schedules/{id}/rotations/{rotation_id}", withPermission(protected, "oncall:update", h.Schedules.DeleteRotation))
+mux.Handle("GET /api/v1/schedules/{id}/overrides", withPermission(protected, "oncall:read", h.Schedules.ListOverrides))
+mux.Handle("POST /api/v1/schedules/{id}/overrides", withPermission(protected, "oncall:update", h.Schedules.CreateOverride))
+mux.Handle("DELETE /api/v1/schedules/{id}/overrides/{override_id}", withPermission(protected, "oncall:update", h.Schedules.DeleteOverride))
+
+// Escalation policies and pages
+mux.Handle("GET /api/v1/escalation-policies", withPermission(protected, "oncall:read", h.Escalation.List))
+mux.Handle("POST /api/v1/escalation-policies", withPermission(protected, "oncall:update", h.Escalation.Create))
+mux.Handle("GET /api/v1/escalation-policies/{id}", withPermission(protected, "oncall:read", h.Escalation.Get))
+mux.Handle("PATCH /api/v1/escalation-policies/{id}", withPermission(protected, "oncall:update", h.Escalation.Update))
+mux.Handle("GET /api/v1/incidents/{id}/pages", withPermission(protected, "incident:read", h.Escalation.ListPages))
+mux.Handle("POST /api/v1/incidents/{id}/pages", withPermission(protected, "incident:update", h.Escalation.CreatePage))
+mux.Handle("POST /api/v1/pages/{id}/acknowledge", withPermission(protected, "incident:update", h.Escalation.Acknowledge))
+mux.Handle("GET /api/v1/pages/{id}/deliveries", withPermission(protected, "incident:read", h.Escalation.Deliveries))
+
+// Live updates (Server-Sent Events)
+mux.Handle("GET /api/v1/stream", withPermission(protected, "incident:read", h.Stream.Stream))
 
 // Catch-all 404
 mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 http.NotFound(w, r)
 })
 }
+
+// withPermission chains RequireAuth and RequirePermission(perm) in front of fn.
+func withPermission(protected func(http.Handler) http.Handler, perm string, fn http.HandlerFunc) http.Handler {
+return protected(middleware.RequirePermission(perm)(fn))
+}
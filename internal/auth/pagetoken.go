@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPageTokenInvalid is returned for page tokens that are malformed,
+	// carry a bad signature, or were never issued for the action.
+	ErrPageTokenInvalid = errors.New("auth: page token is invalid")
+	// ErrPageTokenUsed is returned for page tokens that were already
+	// redeemed.
+	ErrPageTokenUsed = errors.New("auth: page token has already been used")
+	// ErrPageTokenExpired is returned for page tokens past their expiry.
+	ErrPageTokenExpired = errors.New("auth: page token has expired")
+)
+
+// PageTokenStore issues and redeems the single-use tokens in page
+// notification links. A token is a random value plus its HMAC signature,
+// so forged tokens are rejected without a lookup; like refresh tokens,
+// only the SHA-256 hash of the random value is stored.
+type PageTokenStore struct {
+	db     *gorm.DB
+	secret []byte
+}
+
+// NewPageTokenStore creates a PageTokenStore that signs tokens with secret.
+func NewPageTokenStore(db *gorm.DB, secret string) *PageTokenStore {
+	return &PageTokenStore{db: db, secret: []byte(secret)}
+}
+
+// Issue creates a token that lets userID perform action on pageID once
+// within ttl, and returns it.
+func (s *PageTokenStore) Issue(ctx context.Context, pageID, userID, action string, ttl time.Duration) (string, error) {
+	raw, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("generate page token: %w", err)
+	}
+	pt := &model.PageToken{
+		PageID:    pageID,
+		UserID:    userID,
+		Action:    action,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.db.WithContext(ctx).Create(pt).Error; err != nil {
+		return "", fmt.Errorf("store page token: %w", err)
+	}
+	return raw + "." + s.sign(raw), nil
+}
+
+// IssueOnce is Issue for links that may be sent more than once, such as on
+// a retried delivery: every call with the same key and action returns the
+// same token, stored by the first call, which also fixes its expiry. The
+// token is derived from key with the store's secret rather than stored.
+func (s *PageTokenStore) IssueOnce(ctx context.Context, key, pageID, userID, action string, ttl time.Duration) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("page-link:" + action + ":" + key))
+	raw := hex.EncodeToString(mac.Sum(nil))
+	pt := &model.PageToken{
+		PageID:    pageID,
+		UserID:    userID,
+		Action:    action,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(pt).Error; err != nil {
+		return "", fmt.Errorf("store page token: %w", err)
+	}
+	return raw + "." + s.sign(raw), nil
+}
+
+// Redeem checks token for action and marks it used. Exactly one of any
+// concurrent redemptions of a token succeeds.
+func (s *PageTokenStore) Redeem(ctx context.Context, token, action string) (*model.PageToken, error) {
+	raw, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(raw))) {
+		return nil, ErrPageTokenInvalid
+	}
+	db := s.db.WithContext(ctx)
+	var pt model.PageToken
+	err := db.Where("token_hash = ? AND action = ?", hashToken(raw), action).First(&pt).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrPageTokenInvalid
+	case err != nil:
+		return nil, fmt.Errorf("load page token: %w", err)
+	}
+	now := time.Now()
+	if pt.UsedAt != nil {
+		return nil, ErrPageTokenUsed
+	}
+	if now.After(pt.ExpiresAt) {
+		return nil, ErrPageTokenExpired
+	}
+
+	res := db.Model(&model.PageToken{}).
+		Where("id = ? AND used_at IS NULL", pt.ID).
+		Update("used_at", now)
+	if res.Error != nil {
+		return nil, fmt.Errorf("redeem page token: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrPageTokenUsed
+	}
+	pt.UsedAt = &now
+	return &pt, nil
+}
+
+// Release makes a redeemed token usable again, for when the action it was
+// redeemed for failed.
+func (s *PageTokenStore) Release(ctx context.Context, pt *model.PageToken) error {
+	if err := s.db.WithContext(ctx).Model(&model.PageToken{}).
+		Where("id = ?", pt.ID).
+		Update("used_at", nil).Error; err != nil {
+		return fmt.Errorf("release page token: %w", err)
+	}
+	pt.UsedAt = nil
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of raw under the store's secret.
+func (s *PageTokenStore) sign(raw string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("page-token:" + raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,144 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPageToken_RedeemOnce(t *testing.T) {
+	gormDB := dbtest.New(t)
+	store := auth.NewPageTokenStore(gormDB, testSecret)
+	ctx := context.Background()
+
+	token, err := store.Issue(ctx, "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+
+	var stored model.PageToken
+	require.NoError(t, gormDB.First(&stored).Error)
+	raw, _, _ := strings.Cut(token, ".")
+	assert.NotContains(t, stored.TokenHash, raw, "only the hash is stored")
+
+	pt, err := store.Redeem(ctx, token, model.PageActionAck)
+	require.NoError(t, err)
+	assert.Equal(t, "page-1", pt.PageID)
+	assert.Equal(t, "user-1", pt.UserID)
+	assert.NotNil(t, pt.UsedAt)
+
+	_, err = store.Redeem(ctx, token, model.PageActionAck)
+	require.ErrorIs(t, err, auth.ErrPageTokenUsed)
+
+	require.NoError(t, store.Release(ctx, pt))
+	assert.Nil(t, pt.UsedAt)
+	_, err = store.Redeem(ctx, token, model.PageActionAck)
+	require.NoError(t, err, "a released token can be redeemed again")
+}
+
+func TestPageToken_ConcurrentRedeem(t *testing.T) {
+	store := auth.NewPageTokenStore(dbtest.New(t), testSecret)
+	ctx := context.Background()
+	token, err := store.Issue(ctx, "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		ok int
+	)
+	for range 5 {
+		wg.Go(func() {
+			if _, err := store.Redeem(ctx, token, model.PageActionAck); err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1, ok)
+}
+
+func TestPageToken_IssueOnce(t *testing.T) {
+	gormDB := dbtest.New(t)
+	store := auth.NewPageTokenStore(gormDB, testSecret)
+	ctx := context.Background()
+
+	first, err := store.IssueOnce(ctx, "delivery-1", "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+	again, err := store.IssueOnce(ctx, "delivery-1", "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "the same key gets the same token")
+
+	for name, other := range map[string][2]string{
+		"other action": {"delivery-1", model.PageActionResolve},
+		"other key":    {"delivery-2", model.PageActionAck},
+	} {
+		token, err := store.IssueOnce(ctx, other[0], "page-1", "user-1", other[1], time.Hour)
+		require.NoError(t, err, name)
+		assert.NotEqual(t, first, token, name)
+	}
+	var n int64
+	require.NoError(t, gormDB.Model(&model.PageToken{}).Count(&n).Error)
+	assert.Equal(t, int64(3), n)
+
+	_, err = store.Redeem(ctx, first, model.PageActionAck)
+	require.NoError(t, err)
+	_, err = store.IssueOnce(ctx, "delivery-1", "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+	_, err = store.Redeem(ctx, first, model.PageActionAck)
+	require.ErrorIs(t, err, auth.ErrPageTokenUsed, "issuing again does not renew a used token")
+}
+
+func TestPageToken_Rejected(t *testing.T) {
+	gormDB := dbtest.New(t)
+	store := auth.NewPageTokenStore(gormDB, testSecret)
+	ctx := context.Background()
+
+	token, err := store.Issue(ctx, "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+	raw, _, _ := strings.Cut(token, ".")
+
+	for name, tc := range map[string]struct {
+		token, action string
+	}{
+		"wrong action":     {token, model.PageActionResolve},
+		"unsigned":         {raw, model.PageActionAck},
+		"bad signature":    {raw + "." + strings.Repeat("0", 64), model.PageActionAck},
+		"signed elsewhere": {signWith(t, gormDB, "another-secret-at-least-32-bytes"), model.PageActionAck},
+		"empty":            {"", model.PageActionAck},
+		"bare separator":   {".", model.PageActionAck},
+	} {
+		_, err := store.Redeem(ctx, tc.token, tc.action)
+		assert.ErrorIs(t, err, auth.ErrPageTokenInvalid, name)
+	}
+
+	_, err = store.Redeem(ctx, token, model.PageActionAck)
+	require.NoError(t, err, "rejected attempts do not use up the token")
+}
+
+func TestPageToken_Expired(t *testing.T) {
+	store := auth.NewPageTokenStore(dbtest.New(t), testSecret)
+	ctx := context.Background()
+
+	token, err := store.Issue(ctx, "page-1", "user-1", model.PageActionResolve, -time.Second)
+	require.NoError(t, err)
+	_, err = store.Redeem(ctx, token, model.PageActionResolve)
+	require.ErrorIs(t, err, auth.ErrPageTokenExpired)
+}
+
+// signWith issues a token from a store with a different secret.
+func signWith(t *testing.T, gormDB *gorm.DB, secret string) string {
+	t.Helper()
+	token, err := auth.NewPageTokenStore(gormDB, secret).Issue(context.Background(), "page-1", "user-1", model.PageActionAck, time.Hour)
+	require.NoError(t, err)
+	return token
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// pageTokenRetention is how long expired page tokens are kept. It outlasts
+// every retry of a delivery, so IssueOnce never stores a pruned token again.
+const pageTokenRetention = 24 * time.Hour
+
+// PruneTokens deletes expired refresh tokens, and page tokens that expired
+// more than a day ago, every hour until ctx is done.
+func PruneTokens(ctx context.Context, db *gorm.DB, log *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if err := DeleteExpiredTokens(ctx, db, time.Now()); err != nil && ctx.Err() == nil {
+			log.Error("prune tokens", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpiredTokens deletes the tokens PruneTokens prunes as of now.
+func DeleteExpiredTokens(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", now).Delete(&model.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at < ?", now.Add(-pageTokenRetention)).Delete(&model.PageToken{}).Error
+}
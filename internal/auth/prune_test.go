@@ -0,0 +1,40 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpiredTokens(t *testing.T) {
+	gormDB := dbtest.New(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for hash, expires := range map[string]time.Time{"expired": now.Add(-time.Minute), "live": now.Add(time.Hour)} {
+		require.NoError(t, gormDB.Create(&model.RefreshToken{UserID: "user-1", TokenHash: hash, ExpiresAt: expires}).Error)
+	}
+	for hash, expires := range map[string]time.Time{
+		"long expired":     now.Add(-48 * time.Hour),
+		"recently expired": now.Add(-time.Hour),
+		"live":             now.Add(time.Hour),
+	} {
+		require.NoError(t, gormDB.Create(&model.PageToken{
+			PageID: "page-1", UserID: "user-1", Action: model.PageActionAck, TokenHash: hash, ExpiresAt: expires,
+		}).Error)
+	}
+
+	require.NoError(t, auth.DeleteExpiredTokens(ctx, gormDB, now))
+
+	var refresh, page []string
+	require.NoError(t, gormDB.Model(&model.RefreshToken{}).Pluck("token_hash", &refresh).Error)
+	require.NoError(t, gormDB.Model(&model.PageToken{}).Order("token_hash").Pluck("token_hash", &page).Error)
+	assert.Equal(t, []string{"live"}, refresh)
+	assert.Equal(t, []string{"live", "recently expired"}, page, "page tokens are kept a day past expiry")
+}
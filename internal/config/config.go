@@ -7,23 +7,28 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all runtime configuration for Autopsy.
 type Config struct {
-	HTTP   HTTPConfig
-	DB     DBConfig
-	Log    LogConfig
-	JWT    JWTConfig
-	AI     AIConfig
-	App    AppConfig
-	Worker WorkerConfig
-	OTel   OTelConfig
+	HTTP    HTTPConfig
+	DB      DBConfig
+	Log     LogConfig
+	JWT     JWTConfig
+	AI      AIConfig
+	App     AppConfig
+	Worker  WorkerConfig
+	OTel    OTelConfig
+	Webhook WebhookConfig
+	Notify  NotifyConfig
 }
 
 type HTTPConfig struct {
-	Port int
+	Port         int
+	MaxBodyBytes int64  // cap on every request body
+	PublicURL    string // base URL of links sent outside the app
 }
 
 type DBConfig struct {
@@ -45,10 +50,17 @@ type JWTConfig struct {
 }
 
 type AIConfig struct {
-	Provider string
-	APIKey   string
-	APIBase  string
-	Model    string
+	Provider  string
+	APIKey    string
+	APIBase   string // empty uses the provider's public endpoint
+	Model     string // empty uses the provider's default model
+	MaxTokens int
+	Timeout   time.Duration
+	// PostmortemFTSLimit and PostmortemFTSThreshold bound the related
+	// postmortems added to triage prompts: at most Limit matches scoring at
+	// least Threshold, in [0, 1) (see postmortem.Searcher).
+	PostmortemFTSLimit     int
+	PostmortemFTSThreshold float64
 }
 
 type AppConfig struct {
@@ -64,6 +76,28 @@ type OTelConfig struct {
 	OTLPEndpoint string
 }
 
+type WebhookConfig struct {
+	ReplayWindow time.Duration // max clock skew between signer and receiver
+	MaxBodyBytes int64         // cap on webhook payloads; at most HTTP.MaxBodyBytes
+}
+
+type NotifyConfig struct {
+	Timeout time.Duration // per-attempt timeout for webhook and SMTP delivery
+	// SMTP settings for email notifications; email is disabled when
+	// SMTPHost is empty.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	// PageTokenTTL is how long the acknowledge and resolve links in a page
+	// notification stay valid.
+	PageTokenTTL time.Duration
+	// PrivateHosts are lowercase webhook hosts that may be reached on
+	// private, loopback or link-local addresses; all others must be public.
+	PrivateHosts []string
+}
+
 // Load reads configuration from environment variables, applies defaults,
 // and returns an error if any required field is absent.
 func Load() (*Config, error) {
@@ -71,6 +105,8 @@ func Load() (*Config, error) {
 
 	// HTTP
 	cfg.HTTP.Port = envInt("HTTP_PORT", 8080)
+	cfg.HTTP.MaxBodyBytes = int64(envInt("HTTP_MAX_BODY_BYTES", 2<<20))
+	cfg.HTTP.PublicURL = envStr("PUBLIC_URL", fmt.Sprintf("http://localhost:%d", cfg.HTTP.Port))
 
 	// DB
 	cfg.DB.Driver = envStr("DB_DRIVER", "sqlite")
@@ -103,8 +139,18 @@ func Load() (*Config, error) {
 	// AI
 	cfg.AI.Provider = envStr("AI_PROVIDER", "noop")
 	cfg.AI.APIKey = os.Getenv("AI_API_KEY")
-	cfg.AI.APIBase = envStr("AI_API_BASE", "https://api.openai.com/v1")
-	cfg.AI.Model = envStr("AI_MODEL", "gpt-4o-mini")
+	cfg.AI.APIBase = os.Getenv("AI_API_BASE")
+	cfg.AI.Model = os.Getenv("AI_MODEL")
+	cfg.AI.MaxTokens = envInt("AI_MAX_TOKENS", 1024)
+	cfg.AI.Timeout, err = envDuration("AI_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("AI_TIMEOUT: %w", err)
+	}
+	cfg.AI.PostmortemFTSLimit = envInt("AI_POSTMORTEM_FTS_LIMIT", 5)
+	cfg.AI.PostmortemFTSThreshold, err = envFloat("AI_POSTMORTEM_FTS_THRESHOLD", 0.05)
+	if err != nil {
+		return nil, fmt.Errorf("AI_POSTMORTEM_FTS_THRESHOLD: %w", err)
+	}
 
 	// App
 	cfg.App.SeedAdminEmail = envStr("SEED_ADMIN_EMAIL", "admin@autopsy.local")
@@ -116,6 +162,32 @@ func Load() (*Config, error) {
 	// OTel
 	cfg.OTel.OTLPEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
 
+	// Webhooks
+	cfg.Webhook.ReplayWindow, err = envDuration("WEBHOOK_REPLAY_WINDOW", 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_REPLAY_WINDOW: %w", err)
+	}
+	cfg.Webhook.MaxBodyBytes = int64(envInt("WEBHOOK_MAX_BODY_BYTES", 1<<20))
+	if cfg.Webhook.MaxBodyBytes > cfg.HTTP.MaxBodyBytes {
+		return nil, errors.New("WEBHOOK_MAX_BODY_BYTES must not exceed HTTP_MAX_BODY_BYTES")
+	}
+
+	// Notifications
+	cfg.Notify.Timeout, err = envDuration("NOTIFY_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("NOTIFY_TIMEOUT: %w", err)
+	}
+	cfg.Notify.SMTPHost = os.Getenv("SMTP_HOST")
+	cfg.Notify.SMTPPort = envInt("SMTP_PORT", 587)
+	cfg.Notify.SMTPUser = os.Getenv("SMTP_USER")
+	cfg.Notify.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.Notify.SMTPFrom = envStr("SMTP_FROM", "autopsy@localhost")
+	cfg.Notify.PageTokenTTL, err = envDuration("PAGE_TOKEN_TTL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("PAGE_TOKEN_TTL: %w", err)
+	}
+	cfg.Notify.PrivateHosts = envList("NOTIFY_PRIVATE_HOSTS")
+
 	return cfg, nil
 }
 
@@ -126,6 +198,18 @@ func envStr(key, def string) string {
 	return def
 }
 
+// envList splits a comma-separated variable into its lowercase, non-empty
+// items.
+func envList(key string) []string {
+	var items []string
+	for item := range strings.SplitSeq(os.Getenv(key), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func envInt(key string, def int) int {
 	v := os.Getenv(key)
 	if v == "" {
@@ -138,6 +222,18 @@ func envInt(key string, def int) int {
 	return n
 }
 
+func envFloat(key string, def float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", v, err)
+	}
+	return f, nil
+}
+
 func envDuration(key string, def time.Duration) (time.Duration, error) {
 	v := os.Getenv(key)
 	if v == "" {
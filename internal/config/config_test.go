@@ -62,6 +62,8 @@ assert.Equal(t, 720*time.Hour, cfg.JWT.RefreshTTL)
 assert.Equal(t, "admin@autopsy.local", cfg.App.SeedAdminEmail)
 assert.Equal(t, "sqlite", cfg.DB.Driver)
 assert.Equal(t, "autopsy.db", cfg.DB.File)
+assert.Equal(t, time.Hour, cfg.Notify.PageTokenTTL)
+assert.Empty(t, cfg.Notify.PrivateHosts)
 }
 
 func TestLoad_Overrides(t *testing.T) {
@@ -80,6 +82,7 @@ cfg, err := config.Load()
 require.NoError(t, err)
 
 assert.Equal(t, 9090, cfg.HTTP.Port)
+assert.Equal(t, "http://localhost:9090", cfg.HTTP.PublicURL)
 assert.Equal(t, "debug", cfg.Log.Level)
 assert.Equal(t, "text", cfg.Log.Format)
 assert.Equal(t, "openai", cfg.AI.Provider)
@@ -98,3 +101,64 @@ _, err := config.Load()
 require.Error(t, err)
 assert.Contains(t, err.Error(), "JWT_ACCESS_TTL")
 }
+
+func TestLoad_WebhookReplayWindow(t *testing.T) {
+t.Setenv("JWT_SECRET", "test-secret")
+t.Setenv("WEBHOOK_REPLAY_WINDOW", "")
+
+cfg, err := config.Load()
+require.NoError(t, err)
+assert.Equal(t, 5*time.Minute, cfg.Webhook.ReplayWindow)
+
+t.Setenv("WEBHOOK_REPLAY_WINDOW", "30s")
+cfg, err = config.Load()
+require.NoError(t, err)
+assert.Equal(t, 30*time.Second, cfg.Webhook.ReplayWindow)
+
+t.Setenv("WEBHOOK_REPLAY_WINDOW", "soon")
+_, err = config.Load()
+require.Error(t, err)
+assert.Contains(t, err.Error(), "WEBHOOK_REPLAY_WINDOW")
+}
+
+func TestLoad_MaxBodyBytes(t *testing.T) {
+t.Setenv("JWT_SECRET", "test-secret")
+t.Setenv("HTTP_MAX_BODY_BYTES", "")
+t.Setenv("WEBHOOK_MAX_BODY_BYTES", "")
+
+cfg, err := config.Load()
+require.NoError(t, err)
+assert.EqualValues(t, 2<<20, cfg.HTTP.MaxBodyBytes)
+assert.EqualValues(t, 1<<20, cfg.Webhook.MaxBodyBytes)
+
+t.Setenv("WEBHOOK_MAX_BODY_BYTES", "4194304")
+_, err = config.Load()
+require.Error(t, err)
+assert.Contains(t, err.Error(), "WEBHOOK_MAX_BODY_BYTES")
+}
+
+func TestLoad_PostmortemFTS(t *testing.T) {
+t.Setenv("DB_DSN", "postgres://localhost/test")
+t.Setenv("JWT_SECRET", "test-secret")
+os.Unsetenv("AI_POSTMORTEM_FTS_LIMIT")
+os.Unsetenv("AI_POSTMORTEM_FTS_THRESHOLD")
+
+cfg, err := config.Load()
+require.NoError(t, err)
+assert.Equal(t, 5, cfg.AI.PostmortemFTSLimit)
+assert.InDelta(t, 0.05, cfg.AI.PostmortemFTSThreshold, 1e-9)
+
+t.Setenv("AI_POSTMORTEM_FTS_THRESHOLD", "high")
+_, err = config.Load()
+require.Error(t, err)
+assert.Contains(t, err.Error(), "AI_POSTMORTEM_FTS_THRESHOLD")
+}
+
+func TestLoad_NotifyPrivateHosts(t *testing.T) {
+t.Setenv("JWT_SECRET", "test-secret")
+t.Setenv("NOTIFY_PRIVATE_HOSTS", " Hooks.Internal , ,10.0.0.5")
+
+cfg, err := config.Load()
+require.NoError(t, err)
+assert.Equal(t, []string{"hooks.internal", "10.0.0.5"}, cfg.Notify.PrivateHosts)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"strings"
 
 	"github.com/d9705996/autopsy/internal/config"
 	"github.com/d9705996/autopsy/internal/model"
@@ -39,8 +40,9 @@ func New(ctx context.Context, cfg *config.DBConfig) (*gorm.DB, *pgxpool.Pool, er
 
 // openSQLite opens (or creates) the SQLite database file and runs AutoMigrate.
 func openSQLite(cfg *config.DBConfig) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(cfg.File), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(cfg.File)), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite: %w", err)
@@ -57,12 +59,103 @@ func openSQLite(cfg *config.DBConfig) (*gorm.DB, error) {
 		&model.Organization{},
 		&model.User{},
 		&model.RefreshToken{},
+		&model.WebhookSource{},
+		&model.Silence{},
+		&model.Alert{},
+		&model.TriageResult{},
+		&model.Postmortem{},
+		&model.Component{},
+		&model.Incident{},
+		&model.TimelineEntry{},
+		&model.Event{},
+		&model.OnCallSchedule{},
+		&model.Rotation{},
+		&model.Override{},
+		&model.EscalationPolicy{},
+		&model.Page{},
+		&model.NotificationDelivery{},
+		&model.DeliveryAttempt{},
+		&model.PageToken{},
+		&model.OutboxJob{},
 	); err != nil {
 		return nil, fmt.Errorf("sqlite automigrate: %w", err)
 	}
+	if err := migrateSQLiteExtras(db); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
 
+// sqliteFTSStatements create the FTS5 index over postmortem titles and root
+// causes, kept in sync with the postmortems table by triggers. This is the
+// SQLite counterpart of the tsvector column in migration 0015.
+var sqliteFTSStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS postmortems_fts USING fts5(
+		id UNINDEXED, title, root_cause, tokenize = 'porter unicode61')`,
+	`CREATE TRIGGER IF NOT EXISTS postmortems_fts_insert AFTER INSERT ON postmortems BEGIN
+		INSERT INTO postmortems_fts (id, title, root_cause) VALUES (new.id, new.title, new.root_cause);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS postmortems_fts_delete AFTER DELETE ON postmortems BEGIN
+		DELETE FROM postmortems_fts WHERE id = old.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS postmortems_fts_update AFTER UPDATE OF title, root_cause ON postmortems BEGIN
+		DELETE FROM postmortems_fts WHERE id = old.id;
+		INSERT INTO postmortems_fts (id, title, root_cause) VALUES (new.id, new.title, new.root_cause);
+	END`,
+}
+
+// sqliteTimelineStatements make timeline entries append-only, like the
+// trigger in migration 0017. model.TimelineEntry's hooks catch most writes
+// early; these also cover raw SQL.
+var sqliteTimelineStatements = []string{
+	`CREATE TRIGGER IF NOT EXISTS timeline_entries_no_update BEFORE UPDATE ON timeline_entries BEGIN
+		SELECT RAISE(ABORT, 'timeline entries are append-only');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS timeline_entries_no_delete BEFORE DELETE ON timeline_entries BEGIN
+		SELECT RAISE(ABORT, 'timeline entries are append-only');
+	END`,
+}
+
+// sqlitePageStatements allow one triggered page per incident and policy,
+// like the partial index in migration 0027.
+var sqlitePageStatements = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_pages_open ON pages (incident_id, policy_id)
+		WHERE status = 'triggered'`,
+}
+
+// migrateSQLiteExtras runs after AutoMigrate, which cannot express virtual
+// tables, triggers or partial indexes.
+func migrateSQLiteExtras(db *gorm.DB) error {
+	for _, stmt := range sqliteFTSStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("sqlite fts: %w", err)
+		}
+	}
+	for _, stmt := range sqliteTimelineStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("sqlite timeline triggers: %w", err)
+		}
+	}
+	for _, stmt := range sqlitePageStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("sqlite page indexes: %w", err)
+		}
+	}
+	return nil
+}
+
+// sqliteDSN adds connection options to the SQLite file path. Transactions
+// take the write lock up front (_txlock=immediate) and wait for it rather
+// than failing with SQLITE_BUSY, so concurrent writers queue up instead of
+// erroring.
+func sqliteDSN(file string) string {
+	sep := "?"
+	if strings.Contains(file, "?") {
+		sep = "&"
+	}
+	return file + sep + "_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
 // openPostgres opens a GORM Postgres connection via pgx/v5/stdlib and also
 // returns a raw pgxpool.Pool for use by the River job queue.
 func openPostgres(ctx context.Context, cfg *config.DBConfig) (*gorm.DB, *pgxpool.Pool, error) {
@@ -91,7 +184,8 @@ func openPostgres(ctx context.Context, cfg *config.DBConfig) (*gorm.DB, *pgxpool
 	// Open a GORM DB backed by pgx/stdlib (reuses the pgx connection config).
 	sqlDB := stdlib.OpenDBFromPool(pool)
 	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
 	})
 	if err != nil {
 		pool.Close()
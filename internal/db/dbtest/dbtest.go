@@ -0,0 +1,45 @@
+// Package dbtest opens throwaway databases for tests.
+package dbtest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/d9705996/autopsy/internal/db"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// New opens a migrated SQLite database in a temporary directory, closed and
+// removed when t ends.
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+	gormDB, _, err := db.New(context.Background(), &config.DBConfig{
+		Driver: "sqlite",
+		File:   filepath.Join(t.TempDir(), "autopsy.db"),
+	})
+	require.NoError(t, err)
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return gormDB
+}
+
+// CreateAlice creates the user alice, a Responder who is notified through
+// one webhook channel.
+func CreateAlice(t testing.TB, gormDB *gorm.DB) *model.User {
+	t.Helper()
+	alice := &model.User{
+		ID:    "alice",
+		Email: "alice@example.com",
+		Roles: model.StringSlice{"Responder"},
+		NotificationChannels: []model.NotificationChannel{
+			{Type: model.ChannelWebhook, Target: "https://hooks.example.com/alice"},
+		},
+	}
+	require.NoError(t, gormDB.Create(alice).Error)
+	return alice
+}
@@ -0,0 +1,2 @@
+-- 0005_webhook_sources.down.sql
+DROP TABLE IF EXISTS webhook_sources;
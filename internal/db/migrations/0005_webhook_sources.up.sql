@@ -0,0 +1,12 @@
+-- 0005_webhook_sources.up.sql
+CREATE TABLE IF NOT EXISTS webhook_sources (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    name            TEXT        NOT NULL UNIQUE,
+    source_type     TEXT        NOT NULL DEFAULT 'generic',
+    hmac_secret     TEXT        NOT NULL,
+    enabled         BOOLEAN     NOT NULL DEFAULT TRUE,
+    field_mapping   TEXT        NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
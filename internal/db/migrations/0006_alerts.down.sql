@@ -0,0 +1,2 @@
+-- 0006_alerts.down.sql
+DROP TABLE IF EXISTS alerts;
@@ -0,0 +1,23 @@
+-- 0006_alerts.up.sql
+CREATE TABLE IF NOT EXISTS alerts (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    source_id       UUID        NOT NULL REFERENCES webhook_sources(id),
+    source          TEXT        NOT NULL,
+    fingerprint     TEXT        NOT NULL,
+    title           TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    severity_hint   TEXT        NOT NULL DEFAULT '',
+    status          TEXT        NOT NULL DEFAULT 'firing',
+    labels          TEXT        NOT NULL DEFAULT '{}',
+    payload         TEXT        NOT NULL DEFAULT '{}',
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    received_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at     TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_source_id   ON alerts (source_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts (fingerprint);
+CREATE INDEX IF NOT EXISTS idx_alerts_status      ON alerts (status);
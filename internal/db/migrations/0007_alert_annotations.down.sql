@@ -0,0 +1,6 @@
+-- 0007_alert_annotations.down.sql
+DROP INDEX IF EXISTS idx_alerts_source_fingerprint_status;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS generator_url,
+    DROP COLUMN IF EXISTS annotations;
@@ -0,0 +1,8 @@
+-- 0007_alert_annotations.up.sql
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS annotations   TEXT NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS generator_url TEXT NOT NULL DEFAULT '';
+
+-- Resolved notifications look up the open alert by source and fingerprint.
+CREATE INDEX IF NOT EXISTS idx_alerts_source_fingerprint_status
+    ON alerts (source_id, fingerprint, status);
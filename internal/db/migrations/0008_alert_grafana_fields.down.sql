@@ -0,0 +1,7 @@
+-- 0008_alert_grafana_fields.down.sql
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS silence_url,
+    DROP COLUMN IF EXISTS panel_url,
+    DROP COLUMN IF EXISTS dashboard_url,
+    DROP COLUMN IF EXISTS eval_values,
+    DROP COLUMN IF EXISTS rule_uid;
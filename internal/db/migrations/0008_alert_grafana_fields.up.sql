@@ -0,0 +1,7 @@
+-- 0008_alert_grafana_fields.up.sql
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS rule_uid      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS eval_values   TEXT NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS dashboard_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS panel_url     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS silence_url   TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- 0009_alert_dedup.down.sql
+DROP INDEX IF EXISTS idx_alerts_dedup_key;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS dedup_key,
+    DROP COLUMN IF EXISTS last_seen_at,
+    DROP COLUMN IF EXISTS occurrence_count;
+
+ALTER TABLE webhook_sources
+    DROP COLUMN IF EXISTS dedup_window_seconds;
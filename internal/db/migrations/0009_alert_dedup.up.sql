@@ -0,0 +1,14 @@
+-- 0009_alert_dedup.up.sql
+ALTER TABLE webhook_sources
+    ADD COLUMN IF NOT EXISTS dedup_window_seconds INTEGER NOT NULL DEFAULT 300;
+
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS occurrence_count INTEGER     NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS last_seen_at     TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS dedup_key        TEXT        NULL;
+
+UPDATE alerts SET last_seen_at = received_at WHERE last_seen_at IS NULL;
+ALTER TABLE alerts ALTER COLUMN last_seen_at SET NOT NULL;
+
+-- At most one alert per (source, fingerprint) may absorb repeats at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_dedup_key ON alerts (dedup_key);
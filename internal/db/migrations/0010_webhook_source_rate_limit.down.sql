@@ -0,0 +1,3 @@
+-- 0010_webhook_source_rate_limit.down.sql
+ALTER TABLE webhook_sources
+    DROP COLUMN IF EXISTS rate_limit_rps;
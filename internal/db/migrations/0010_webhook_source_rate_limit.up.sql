@@ -0,0 +1,3 @@
+-- 0010_webhook_source_rate_limit.up.sql
+ALTER TABLE webhook_sources
+    ADD COLUMN IF NOT EXISTS rate_limit_rps INTEGER NOT NULL DEFAULT 100;
@@ -0,0 +1,11 @@
+-- 0011_alert_actions_silences.down.sql
+DROP INDEX IF EXISTS idx_alerts_suppressed;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS silence_id,
+    DROP COLUMN IF EXISTS suppressed,
+    DROP COLUMN IF EXISTS resolved_by,
+    DROP COLUMN IF EXISTS acknowledged_by,
+    DROP COLUMN IF EXISTS acknowledged_at;
+
+DROP TABLE IF EXISTS silences;
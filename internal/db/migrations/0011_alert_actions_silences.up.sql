@@ -0,0 +1,23 @@
+-- 0011_alert_actions_silences.up.sql
+CREATE TABLE IF NOT EXISTS silences (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    matchers        TEXT        NOT NULL,
+    comment         TEXT        NOT NULL DEFAULT '',
+    created_by      UUID        NULL,
+    starts_at       TIMESTAMPTZ NOT NULL,
+    ends_at         TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_silences_ends_at ON silences (ends_at);
+
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS acknowledged_by UUID        NULL,
+    ADD COLUMN IF NOT EXISTS resolved_by     UUID        NULL,
+    ADD COLUMN IF NOT EXISTS suppressed      BOOLEAN     NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS silence_id      UUID        NULL REFERENCES silences(id);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_suppressed ON alerts (suppressed);
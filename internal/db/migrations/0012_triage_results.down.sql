@@ -0,0 +1,5 @@
+-- 0012_triage_results.down.sql
+DROP TABLE IF EXISTS triage_results;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS triage_status;
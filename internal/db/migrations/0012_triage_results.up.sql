@@ -0,0 +1,22 @@
+-- 0012_triage_results.up.sql
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS triage_status TEXT NULL;
+
+CREATE TABLE IF NOT EXISTS triage_results (
+    id                UUID             PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id   UUID             NULL,
+    alert_id          UUID             NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    attempt           INTEGER          NOT NULL,
+    status            TEXT             NOT NULL,
+    provider          TEXT             NOT NULL,
+    ai_severity       TEXT             NOT NULL DEFAULT '',
+    ai_summary        TEXT             NOT NULL DEFAULT '',
+    probable_cause    TEXT             NOT NULL DEFAULT '',
+    suggested_actions TEXT             NOT NULL DEFAULT '[]',
+    confidence_score  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    error             TEXT             NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_triage_results_alert_id ON triage_results (alert_id);
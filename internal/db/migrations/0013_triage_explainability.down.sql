@@ -0,0 +1,9 @@
+-- 0013_triage_explainability.down.sql
+ALTER TABLE triage_results
+    DROP COLUMN IF EXISTS output_tokens,
+    DROP COLUMN IF EXISTS input_tokens,
+    DROP COLUMN IF EXISTS latency_ms,
+    DROP COLUMN IF EXISTS model,
+    DROP COLUMN IF EXISTS response,
+    DROP COLUMN IF EXISTS prompt,
+    DROP COLUMN IF EXISTS system_prompt;
@@ -0,0 +1,9 @@
+-- 0013_triage_explainability.up.sql
+ALTER TABLE triage_results
+    ADD COLUMN IF NOT EXISTS system_prompt TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS prompt        TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS response      TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS model         TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS latency_ms    BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS input_tokens  INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS output_tokens INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- 0014_webhook_source_ai_settings.down.sql
+ALTER TABLE webhook_sources
+    DROP COLUMN IF EXISTS ai_max_tokens,
+    DROP COLUMN IF EXISTS ai_model,
+    DROP COLUMN IF EXISTS prompt_template;
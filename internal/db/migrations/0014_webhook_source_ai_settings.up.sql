@@ -0,0 +1,5 @@
+-- 0014_webhook_source_ai_settings.up.sql
+ALTER TABLE webhook_sources
+    ADD COLUMN IF NOT EXISTS prompt_template TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ai_model        TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ai_max_tokens   INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- 0015_postmortems.down.sql
+ALTER TABLE triage_results
+    DROP COLUMN IF EXISTS related_postmortems;
+
+DROP TABLE IF EXISTS postmortems;
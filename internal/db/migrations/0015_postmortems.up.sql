@@ -0,0 +1,23 @@
+-- 0015_postmortems.up.sql
+CREATE TABLE IF NOT EXISTS postmortems (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    incident_id     UUID        NULL,
+    title           TEXT        NOT NULL,
+    root_cause      TEXT        NOT NULL DEFAULT '',
+    status          TEXT        NOT NULL DEFAULT 'draft',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Title matches weigh more than root-cause matches in ts_rank.
+    search_vector   TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(root_cause, '')), 'B')
+    ) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_postmortems_incident_id   ON postmortems (incident_id);
+CREATE INDEX IF NOT EXISTS idx_postmortems_status        ON postmortems (status);
+CREATE INDEX IF NOT EXISTS idx_postmortems_search_vector ON postmortems USING GIN (search_vector);
+
+ALTER TABLE triage_results
+    ADD COLUMN IF NOT EXISTS related_postmortems TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+-- 0016_incidents.down.sql
+DROP TABLE IF EXISTS incidents;
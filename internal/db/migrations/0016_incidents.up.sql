@@ -0,0 +1,19 @@
+-- 0016_incidents.up.sql
+CREATE TABLE IF NOT EXISTS incidents (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    title           TEXT        NOT NULL,
+    summary         TEXT        NOT NULL DEFAULT '',
+    severity        TEXT        NOT NULL,
+    status          TEXT        NOT NULL,
+    declared_by     UUID        NULL,
+    declared_at     TIMESTAMPTZ NOT NULL,
+    resolved_at     TIMESTAMPTZ NULL,
+    resolved_by     UUID        NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_severity    ON incidents (severity);
+CREATE INDEX IF NOT EXISTS idx_incidents_status      ON incidents (status);
+CREATE INDEX IF NOT EXISTS idx_incidents_declared_at ON incidents (declared_at);
@@ -0,0 +1,3 @@
+-- 0017_timeline_entries.down.sql
+DROP TABLE IF EXISTS timeline_entries;
+DROP FUNCTION IF EXISTS timeline_entries_append_only();
@@ -0,0 +1,32 @@
+-- 0017_timeline_entries.up.sql
+CREATE TABLE IF NOT EXISTS timeline_entries (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    incident_id     UUID        NOT NULL REFERENCES incidents(id),
+    kind            TEXT        NOT NULL,
+    author_id       UUID        NULL,
+    body            TEXT        NOT NULL DEFAULT '',
+    details         TEXT        NOT NULL DEFAULT '{}',
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_timeline_entries_incident ON timeline_entries (incident_id, occurred_at);
+
+-- The timeline is an audit record: rows can be inserted but never changed.
+CREATE OR REPLACE FUNCTION timeline_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'timeline entries are append-only'
+        USING ERRCODE = 'restrict_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS timeline_entries_append_only ON timeline_entries;
+CREATE TRIGGER timeline_entries_append_only
+    BEFORE UPDATE OR DELETE ON timeline_entries
+    FOR EACH ROW EXECUTE FUNCTION timeline_entries_append_only();
+
+DROP TRIGGER IF EXISTS timeline_entries_no_truncate ON timeline_entries;
+CREATE TRIGGER timeline_entries_no_truncate
+    BEFORE TRUNCATE ON timeline_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION timeline_entries_append_only();
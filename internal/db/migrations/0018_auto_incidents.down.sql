@@ -0,0 +1,13 @@
+-- 0018_auto_incidents.down.sql
+DROP INDEX IF EXISTS idx_alerts_incident_id;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS incident_id;
+
+DROP INDEX IF EXISTS idx_incidents_dedup_key;
+
+ALTER TABLE incidents
+    DROP COLUMN IF EXISTS dedup_key;
+
+ALTER TABLE webhook_sources
+    DROP COLUMN IF EXISTS auto_declare_severity;
@@ -0,0 +1,13 @@
+-- 0018_auto_incidents.up.sql
+ALTER TABLE webhook_sources
+    ADD COLUMN IF NOT EXISTS auto_declare_severity TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS dedup_key TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_dedup_key ON incidents (dedup_key);
+
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS incident_id UUID NULL REFERENCES incidents(id);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_incident_id ON alerts (incident_id);
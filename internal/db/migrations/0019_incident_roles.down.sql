@@ -0,0 +1,7 @@
+-- 0019_incident_roles.down.sql
+DROP INDEX IF EXISTS idx_incidents_commander_id;
+
+ALTER TABLE incidents
+    DROP COLUMN IF EXISTS comms_lead_id,
+    DROP COLUMN IF EXISTS scribe_id,
+    DROP COLUMN IF EXISTS commander_id;
@@ -0,0 +1,7 @@
+-- 0019_incident_roles.up.sql
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS commander_id  UUID NULL,
+    ADD COLUMN IF NOT EXISTS scribe_id     UUID NULL,
+    ADD COLUMN IF NOT EXISTS comms_lead_id UUID NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_commander_id ON incidents (commander_id);
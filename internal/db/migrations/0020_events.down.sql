@@ -0,0 +1,2 @@
+-- 0020_events.down.sql
+DROP TABLE IF EXISTS events;
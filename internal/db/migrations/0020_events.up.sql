@@ -0,0 +1,12 @@
+-- 0020_events.up.sql
+CREATE TABLE IF NOT EXISTS events (
+    id              BIGSERIAL   PRIMARY KEY,
+    organization_id UUID        NULL,
+    type            TEXT        NOT NULL,
+    resource_type   TEXT        NOT NULL,
+    resource_id     TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_organization_id ON events (organization_id);
+CREATE INDEX IF NOT EXISTS idx_events_created_at      ON events (created_at);
@@ -0,0 +1,10 @@
+-- 0021_incident_impact.down.sql
+ALTER TABLE incidents
+    DROP COLUMN IF EXISTS mitigated_at,
+    DROP COLUMN IF EXISTS acknowledged_at,
+    DROP COLUMN IF EXISTS detected_at,
+    DROP COLUMN IF EXISTS impact_ended_at,
+    DROP COLUMN IF EXISTS impact_started_at;
+
+DROP TABLE IF EXISTS incident_components;
+DROP TABLE IF EXISTS components;
@@ -0,0 +1,28 @@
+-- 0021_incident_impact.up.sql
+CREATE TABLE IF NOT EXISTS components (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    name            TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_components_name ON components (name);
+
+CREATE TABLE IF NOT EXISTS incident_components (
+    incident_id  UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    component_id UUID NOT NULL REFERENCES components(id) ON DELETE CASCADE,
+    PRIMARY KEY (incident_id, component_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_components_component_id ON incident_components (component_id);
+
+ALTER TABLE incidents
+    ADD COLUMN IF NOT EXISTS impact_started_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS impact_ended_at   TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS detected_at       TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS acknowledged_at   TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS mitigated_at      TIMESTAMPTZ NULL;
+
+UPDATE incidents SET detected_at = declared_at WHERE detected_at IS NULL;
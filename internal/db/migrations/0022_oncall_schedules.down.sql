@@ -0,0 +1,4 @@
+-- 0022_oncall_schedules.down.sql
+DROP TABLE IF EXISTS overrides;
+DROP TABLE IF EXISTS rotations;
+DROP TABLE IF EXISTS on_call_schedules;
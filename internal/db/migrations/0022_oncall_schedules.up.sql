@@ -0,0 +1,40 @@
+-- 0022_oncall_schedules.up.sql
+CREATE TABLE IF NOT EXISTS on_call_schedules (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    name            TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    timezone        TEXT        NOT NULL DEFAULT 'UTC',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_on_call_schedules_name ON on_call_schedules (name);
+
+CREATE TABLE IF NOT EXISTS rotations (
+    id                 UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id        UUID        NOT NULL REFERENCES on_call_schedules(id) ON DELETE CASCADE,
+    name               TEXT        NOT NULL DEFAULT '',
+    type               TEXT        NOT NULL,
+    shift_length_hours INTEGER     NOT NULL DEFAULT 0,
+    start_at           TIMESTAMPTZ NOT NULL,
+    participant_ids    TEXT        NOT NULL DEFAULT '[]',
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rotations_schedule_id ON rotations (schedule_id);
+
+CREATE TABLE IF NOT EXISTS overrides (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID        NOT NULL REFERENCES on_call_schedules(id) ON DELETE CASCADE,
+    user_id     UUID        NOT NULL REFERENCES users(id),
+    created_by  UUID        NULL,
+    start_at    TIMESTAMPTZ NOT NULL,
+    end_at      TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_overrides_schedule_id ON overrides (schedule_id, end_at);
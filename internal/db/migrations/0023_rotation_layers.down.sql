@@ -0,0 +1,4 @@
+-- 0023_rotation_layers.down.sql
+ALTER TABLE rotations
+    DROP COLUMN IF EXISTS restrictions,
+    DROP COLUMN IF EXISTS layer;
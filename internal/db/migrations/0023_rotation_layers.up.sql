@@ -0,0 +1,4 @@
+-- 0023_rotation_layers.up.sql
+ALTER TABLE rotations
+    ADD COLUMN IF NOT EXISTS layer        INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS restrictions TEXT    NOT NULL DEFAULT '[]';
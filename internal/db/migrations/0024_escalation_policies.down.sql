@@ -0,0 +1,3 @@
+-- 0024_escalation_policies.down.sql
+DROP TABLE IF EXISTS pages;
+DROP TABLE IF EXISTS escalation_policies;
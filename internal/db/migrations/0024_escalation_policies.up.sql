@@ -0,0 +1,31 @@
+-- 0024_escalation_policies.up.sql
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    name            TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    tiers           TEXT        NOT NULL DEFAULT '[]',
+    repeat_count    INTEGER     NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_escalation_policies_name ON escalation_policies (name);
+
+CREATE TABLE IF NOT EXISTS pages (
+    id                 UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id    UUID        NULL,
+    incident_id        UUID        NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    policy_id          UUID        NOT NULL REFERENCES escalation_policies(id),
+    status             TEXT        NOT NULL DEFAULT 'triggered',
+    tier               INTEGER     NOT NULL DEFAULT 0,
+    cycle              INTEGER     NOT NULL DEFAULT 0,
+    next_escalation_at TIMESTAMPTZ NULL,
+    acknowledged_at    TIMESTAMPTZ NULL,
+    acknowledged_by    UUID        NULL REFERENCES users(id),
+    created_by         UUID        NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pages_incident_id ON pages (incident_id);
@@ -0,0 +1,13 @@
+-- 0025_notification_deliveries.down.sql
+DROP TABLE IF EXISTS delivery_attempts;
+DROP TABLE IF EXISTS notification_deliveries;
+
+ALTER TABLE users
+    ALTER COLUMN notification_channels DROP DEFAULT;
+
+ALTER TABLE users
+    ALTER COLUMN notification_channels TYPE JSONB
+        USING notification_channels::JSONB;
+
+ALTER TABLE users
+    ALTER COLUMN notification_channels SET DEFAULT '[]';
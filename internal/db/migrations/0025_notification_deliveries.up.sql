@@ -0,0 +1,41 @@
+-- 0025_notification_deliveries.up.sql
+-- notification_channels becomes a JSON array of {type, target} stored as
+-- TEXT, like roles in 0004.
+ALTER TABLE users
+    ALTER COLUMN notification_channels TYPE TEXT
+        USING notification_channels::TEXT;
+
+ALTER TABLE users
+    ALTER COLUMN notification_channels SET DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID        NULL,
+    page_id         UUID        NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    incident_id     UUID        NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id         UUID        NOT NULL REFERENCES users(id),
+    channel         TEXT        NOT NULL,
+    target          TEXT        NOT NULL,
+    tier            INTEGER     NOT NULL DEFAULT 0,
+    cycle           INTEGER     NOT NULL DEFAULT 0,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    delivered_at    TIMESTAMPTZ NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_page_id ON notification_deliveries (page_id);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID        NOT NULL REFERENCES notification_deliveries(id) ON DELETE CASCADE,
+    attempt     INTEGER     NOT NULL,
+    status_code INTEGER     NULL,
+    error       TEXT        NOT NULL DEFAULT '',
+    duration_ms BIGINT      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_delivery_id ON delivery_attempts (delivery_id);
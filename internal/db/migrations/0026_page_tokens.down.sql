@@ -0,0 +1,2 @@
+-- 0026_page_tokens.down.sql
+DROP TABLE IF EXISTS page_tokens;
@@ -0,0 +1,15 @@
+-- 0026_page_tokens.up.sql
+-- Single-use acknowledge/resolve links in page notifications. Like
+-- refresh_tokens, only the SHA-256 hash of each token is stored.
+CREATE TABLE IF NOT EXISTS page_tokens (
+    id          UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id     UUID        NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    user_id     UUID        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action      TEXT        NOT NULL,
+    token_hash  TEXT        NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_page_tokens_page_id ON page_tokens (page_id);
@@ -0,0 +1,2 @@
+-- 0027_pages_open_unique.down.sql
+DROP INDEX IF EXISTS idx_pages_open;
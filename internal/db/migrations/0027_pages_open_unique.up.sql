@@ -0,0 +1,5 @@
+-- 0027_pages_open_unique.up.sql
+-- At most one triggered page per incident and policy, so concurrent
+-- requests to page an incident cannot both start one.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pages_open ON pages (incident_id, policy_id)
+    WHERE status = 'triggered';
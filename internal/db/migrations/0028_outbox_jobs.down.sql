@@ -0,0 +1,2 @@
+-- 0028_outbox_jobs.down.sql
+DROP TABLE IF EXISTS outbox_jobs;
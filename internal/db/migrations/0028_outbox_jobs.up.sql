@@ -0,0 +1,9 @@
+-- 0028_outbox_jobs.up.sql
+-- Jobs staged in the transaction of the change that calls for them, and
+-- moved onto the River queue once they commit.
+CREATE TABLE IF NOT EXISTS outbox_jobs (
+    id         BIGSERIAL   PRIMARY KEY,
+    kind       TEXT        NOT NULL,
+    args       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,429 @@
+// Package escalation pages incident responders through escalation policies,
+// moving on to the next tier whenever a tier does not acknowledge in time.
+package escalation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/notify"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when a page does not exist.
+	ErrNotFound = errors.New("escalation: page not found")
+	// ErrPolicyNotFound is returned when an escalation policy does not exist.
+	ErrPolicyNotFound = errors.New("escalation: policy not found")
+	// ErrIncidentResolved is returned when paging for a resolved incident.
+	ErrIncidentResolved = errors.New("escalation: incident is resolved")
+	// ErrAlreadyPaging is returned when the incident already has a
+	// triggered page for the policy.
+	ErrAlreadyPaging = errors.New("escalation: incident is already being paged with this policy")
+	// ErrPageClosed is returned when acknowledging a page that stopped
+	// because its incident was resolved.
+	ErrPageClosed = errors.New("escalation: page stopped because its incident was resolved")
+)
+
+// Step is a page's pending escalation: the page leaves Tier of Cycle At,
+// unless it is acknowledged first.
+type Step struct {
+	PageID string
+	Tier   int
+	Cycle  int
+	At     time.Time
+}
+
+// Outcome is the result of a paging step: the notification deliveries it
+// queued, which the caller must send, and the escalation step that follows,
+// nil once the page has stopped.
+type Outcome struct {
+	Deliveries []string
+	Next       *Step
+}
+
+// Scheduler queues the jobs an outcome asks for: its deliveries and its
+// next step. Start and Escalate call it with the transaction that records
+// the step, so the jobs should be written in tx and run only once it
+// commits. A step whose jobs cannot be queued is rolled back and fails.
+type Scheduler func(tx *gorm.DB, out Outcome) error
+
+// Service starts, escalates and acknowledges pages.
+type Service struct {
+	db     *gorm.DB
+	oncall *oncall.Service
+}
+
+// NewService creates a Service that resolves schedule targets with oc.
+func NewService(db *gorm.DB, oc *oncall.Service) *Service {
+	return &Service{db: db, oncall: oc}
+}
+
+// Policy loads escalation policy id.
+func (s *Service) Policy(ctx context.Context, id string) (*model.EscalationPolicy, error) {
+	var p model.EscalationPolicy
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&p).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrPolicyNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load escalation policy: %w", err)
+	}
+	return &p, nil
+}
+
+// Get loads page id.
+func (s *Service) Get(ctx context.Context, id string) (*model.Page, error) {
+	var p model.Page
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&p).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load page: %w", err)
+	}
+	return &p, nil
+}
+
+// Start pages incidentID with policy policyID on behalf of actor: it
+// notifies the first tier, has schedule queue the deliveries and next step,
+// and returns the new page with that outcome. A nil schedule queues nothing.
+func (s *Service) Start(ctx context.Context, incidentID, policyID string, actor *string, schedule Scheduler) (*model.Page, Outcome, error) {
+	inc, err := s.loadIncident(ctx, incidentID)
+	if err != nil {
+		return nil, Outcome{}, err
+	}
+	if inc.Status == model.IncidentStatusResolved {
+		return nil, Outcome{}, ErrIncidentResolved
+	}
+	policy, err := s.Policy(ctx, policyID)
+	if err != nil {
+		return nil, Outcome{}, err
+	}
+	if len(policy.Tiers) == 0 {
+		return nil, Outcome{}, fmt.Errorf("escalation: policy %s has no tiers", policy.ID)
+	}
+
+	now := time.Now().UTC()
+	users, err := s.targets(ctx, policy.Tiers[0], now)
+	if err != nil {
+		return nil, Outcome{}, err
+	}
+	next := now.Add(timeout(policy.Tiers[0]))
+	page := &model.Page{
+		OrganizationID:   inc.OrganizationID,
+		IncidentID:       inc.ID,
+		PolicyID:         policy.ID,
+		Status:           model.PageStatusTriggered,
+		NextEscalationAt: &next,
+		CreatedBy:        actor,
+	}
+	var out Outcome
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(page).Error; err != nil {
+			return err
+		}
+		deliveries, err := notify.Queue(tx, page, users)
+		if err != nil {
+			return err
+		}
+		if err := incident.AppendEntry(tx, sentEntry(page, users, actor, now)); err != nil {
+			return err
+		}
+		out = Outcome{Deliveries: deliveries, Next: pending(page)}
+		return out.schedule(tx, schedule)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		// A unique index allows one triggered page per incident and policy.
+		return nil, Outcome{}, ErrAlreadyPaging
+	case err != nil:
+		return nil, Outcome{}, fmt.Errorf("start page: %w", err)
+	}
+	return page, out, nil
+}
+
+// Escalate carries out step at now: it notifies the page's next tier, has
+// schedule queue the deliveries and the step after it, and returns that
+// outcome. A nil schedule queues nothing. After the last tier the
+// policy starts again from the first, RepeatCount times, and then the page
+// is exhausted.
+//
+// The outcome has no next step once the page has stopped: it was
+// acknowledged, its incident was resolved or it was exhausted. If step is
+// stale or comes due early, nothing happens and the page's pending step is
+// scheduled again, so scheduling must ignore steps that are already
+// scheduled.
+func (s *Service) Escalate(ctx context.Context, step Step, now time.Time, schedule Scheduler) (Outcome, error) {
+	now = now.UTC()
+	page, err := s.Get(ctx, step.PageID)
+	if err != nil {
+		return Outcome{}, err
+	}
+	if page.Status != model.PageStatusTriggered || page.NextEscalationAt == nil {
+		return Outcome{}, nil
+	}
+	if page.Tier != step.Tier || page.Cycle != step.Cycle || now.Before(*page.NextEscalationAt) {
+		return s.reschedule(ctx, page, schedule)
+	}
+
+	inc, err := s.loadIncident(ctx, page.IncidentID)
+	if err != nil {
+		return Outcome{}, err
+	}
+	if inc.Status == model.IncidentStatusResolved {
+		return Outcome{}, s.stop(ctx, page, model.PageStatusResolved, now)
+	}
+	policy, err := s.Policy(ctx, page.PolicyID)
+	if err != nil {
+		return Outcome{}, err
+	}
+	tier, cycle := page.Tier+1, page.Cycle
+	if tier >= len(policy.Tiers) {
+		tier, cycle = 0, cycle+1
+	}
+	if cycle > policy.RepeatCount || len(policy.Tiers) == 0 {
+		return Outcome{}, s.stop(ctx, page, model.PageStatusExhausted, now)
+	}
+
+	users, err := s.targets(ctx, policy.Tiers[tier], now)
+	if err != nil {
+		return Outcome{}, err
+	}
+	next := now.Add(timeout(policy.Tiers[tier]))
+	var (
+		moved bool
+		out   Outcome
+	)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only move the page from the tier read above, so an
+		// acknowledgement or a duplicate step cannot be overtaken.
+		res := tx.Model(&model.Page{}).
+			Where("id = ? AND status = ? AND tier = ? AND cycle = ?", page.ID, model.PageStatusTriggered, page.Tier, page.Cycle).
+			Updates(map[string]any{"tier": tier, "cycle": cycle, "next_escalation_at": next, "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		moved = true
+		page.Tier, page.Cycle = tier, cycle
+		deliveries, err := notify.Queue(tx, page, users)
+		if err != nil {
+			return err
+		}
+		if err := incident.AppendEntry(tx, sentEntry(page, users, nil, now)); err != nil {
+			return err
+		}
+		out = Outcome{Deliveries: deliveries, Next: &Step{PageID: page.ID, Tier: tier, Cycle: cycle, At: next}}
+		return out.schedule(tx, schedule)
+	})
+	if err != nil {
+		return Outcome{}, fmt.Errorf("escalate page: %w", err)
+	}
+	if !moved {
+		// Lost a race with an acknowledgement or another step.
+		if page, err = s.Get(ctx, step.PageID); err != nil || page.Status != model.PageStatusTriggered {
+			return Outcome{}, err
+		}
+		return s.reschedule(ctx, page, schedule)
+	}
+	return out, nil
+}
+
+// reschedule schedules page's pending step again, for steps that find the
+// page elsewhere.
+func (s *Service) reschedule(ctx context.Context, page *model.Page, schedule Scheduler) (Outcome, error) {
+	out := Outcome{Next: pending(page)}
+	if err := out.schedule(s.db.WithContext(ctx), schedule); err != nil {
+		return Outcome{}, fmt.Errorf("reschedule page: %w", err)
+	}
+	return out, nil
+}
+
+func (o Outcome) schedule(tx *gorm.DB, schedule Scheduler) error {
+	if schedule == nil {
+		return nil
+	}
+	return schedule(tx, o)
+}
+
+// pending returns the escalation step page is waiting on. page must be
+// triggered.
+func pending(page *model.Page) *Step {
+	return &Step{PageID: page.ID, Tier: page.Tier, Cycle: page.Cycle, At: *page.NextEscalationAt}
+}
+
+// stop ends page with status and records why on the timeline.
+func (s *Service) stop(ctx context.Context, page *model.Page, status string, now time.Time) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Page{}).
+			Where("id = ? AND status = ? AND tier = ? AND cycle = ?", page.ID, model.PageStatusTriggered, page.Tier, page.Cycle).
+			Updates(map[string]any{"status": status, "next_escalation_at": nil, "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return incident.AppendEntry(tx, &model.TimelineEntry{
+			OrganizationID: page.OrganizationID,
+			IncidentID:     page.IncidentID,
+			Kind:           model.TimelineKindEscalation,
+			Details:        pageDetails(page, map[string]string{"status": status}),
+			OccurredAt:     now,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("stop page: %w", err)
+	}
+	return nil
+}
+
+// Acknowledge marks page id acknowledged by actor, which stops its
+// escalation, and records the first acknowledgement of its incident.
+// Exhausted pages can still be acknowledged; acknowledging an acknowledged
+// page is a no-op.
+func (s *Service) Acknowledge(ctx context.Context, id string, actor *string) (*model.Page, error) {
+	page, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch page.Status {
+	case model.PageStatusAcknowledged:
+		return page, nil
+	case model.PageStatusResolved:
+		return nil, ErrPageClosed
+	}
+
+	now := time.Now().UTC()
+	var acked bool
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Page{}).
+			Where("id = ? AND status IN ?", id, []string{model.PageStatusTriggered, model.PageStatusExhausted}).
+			Updates(map[string]any{
+				"status":             model.PageStatusAcknowledged,
+				"acknowledged_at":    now,
+				"acknowledged_by":    actor,
+				"next_escalation_at": nil,
+				"updated_at":         now,
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		acked = true
+		res = tx.Model(&model.Incident{}).
+			Where("id = ? AND acknowledged_at IS NULL", page.IncidentID).
+			Updates(map[string]any{"acknowledged_at": now, "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			if err := events.Record(tx, page.OrganizationID, model.EventIncidentUpdated, "incident", page.IncidentID); err != nil {
+				return err
+			}
+		}
+		return incident.AppendEntry(tx, &model.TimelineEntry{
+			OrganizationID: page.OrganizationID,
+			IncidentID:     page.IncidentID,
+			Kind:           model.TimelineKindAck,
+			AuthorID:       actor,
+			Details:        pageDetails(page, nil),
+			OccurredAt:     now,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("acknowledge page: %w", err)
+	}
+	if page, err = s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if !acked && page.Status != model.PageStatusAcknowledged {
+		return nil, ErrPageClosed
+	}
+	return page, nil
+}
+
+func (s *Service) loadIncident(ctx context.Context, id string) (*model.Incident, error) {
+	var inc model.Incident
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&inc).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, incident.ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load incident: %w", err)
+	}
+	return &inc, nil
+}
+
+// targets returns the active users tier pages at t: its users and whoever
+// is on call for its schedules, without duplicates.
+func (s *Service) targets(ctx context.Context, tier model.EscalationTier, t time.Time) ([]string, error) {
+	var ids []string
+	for _, target := range tier.Targets {
+		switch target.Type {
+		case model.EscalationTargetUser:
+			ids = append(ids, target.ID)
+		case model.EscalationTargetSchedule:
+			_, shifts, err := s.oncall.OnCall(ctx, target.ID, t)
+			if errors.Is(err, oncall.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, sh := range shifts {
+				ids = append(ids, sh.UserID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var active []string
+	if err := s.db.WithContext(ctx).Model(&model.User{}).
+		Where("id IN ? AND deactivated_at IS NULL", ids).
+		Pluck("id", &active).Error; err != nil {
+		return nil, fmt.Errorf("load users: %w", err)
+	}
+	var users []string
+	for _, id := range ids {
+		if slices.Contains(active, id) && !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+	return users, nil
+}
+
+// sentEntry records that page notified users.
+func sentEntry(page *model.Page, users []string, actor *string, at time.Time) *model.TimelineEntry {
+	return &model.TimelineEntry{
+		OrganizationID: page.OrganizationID,
+		IncidentID:     page.IncidentID,
+		Kind:           model.TimelineKindPageSent,
+		AuthorID:       actor,
+		Details:        pageDetails(page, map[string]string{"user_ids": strings.Join(users, ",")}),
+		OccurredAt:     at,
+	}
+}
+
+// pageDetails adds page's identity and position to extra.
+func pageDetails(page *model.Page, extra map[string]string) map[string]string {
+	details := map[string]string{
+		"page_id":   page.ID,
+		"policy_id": page.PolicyID,
+		"tier":      strconv.Itoa(page.Tier),
+		"cycle":     strconv.Itoa(page.Cycle),
+	}
+	maps.Copy(details, extra)
+	return details
+}
+
+func timeout(tier model.EscalationTier) time.Duration {
+	return time.Duration(tier.TimeoutMinutes) * time.Minute
+}
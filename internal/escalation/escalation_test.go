@@ -0,0 +1,268 @@
+package escalation_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fixture struct {
+	db       *gorm.DB
+	svc      *escalation.Service
+	incident *model.Incident
+	policy   *model.EscalationPolicy
+}
+
+// setup declares an incident and a policy that pages alice, then whoever is
+// on call for a schedule bob is always on, and repeats once. Only alice has a
+// notification channel.
+func setup(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	dbtest.CreateAlice(t, gormDB)
+	require.NoError(t, gormDB.Create(&model.User{ID: "bob", Email: "bob@example.com"}).Error)
+	sched := &model.OnCallSchedule{Name: "Primary", Rotations: []model.Rotation{{
+		Type:           model.RotationWeekly,
+		StartAt:        time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC),
+		ParticipantIDs: model.StringSlice{"bob"},
+	}}}
+	require.NoError(t, gormDB.Create(sched).Error)
+	policy := &model.EscalationPolicy{
+		Name: "Checkout",
+		Tiers: []model.EscalationTier{
+			{Targets: []model.EscalationTarget{{Type: model.EscalationTargetUser, ID: "alice"}}, TimeoutMinutes: 5},
+			{Targets: []model.EscalationTarget{{Type: model.EscalationTargetSchedule, ID: sched.ID}}, TimeoutMinutes: 10},
+		},
+		RepeatCount: 1,
+	}
+	require.NoError(t, gormDB.Create(policy).Error)
+
+	inc := &model.Incident{Title: "Checkout errors", Severity: model.SeveritySEV2}
+	require.NoError(t, incident.NewService(gormDB).Declare(ctx, inc, nil))
+	return &fixture{
+		db:       gormDB,
+		svc:      escalation.NewService(gormDB, oncall.NewService(gormDB)),
+		incident: inc,
+		policy:   policy,
+	}
+}
+
+func (f *fixture) start(t *testing.T) (*model.Page, *escalation.Step) {
+	t.Helper()
+	page, out, err := f.svc.Start(context.Background(), f.incident.ID, f.policy.ID, nil, nil)
+	require.NoError(t, err)
+	return page, out.Next
+}
+
+func (f *fixture) entries(t *testing.T, kind string) []model.TimelineEntry {
+	t.Helper()
+	var entries []model.TimelineEntry
+	require.NoError(t, f.db.Where("incident_id = ? AND kind = ?", f.incident.ID, kind).
+		Order("occurred_at, id").Find(&entries).Error)
+	return entries
+}
+
+func TestEscalate_AdvancesThroughTiersAndRepeats(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	page, step := f.start(t)
+	assert.Equal(t, model.PageStatusTriggered, page.Status)
+	assert.Equal(t, 0, step.Tier)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), step.At, time.Minute)
+
+	var positions [][2]int
+	for step != nil {
+		positions = append(positions, [2]int{step.Tier, step.Cycle})
+		out, err := f.svc.Escalate(ctx, *step, step.At, nil)
+		require.NoError(t, err)
+		if out.Next != nil {
+			assert.True(t, out.Next.At.After(step.At))
+		}
+		step = out.Next
+	}
+	assert.Equal(t, [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}, positions)
+
+	page, err := f.svc.Get(ctx, page.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PageStatusExhausted, page.Status)
+	assert.Nil(t, page.NextEscalationAt)
+
+	sent := f.entries(t, model.TimelineKindPageSent)
+	require.Len(t, sent, 4)
+	var users []string
+	for _, e := range sent {
+		assert.Equal(t, page.ID, e.Details["page_id"])
+		users = append(users, e.Details["user_ids"])
+	}
+	assert.Equal(t, []string{"alice", "bob", "alice", "bob"}, users, "schedule tiers page whoever is on call")
+	stopped := f.entries(t, model.TimelineKindEscalation)
+	require.Len(t, stopped, 1)
+	assert.Equal(t, model.PageStatusExhausted, stopped[0].Details["status"])
+
+	var deliveries []model.NotificationDelivery
+	require.NoError(t, f.db.Where("page_id = ?", page.ID).Order("cycle, tier").Find(&deliveries).Error)
+	require.Len(t, deliveries, 2, "each tier that pages alice queues a delivery to her channel")
+	for i, d := range deliveries {
+		assert.Equal(t, "alice", d.UserID)
+		assert.Equal(t, model.ChannelWebhook, d.Channel)
+		assert.Equal(t, model.DeliveryStatusPending, d.Status)
+		assert.Equal(t, i, d.Cycle)
+	}
+}
+
+func TestAcknowledge_StopsEscalation(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	page, step := f.start(t)
+	alice, bob := "alice", "bob"
+
+	acked, err := f.svc.Acknowledge(ctx, page.ID, &alice)
+	require.NoError(t, err)
+	assert.Equal(t, model.PageStatusAcknowledged, acked.Status)
+	assert.Equal(t, "alice", *acked.AcknowledgedBy)
+	assert.Nil(t, acked.NextEscalationAt)
+
+	out, err := f.svc.Escalate(ctx, *step, step.At, nil)
+	require.NoError(t, err)
+	assert.Nil(t, out.Next)
+	assert.Empty(t, out.Deliveries)
+	assert.Len(t, f.entries(t, model.TimelineKindPageSent), 1)
+
+	acks := f.entries(t, model.TimelineKindAck)
+	require.Len(t, acks, 1)
+	assert.Equal(t, "alice", *acks[0].AuthorID)
+	assert.Equal(t, "0", acks[0].Details["tier"])
+
+	inc, err := incident.NewService(f.db).Get(ctx, f.incident.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, inc.AcknowledgedAt, "the first acknowledgement acknowledges the incident")
+
+	again, err := f.svc.Acknowledge(ctx, page.ID, &bob)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", *again.AcknowledgedBy, "acknowledging twice is a no-op")
+	assert.Len(t, f.entries(t, model.TimelineKindAck), 1)
+}
+
+func TestEscalate_EarlyAndStaleSteps(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	_, step := f.start(t)
+
+	early, err := f.svc.Escalate(ctx, *step, step.At.Add(-time.Minute), nil)
+	require.NoError(t, err)
+	assert.Equal(t, step, early.Next, "an early step is rescheduled unchanged")
+
+	next, err := f.svc.Escalate(ctx, *step, step.At, nil)
+	require.NoError(t, err)
+	require.NotNil(t, next.Next)
+
+	stale, err := f.svc.Escalate(ctx, *step, step.At, nil)
+	require.NoError(t, err)
+	assert.Equal(t, next.Next, stale.Next, "a stale step returns the pending one")
+	assert.Empty(t, stale.Deliveries)
+	assert.Len(t, f.entries(t, model.TimelineKindPageSent), 2)
+}
+
+func TestEscalate_StopsWhenIncidentResolved(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	page, step := f.start(t)
+	_, err := incident.NewService(f.db).Transition(ctx, f.incident.ID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+
+	out, err := f.svc.Escalate(ctx, *step, step.At, nil)
+	require.NoError(t, err)
+	assert.Nil(t, out.Next)
+	page, err = f.svc.Get(ctx, page.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.PageStatusResolved, page.Status)
+
+	_, err = f.svc.Acknowledge(ctx, page.ID, nil)
+	require.ErrorIs(t, err, escalation.ErrPageClosed)
+}
+
+func TestStart_Errors(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	_, _, err := f.svc.Start(ctx, f.incident.ID, "missing", nil, nil)
+	require.ErrorIs(t, err, escalation.ErrPolicyNotFound)
+	_, _, err = f.svc.Start(ctx, "missing", f.policy.ID, nil, nil)
+	require.ErrorIs(t, err, incident.ErrNotFound)
+
+	f.start(t)
+	_, _, err = f.svc.Start(ctx, f.incident.ID, f.policy.ID, nil, nil)
+	require.ErrorIs(t, err, escalation.ErrAlreadyPaging)
+
+	_, err = incident.NewService(f.db).Transition(ctx, f.incident.ID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+	_, _, err = f.svc.Start(ctx, f.incident.ID, f.policy.ID, nil, nil)
+	require.ErrorIs(t, err, escalation.ErrIncidentResolved)
+}
+
+func TestSchedulerFailureRollsBack(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	errQueue := errors.New("queue unavailable")
+	failing := func(*gorm.DB, escalation.Outcome) error { return errQueue }
+
+	_, _, err := f.svc.Start(ctx, f.incident.ID, f.policy.ID, nil, failing)
+	require.ErrorIs(t, err, errQueue)
+	var n int64
+	require.NoError(t, f.db.Model(&model.Page{}).Count(&n).Error)
+	assert.Zero(t, n, "the page is not started")
+	assert.Empty(t, f.entries(t, model.TimelineKindPageSent))
+
+	var scheduled []escalation.Outcome
+	page, out, err := f.svc.Start(ctx, f.incident.ID, f.policy.ID, nil, func(_ *gorm.DB, out escalation.Outcome) error {
+		scheduled = append(scheduled, out)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []escalation.Outcome{out}, scheduled)
+
+	_, err = f.svc.Escalate(ctx, *out.Next, out.Next.At, failing)
+	require.ErrorIs(t, err, errQueue)
+	page, err = f.svc.Get(ctx, page.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, page.Tier, "the page stays on its tier")
+	assert.Len(t, f.entries(t, model.TimelineKindPageSent), 1)
+}
+
+func TestStart_ConcurrentPagesOnce(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started int
+	)
+	for range 5 {
+		wg.Go(func() {
+			_, _, err := f.svc.Start(ctx, f.incident.ID, f.policy.ID, nil, nil)
+			if err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+				return
+			}
+			assert.ErrorIs(t, err, escalation.ErrAlreadyPaging)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1, started)
+	assert.Len(t, f.entries(t, model.TimelineKindPageSent), 1)
+}
@@ -0,0 +1,331 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gorm.io/gorm"
+)
+
+const (
+	// pollInterval is how often the SQLite broker looks for new events.
+	pollInterval = 250 * time.Millisecond
+	// reconnectDelay is the pause before re-establishing a lost LISTEN
+	// connection.
+	reconnectDelay = 2 * time.Second
+	// Retention is how long events are kept for Last-Event-ID resume.
+	Retention = 24 * time.Hour
+	// Lookback is how long before the newest event seen resumed streams and
+	// the reconnecting listener look again for events that committed late.
+	Lookback = time.Minute
+	// subscriberBuffer is how many events a subscriber may fall behind
+	// before it is disconnected.
+	subscriberBuffer = 64
+)
+
+// Broker delivers committed events to the subscribers of this process.
+type Broker struct {
+	db   *gorm.DB
+	pool *pgxpool.Pool
+	log  *slog.Logger
+
+	// last is the newest event published, recorded at lastAt.
+	last   int64
+	lastAt time.Time
+	// seen holds the IDs of events the listener published since Lookback
+	// before lastAt, with when they were recorded, so catching up after a
+	// reconnect does not publish them again. It is swept at sweptAt.
+	seen    map[int64]time.Time
+	sweptAt time.Time
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroker creates a Broker. pool is used to LISTEN for events recorded by
+// any replica; when it is nil (SQLite) the broker polls the events table.
+// Either way it publishes events recorded after NewBroker returns.
+func NewBroker(db *gorm.DB, pool *pgxpool.Pool, log *slog.Logger) *Broker {
+	b := &Broker{db: db, pool: pool, log: log, subs: map[*Subscription]struct{}{}, seen: map[int64]time.Time{}}
+	var latest model.Event
+	if err := db.Order("id DESC").Limit(1).Find(&latest).Error; err != nil {
+		log.Error("load latest event", "err", err)
+	}
+	b.last, b.lastAt = latest.ID, latest.CreatedAt
+	return b
+}
+
+// Subscription receives the events of one organization. Events is closed
+// when the subscriber falls too far behind or the broker stops; the client
+// should then reconnect and resume from the last event it saw.
+type Subscription struct {
+	orgID  string
+	events chan model.Event
+	broker *Broker
+}
+
+// Events returns the channel of live events.
+func (s *Subscription) Events() <-chan model.Event { return s.events }
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription) Close() { s.broker.drop(s) }
+
+// Subscribe starts delivering orgID's events. Use Since to catch up on
+// events recorded before the subscription.
+func (b *Broker) Subscribe(orgID string) *Subscription {
+	s := &Subscription{orgID: orgID, events: make(chan model.Event, subscriberBuffer), broker: b}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(s.events)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+func (b *Broker) drop(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
+
+// Since returns up to limit of orgID's events with IDs above afterID, oldest
+// first. To resume a stream, start from Rewind rather than the last event
+// the client saw.
+func (b *Broker) Since(ctx context.Context, orgID string, afterID int64, limit int) ([]model.Event, error) {
+	var evs []model.Event
+	err := scoped(b.db.WithContext(ctx), orgID).
+		Where("id > ?", afterID).
+		Order("id").Limit(limit).
+		Find(&evs).Error
+	if err != nil {
+		return nil, fmt.Errorf("load events: %w", err)
+	}
+	return evs, nil
+}
+
+// Rewind returns the ID to replay events after to resume a stream whose
+// client last saw event lastID. Event IDs are assigned on insert, so on
+// Postgres an event can commit after one with a higher ID, and a client
+// that saw lastID may have missed it. Rewind steps back to the first event
+// recorded within Lookback before lastID so those are replayed too; the
+// client sees the rest of that window again.
+func (b *Broker) Rewind(ctx context.Context, lastID int64) (int64, error) {
+	db := b.db.WithContext(ctx)
+	var last model.Event
+	if err := db.Where("id = ?", lastID).Limit(1).Find(&last).Error; err != nil {
+		return 0, fmt.Errorf("load event: %w", err)
+	}
+	if last.ID == 0 {
+		// Pruned, or never recorded.
+		return lastID, nil
+	}
+	var first int64
+	if err := db.Model(&model.Event{}).
+		Select("COALESCE(MIN(id), ?)", lastID+1).
+		Where("id < ? AND created_at >= ?", lastID, last.CreatedAt.Add(-Lookback)).
+		Scan(&first).Error; err != nil {
+		return 0, fmt.Errorf("load events: %w", err)
+	}
+	return first - 1, nil
+}
+
+// scoped limits an events query to one organization. Events without an
+// organization belong to callers without one.
+func scoped(q *gorm.DB, orgID string) *gorm.DB {
+	if orgID == "" {
+		return q.Where("organization_id IS NULL")
+	}
+	return q.Where("organization_id = ?", orgID)
+}
+
+// publish hands ev to every subscriber in its organization. Subscribers
+// whose buffer is full are disconnected rather than blocking the others.
+func (b *Broker) publish(ev model.Event) {
+	org := ""
+	if ev.OrganizationID != nil {
+		org = *ev.OrganizationID
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if s.orgID != org {
+			continue
+		}
+		select {
+		case s.events <- ev:
+		default:
+			b.log.Warn("stream subscriber too slow; disconnecting", "org_id", org)
+			delete(b.subs, s)
+			close(s.events)
+		}
+	}
+}
+
+// Run delivers events until ctx is done, then disconnects every subscriber.
+// It also prunes events older than Retention.
+func (b *Broker) Run(ctx context.Context) {
+	defer b.shutdown()
+	go b.prune(ctx)
+	if b.pool != nil {
+		b.listen(ctx)
+		return
+	}
+	b.poll(ctx)
+}
+
+func (b *Broker) shutdown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
+
+// poll tails the events table. SQLite serialises writers, so event IDs
+// commit in order and tailing by ID misses nothing.
+func (b *Broker) poll(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var evs []model.Event
+		if err := b.db.WithContext(ctx).Where("id > ?", b.last).Order("id").Find(&evs).Error; err != nil {
+			if ctx.Err() == nil {
+				b.log.Error("poll events", "err", err)
+			}
+			continue
+		}
+		for _, ev := range evs {
+			b.publish(ev)
+			b.last = ev.ID
+		}
+	}
+}
+
+// listen follows NOTIFY on a dedicated connection, reconnecting after
+// errors. Each notification carries the ID of one committed event.
+func (b *Broker) listen(ctx context.Context) {
+	for {
+		err := b.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.log.Error("event listener failed; reconnecting", "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) listenOnce(ctx context.Context) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	// Events committed while not listening were announced to nobody.
+	if err := b.catchUp(ctx); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(n.Payload, 10, 64)
+		if err != nil {
+			b.log.Warn("ignoring malformed event notification", "payload", n.Payload)
+			continue
+		}
+		var ev model.Event
+		err = b.db.WithContext(ctx).First(&ev, "id = ?", id).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// Pruned already.
+		case err != nil:
+			b.log.Error("load notified event", "event_id", id, "err", err)
+		default:
+			b.deliver(ev)
+		}
+	}
+}
+
+// catchUp publishes the events the listener may have missed: those after
+// the newest it published, and those that committed late within Lookback
+// before it.
+func (b *Broker) catchUp(ctx context.Context) error {
+	var evs []model.Event
+	if err := b.db.WithContext(ctx).
+		Where("id > ? OR created_at >= ?", b.last, b.lastAt.Add(-Lookback)).
+		Order("id").Find(&evs).Error; err != nil {
+		return fmt.Errorf("catch up on events: %w", err)
+	}
+	for _, ev := range evs {
+		b.deliver(ev)
+	}
+	return nil
+}
+
+// deliver publishes ev for the listener unless it did so already.
+func (b *Broker) deliver(ev model.Event) {
+	if _, ok := b.seen[ev.ID]; ok {
+		return
+	}
+	b.publish(ev)
+	b.seen[ev.ID] = ev.CreatedAt
+	if ev.ID > b.last {
+		b.last, b.lastAt = ev.ID, ev.CreatedAt
+	}
+	if b.lastAt.Sub(b.sweptAt) < Lookback {
+		return
+	}
+	cutoff := b.lastAt.Add(-Lookback)
+	for id, at := range b.seen {
+		if at.Before(cutoff) {
+			delete(b.seen, id)
+		}
+	}
+	b.sweptAt = b.lastAt
+}
+
+// prune deletes expired events every hour.
+func (b *Broker) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if err := b.db.WithContext(ctx).
+			Where("created_at < ?", time.Now().Add(-Retention)).
+			Delete(&model.Event{}).Error; err != nil && ctx.Err() == nil {
+			b.log.Error("prune events", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
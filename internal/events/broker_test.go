@@ -0,0 +1,121 @@
+package events_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newBroker(t *testing.T) (*events.Broker, *gorm.DB) {
+	t.Helper()
+	gormDB := dbtest.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	b := events.NewBroker(gormDB, nil, slog.Default())
+	go b.Run(ctx)
+	return b, gormDB
+}
+
+func receive(t *testing.T, sub *events.Subscription) model.Event {
+	t.Helper()
+	select {
+	case ev, ok := <-sub.Events():
+		require.True(t, ok, "subscription closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+		return model.Event{}
+	}
+}
+
+func TestBroker_DeliversCommittedEventsByOrganization(t *testing.T) {
+	b, gormDB := newBroker(t)
+	acme, other := "acme", "other"
+	subAcme := b.Subscribe(acme)
+	defer subAcme.Close()
+	subNone := b.Subscribe("")
+	defer subNone.Close()
+
+	require.NoError(t, events.Record(gormDB, &other, model.EventAlertCreated, "alert", "a0"))
+	require.NoError(t, gormDB.Transaction(func(tx *gorm.DB) error {
+		return events.Record(tx, &acme, model.EventIncidentCreated, "incident", "i1")
+	}))
+	// Rolled back changes publish nothing.
+	_ = gormDB.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, events.Record(tx, &acme, model.EventIncidentUpdated, "incident", "i1"))
+		return assert.AnError
+	})
+	require.NoError(t, events.Record(gormDB, nil, model.EventAlertCreated, "alert", "a1"))
+	require.NoError(t, events.Record(gormDB, &acme, model.EventAlertCreated, "alert", "a2"))
+
+	ev := receive(t, subAcme)
+	assert.Equal(t, model.EventIncidentCreated, ev.Type)
+	assert.Equal(t, "i1", ev.ResourceID)
+	assert.Equal(t, "a2", receive(t, subAcme).ResourceID)
+	assert.Equal(t, "a1", receive(t, subNone).ResourceID)
+}
+
+func TestBroker_Since(t *testing.T) {
+	b, gormDB := newBroker(t)
+	acme := "acme"
+	for _, id := range []string{"a1", "a2", "a3"} {
+		require.NoError(t, events.Record(gormDB, &acme, model.EventAlertCreated, "alert", id))
+	}
+	require.NoError(t, events.Record(gormDB, nil, model.EventAlertCreated, "alert", "unscoped"))
+
+	all, err := b.Since(context.Background(), acme, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	rest, err := b.Since(context.Background(), acme, all[0].ID, 1)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "a2", rest[0].ResourceID)
+}
+
+func TestBroker_Rewind(t *testing.T) {
+	b, gormDB := newBroker(t)
+	ctx := context.Background()
+	now := time.Now()
+	var evs []model.Event
+	for _, at := range []time.Time{now.Add(-2 * events.Lookback), now.Add(-events.Lookback / 2), now} {
+		ev := model.Event{Type: model.EventAlertCreated, ResourceType: "alert", ResourceID: "a", CreatedAt: at}
+		require.NoError(t, gormDB.Create(&ev).Error)
+		evs = append(evs, ev)
+	}
+
+	from, err := b.Rewind(ctx, evs[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, evs[1].ID-1, from, "events within the lookback are replayed")
+
+	from, err = b.Rewind(ctx, evs[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, evs[0].ID, from)
+
+	from, err = b.Rewind(ctx, 999)
+	require.NoError(t, err)
+	assert.Equal(t, int64(999), from, "unknown events are resumed after as is")
+}
+
+func TestBroker_ClosesSubscriptionsOnStop(t *testing.T) {
+	gormDB := dbtest.New(t)
+	b := events.NewBroker(gormDB, nil, slog.Default())
+	sub := b.Subscribe("")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { b.Run(ctx); close(done) }()
+	cancel()
+	<-done
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	sub.Close()
+}
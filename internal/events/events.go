@@ -0,0 +1,41 @@
+// Package events feeds the live update stream. Changes are recorded as
+// model.Event rows in the same transaction as the change itself, and a
+// Broker fans committed events out to the subscribers of this process.
+//
+// On Postgres every recorded event is announced with NOTIFY, which is only
+// delivered once the transaction commits, so every replica learns about
+// every event. On SQLite there is a single process; its Broker polls the
+// events table instead.
+package events
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// channel is the Postgres NOTIFY channel carrying new event IDs.
+const channel = "autopsy_events"
+
+// Record appends an event of type typ about the given resource. tx should be
+// the transaction making the change, so the event is published if and only
+// if the change commits.
+func Record(tx *gorm.DB, orgID *string, typ, resourceType, resourceID string) error {
+	ev := model.Event{
+		OrganizationID: orgID,
+		Type:           typ,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+	}
+	if err := tx.Create(&ev).Error; err != nil {
+		return fmt.Errorf("record %s event: %w", typ, err)
+	}
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_notify(?, ?)", channel, strconv.FormatInt(ev.ID, 10)).Error; err != nil {
+			return fmt.Errorf("notify %s event: %w", typ, err)
+		}
+	}
+	return nil
+}
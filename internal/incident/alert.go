@@ -0,0 +1,111 @@
+package incident
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// maxDeclareAttempts bounds the find/declare loop in OpenForAlert. Each retry
+// follows a lost race to declare, after which the find should succeed.
+const maxDeclareAttempts = 3
+
+// OpenForAlert attaches a triaged alert to the open incident declared for its
+// fingerprint, or declares one from the alert and its triage result t. The
+// link is recorded on the alert and as an alert_linked timeline entry,
+// followed by an ai_hypothesis entry holding the triage verdict. It
+// reports whether a new incident was declared. Alerts that are already
+// linked are left alone.
+//
+// tx should be a transaction. Concurrent alerts with the same fingerprint are
+// serialised by the unique index on incidents.dedup_key, as in ingest.
+func OpenForAlert(tx *gorm.DB, a *model.Alert, t *model.TriageResult) (*model.Incident, bool, error) {
+	if a.IncidentID != nil {
+		return nil, false, nil
+	}
+	key := model.DedupKey(a.SourceID, a.Fingerprint)
+	for range maxDeclareAttempts {
+		now := time.Now()
+		var inc model.Incident
+		err := tx.Where("dedup_key = ?", key).First(&inc).Error
+		switch {
+		case err == nil:
+			return &inc, false, linkAlert(tx, &inc, a, t, now)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, false, fmt.Errorf("find open incident: %w", err)
+		}
+
+		inc = model.Incident{
+			OrganizationID: a.OrganizationID,
+			Title:          a.Title,
+			Summary:        t.Summary,
+			Severity:       t.Severity,
+			Status:         model.IncidentStatusDeclared,
+			DeclaredAt:     now,
+			DetectedAt:     &a.OccurredAt,
+			DedupKey:       &key,
+		}
+		err = tx.Transaction(func(sp *gorm.DB) error {
+			if err := sp.Create(&inc).Error; err != nil {
+				return err
+			}
+			if err := events.Record(sp, inc.OrganizationID, model.EventIncidentCreated, "incident", inc.ID); err != nil {
+				return err
+			}
+			if err := AppendEntry(sp, statusEntry(&inc, "", nil, now)); err != nil {
+				return err
+			}
+			return linkAlert(sp, &inc, a, t, now)
+		})
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			continue
+		case err != nil:
+			return nil, false, fmt.Errorf("declare incident: %w", err)
+		}
+		return &inc, true, nil
+	}
+	return nil, false, fmt.Errorf("declare incident: dedup key %s still contended after %d attempts", key, maxDeclareAttempts)
+}
+
+// linkAlert points a at inc and appends the alert_linked and ai_hypothesis
+// timeline entries.
+func linkAlert(tx *gorm.DB, inc *model.Incident, a *model.Alert, t *model.TriageResult, at time.Time) error {
+	if err := tx.Model(&model.Alert{}).Where("id = ?", a.ID).Update("incident_id", inc.ID).Error; err != nil {
+		return fmt.Errorf("link alert: %w", err)
+	}
+	a.IncidentID = &inc.ID
+	if err := AppendEntry(tx, &model.TimelineEntry{
+		OrganizationID: inc.OrganizationID,
+		IncidentID:     inc.ID,
+		Kind:           model.TimelineKindAlertLinked,
+		Body:           a.Title,
+		Details: map[string]string{
+			"alert_id":    a.ID,
+			"source":      a.Source,
+			"fingerprint": a.Fingerprint,
+			"ai_severity": t.Severity,
+		},
+		OccurredAt: at,
+	}); err != nil {
+		return err
+	}
+	return AppendEntry(tx, &model.TimelineEntry{
+		OrganizationID: inc.OrganizationID,
+		IncidentID:     inc.ID,
+		Kind:           model.TimelineKindAIHypothesis,
+		Body:           t.Summary,
+		Details: map[string]string{
+			"alert_id":         a.ID,
+			"triage_result_id": t.ID,
+			"probable_cause":   t.ProbableCause,
+			"confidence_score": strconv.FormatFloat(t.ConfidenceScore, 'f', 2, 64),
+		},
+		OccurredAt: at,
+	})
+}
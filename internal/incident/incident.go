@@ -0,0 +1,319 @@
+// Package incident implements the incident lifecycle: declaring incidents
+// and moving them through declared → investigating → identified →
+// monitoring → resolved.
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when the incident does not exist.
+var ErrNotFound = errors.New("incident: not found")
+
+// ErrInvalidTransition matches every *TransitionError.
+var ErrInvalidTransition = errors.New("incident: invalid status transition")
+
+// errMoveLost rolls back an update whose status change lost a race.
+var errMoveLost = errors.New("incident: status changed concurrently")
+
+// TransitionError reports a status change the lifecycle does not allow.
+type TransitionError struct {
+	From, To string
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == model.IncidentStatusResolved {
+		return "incident is resolved and must be reopened before it can become " + e.To
+	}
+	return "incident is " + e.From + " and cannot become " + e.To
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *TransitionError) Is(target error) bool { return target == ErrInvalidTransition }
+
+// transitions lists the statuses each status may move to. Responders may
+// step back to investigating when a fix does not hold. Leaving resolved is
+// a reopen, which needs its own permission and goes through Reopen.
+var transitions = map[string][]string{
+	model.IncidentStatusDeclared:      {model.IncidentStatusInvestigating, model.IncidentStatusResolved},
+	model.IncidentStatusInvestigating: {model.IncidentStatusIdentified, model.IncidentStatusResolved},
+	model.IncidentStatusIdentified:    {model.IncidentStatusInvestigating, model.IncidentStatusMonitoring, model.IncidentStatusResolved},
+	model.IncidentStatusMonitoring:    {model.IncidentStatusInvestigating, model.IncidentStatusResolved},
+	model.IncidentStatusResolved:      {},
+}
+
+// CanTransition reports whether an incident may move from one status to
+// another through Transition.
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// sourcesOf returns the statuses from which Transition may reach to.
+func sourcesOf(to string) []string {
+	var from []string
+	for _, s := range model.IncidentStatuses {
+		if CanTransition(s, to) {
+			from = append(from, s)
+		}
+	}
+	return from
+}
+
+// Service declares incidents and changes their status.
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Declare stores inc as a new incident in the declared status, declared now
+// by actor, and starts its timeline. actor may be nil for incidents declared
+// by the system. DetectedAt defaults to the declaration time, and
+// inc.Components must already exist in the catalog.
+func (s *Service) Declare(ctx context.Context, inc *model.Incident, actor *string) error {
+	now := time.Now()
+	inc.Status = model.IncidentStatusDeclared
+	inc.DeclaredAt = now
+	inc.DeclaredBy = actor
+	if inc.DetectedAt == nil {
+		inc.DetectedAt = &now
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Components.*").Create(inc).Error; err != nil {
+			return err
+		}
+		if err := events.Record(tx, inc.OrganizationID, model.EventIncidentCreated, "incident", inc.ID); err != nil {
+			return err
+		}
+		return AppendEntry(tx, statusEntry(inc, "", actor, now))
+	})
+	if err != nil {
+		return fmt.Errorf("declare incident: %w", err)
+	}
+	return nil
+}
+
+// Transition moves incident id to status to on behalf of actor and returns
+// the updated incident. Moving to the current status is a no-op. An illegal
+// move, including one that lost a race with a concurrent change, returns a
+// *TransitionError.
+func (s *Service) Transition(ctx context.Context, id, to string, actor *string) (*model.Incident, error) {
+	if !slices.Contains(model.IncidentStatuses, to) {
+		return nil, fmt.Errorf("incident: unknown status %q", to)
+	}
+	inc, err := s.Get(ctx, id)
+	if err != nil || inc.Status == to {
+		return inc, err
+	}
+	return s.move(ctx, id, sourcesOf(to), to, actor, transitionUpdates(inc, to, actor))
+}
+
+// transitionUpdates returns the column changes moving inc to status to on
+// behalf of actor: the first move acknowledges the incident, and moving to
+// monitoring or resolved marks it mitigated unless it already is.
+func transitionUpdates(inc *model.Incident, to string, actor *string) map[string]any {
+	now := time.Now()
+	updates := map[string]any{"status": to, "updated_at": now}
+	if inc.AcknowledgedAt == nil {
+		updates["acknowledged_at"] = now
+	}
+	if inc.MitigatedAt == nil && (to == model.IncidentStatusMonitoring || to == model.IncidentStatusResolved) {
+		updates["mitigated_at"] = now
+	}
+	if to == model.IncidentStatusResolved {
+		updates["resolved_at"] = now
+		updates["resolved_by"] = actor
+		// New alerts with the same fingerprint open a new incident.
+		updates["dedup_key"] = nil
+	}
+	return updates
+}
+
+// Reopen moves a resolved incident back to investigating on behalf of actor
+// and clears its resolution and mitigation. Reopening an incident that is
+// not resolved returns a *TransitionError.
+func (s *Service) Reopen(ctx context.Context, id string, actor *string) (*model.Incident, error) {
+	return s.move(ctx, id, []string{model.IncidentStatusResolved}, model.IncidentStatusInvestigating, actor, map[string]any{
+		"status":       model.IncidentStatusInvestigating,
+		"resolved_at":  nil,
+		"resolved_by":  nil,
+		"mitigated_at": nil,
+		"updated_at":   time.Now(),
+	})
+}
+
+// move applies updates if the incident's status is one of from and records
+// the change on the timeline. The status check is part of the UPDATE so
+// concurrent changes cannot both succeed.
+func (s *Service) move(ctx context.Context, id string, from []string, to string, actor *string, updates map[string]any) (*model.Incident, error) {
+	inc, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(from, inc.Status) {
+		return nil, &TransitionError{From: inc.Status, To: to}
+	}
+
+	var moved bool
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		moved, err = moveTx(tx, inc, from, to, actor, updates)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update incident: %w", err)
+	}
+	if inc, err = s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	if !moved && inc.Status != to {
+		return nil, &TransitionError{From: inc.Status, To: to}
+	}
+	return inc, nil
+}
+
+// moveTx applies updates in tx if inc is still in one of the statuses from,
+// records the change and sets inc.Status to to. It reports whether the
+// incident moved.
+func moveTx(tx *gorm.DB, inc *model.Incident, from []string, to string, actor *string, updates map[string]any) (bool, error) {
+	res := tx.Model(&model.Incident{}).
+		Where("id = ? AND status IN ?", inc.ID, from).
+		Updates(updates)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	prev := inc.Status
+	inc.Status = to
+	if err := events.Record(tx, inc.OrganizationID, model.EventIncidentUpdated, "incident", inc.ID); err != nil {
+		return false, err
+	}
+	return true, AppendEntry(tx, statusEntry(inc, prev, actor, time.Now()))
+}
+
+// statusEntry builds the timeline entry recording inc's move from prev to
+// its current status. prev is empty when the incident is declared.
+func statusEntry(inc *model.Incident, prev string, actor *string, at time.Time) *model.TimelineEntry {
+	details := map[string]string{"to": inc.Status}
+	if prev != "" {
+		details["from"] = prev
+	}
+	return &model.TimelineEntry{
+		OrganizationID: inc.OrganizationID,
+		IncidentID:     inc.ID,
+		Kind:           model.TimelineKindStatusChange,
+		AuthorID:       actor,
+		Details:        details,
+		OccurredAt:     at,
+	}
+}
+
+// Append adds e to the timeline of its incident. OccurredAt defaults to now.
+func (s *Service) Append(ctx context.Context, e *model.TimelineEntry) error {
+	inc, err := s.Get(ctx, e.IncidentID)
+	if err != nil {
+		return err
+	}
+	e.OrganizationID = inc.OrganizationID
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return AppendEntry(tx, e)
+	}); err != nil {
+		return fmt.Errorf("append timeline entry: %w", err)
+	}
+	return nil
+}
+
+// AppendEntry stores e and publishes it on the live stream. tx is the caller's
+// transaction, so entries recorded alongside other changes commit with them.
+func AppendEntry(tx *gorm.DB, e *model.TimelineEntry) error {
+	if err := tx.Create(e).Error; err != nil {
+		return err
+	}
+	return events.Record(tx, e.OrganizationID, model.EventTimelineEntryCreated, "timeline_entry", e.ID)
+}
+
+// Update saves inc's editable fields: title, summary, severity, the impact
+// timestamps and, when inc.Components is non-nil, the affected components,
+// which must already exist in the catalog. If to is not empty and differs
+// from inc.Status, the incident also moves to status to on behalf of actor
+// as with Transition, in the same transaction: an illegal move, including
+// one that lost a race with a concurrent change, returns a *TransitionError
+// and saves nothing. inc is reloaded once saved.
+func (s *Service) Update(ctx context.Context, inc *model.Incident, to string, actor *string) error {
+	moving := to != "" && to != inc.Status
+	if moving {
+		if !slices.Contains(model.IncidentStatuses, to) {
+			return fmt.Errorf("incident: unknown status %q", to)
+		}
+		if !CanTransition(inc.Status, to) {
+			return &TransitionError{From: inc.Status, To: to}
+		}
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(inc).Select("title", "summary", "severity", "impact_started_at", "impact_ended_at",
+			"detected_at", "mitigated_at", "updated_at").Updates(inc).Error; err != nil {
+			return err
+		}
+		if inc.Components != nil {
+			if err := tx.Model(inc).Omit("Components.*").Association("Components").Replace(inc.Components); err != nil {
+				return err
+			}
+		}
+		if !moving {
+			return events.Record(tx, inc.OrganizationID, model.EventIncidentUpdated, "incident", inc.ID)
+		}
+		// Only move from the status the changes were made against.
+		moved, err := moveTx(tx, inc, []string{inc.Status}, to, actor, transitionUpdates(inc, to, actor))
+		if err == nil && !moved {
+			err = errMoveLost
+		}
+		return err
+	})
+	if errors.Is(err, errMoveLost) {
+		cur, err := s.Get(ctx, inc.ID)
+		if err != nil {
+			return err
+		}
+		return &TransitionError{From: cur.Status, To: to}
+	}
+	if err != nil {
+		return fmt.Errorf("update incident: %w", err)
+	}
+	saved, err := s.Get(ctx, inc.ID)
+	if err != nil {
+		return err
+	}
+	*inc = *saved
+	return nil
+}
+
+// OrderComponents sorts preloaded components by name. Pass it to Preload so
+// every view of an incident lists its components the same way.
+func OrderComponents(db *gorm.DB) *gorm.DB {
+	return db.Order("name")
+}
+
+// Get loads incident id with its components.
+func (s *Service) Get(ctx context.Context, id string) (*model.Incident, error) {
+	var inc model.Incident
+	err := s.db.WithContext(ctx).Preload("Components", OrderComponents).Where("id = ?", id).First(&inc).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load incident: %w", err)
+	}
+	return &inc, nil
+}
@@ -0,0 +1,328 @@
+package incident_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newService(t *testing.T) *incident.Service {
+	t.Helper()
+	return incident.NewService(dbtest.New(t))
+}
+
+func declare(t *testing.T, s *incident.Service) *model.Incident {
+	t.Helper()
+	inc := &model.Incident{Title: "Checkout errors", Severity: model.SeveritySEV2}
+	require.NoError(t, s.Declare(context.Background(), inc, nil))
+	return inc
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{model.IncidentStatusDeclared, model.IncidentStatusInvestigating, true},
+		{model.IncidentStatusDeclared, model.IncidentStatusResolved, true},
+		{model.IncidentStatusDeclared, model.IncidentStatusMonitoring, false},
+		{model.IncidentStatusInvestigating, model.IncidentStatusIdentified, true},
+		{model.IncidentStatusInvestigating, model.IncidentStatusDeclared, false},
+		{model.IncidentStatusIdentified, model.IncidentStatusMonitoring, true},
+		{model.IncidentStatusMonitoring, model.IncidentStatusInvestigating, true},
+		{model.IncidentStatusMonitoring, model.IncidentStatusIdentified, false},
+		{model.IncidentStatusResolved, model.IncidentStatusInvestigating, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, incident.CanTransition(tt.from, tt.to), "%s → %s", tt.from, tt.to)
+	}
+}
+
+func TestTransition_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	inc := declare(t, s)
+	assert.Equal(t, model.IncidentStatusDeclared, inc.Status)
+
+	actor := "user-1"
+	for _, to := range []string{
+		model.IncidentStatusInvestigating,
+		model.IncidentStatusIdentified,
+		model.IncidentStatusMonitoring,
+		model.IncidentStatusResolved,
+	} {
+		got, err := s.Transition(ctx, inc.ID, to, &actor)
+		require.NoError(t, err, to)
+		assert.Equal(t, to, got.Status)
+	}
+
+	got, err := s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.ResolvedAt)
+	assert.Equal(t, &actor, got.ResolvedBy)
+}
+
+func TestTransition_RejectsIllegalMove(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	inc := declare(t, s)
+
+	_, err := s.Transition(ctx, inc.ID, model.IncidentStatusMonitoring, nil)
+	var te *incident.TransitionError
+	require.True(t, errors.As(err, &te))
+	assert.ErrorIs(t, err, incident.ErrInvalidTransition)
+	assert.Equal(t, model.IncidentStatusDeclared, te.From)
+
+	got, err := s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.IncidentStatusDeclared, got.Status)
+}
+
+func TestTransition_SameStatusIsNoop(t *testing.T) {
+	s := newService(t)
+	inc := declare(t, s)
+
+	got, err := s.Transition(context.Background(), inc.ID, model.IncidentStatusDeclared, nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.IncidentStatusDeclared, got.Status)
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	inc := declare(t, s)
+
+	_, err := s.Reopen(ctx, inc.ID, nil)
+	assert.ErrorIs(t, err, incident.ErrInvalidTransition, "only resolved incidents can be reopened")
+
+	_, err = s.Transition(ctx, inc.ID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+	_, err = s.Transition(ctx, inc.ID, model.IncidentStatusInvestigating, nil)
+	assert.ErrorIs(t, err, incident.ErrInvalidTransition, "leaving resolved needs Reopen")
+
+	got, err := s.Reopen(ctx, inc.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.IncidentStatusInvestigating, got.Status)
+	assert.Nil(t, got.ResolvedAt)
+	assert.Nil(t, got.ResolvedBy)
+}
+
+func TestGet_NotFound(t *testing.T) {
+	_, err := newService(t).Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, incident.ErrNotFound)
+}
+
+func TestTimeline_RecordsStatusChanges(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	inc := declare(t, s)
+	_, err := s.Transition(ctx, inc.ID, model.IncidentStatusInvestigating, nil)
+	require.NoError(t, err)
+	_, err = s.Transition(ctx, inc.ID, model.IncidentStatusMonitoring, nil)
+	require.Error(t, err)
+
+	var entries []model.TimelineEntry
+	require.NoError(t, gormDB.Where("incident_id = ?", inc.ID).Order("occurred_at").Find(&entries).Error)
+	require.Len(t, entries, 2, "rejected transitions are not recorded")
+	assert.Equal(t, map[string]string{"to": model.IncidentStatusDeclared}, entries[0].Details)
+	assert.Equal(t, map[string]string{"from": model.IncidentStatusDeclared, "to": model.IncidentStatusInvestigating}, entries[1].Details)
+}
+
+func TestTimeline_AppendOnly(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	inc := declare(t, s)
+	e := &model.TimelineEntry{IncidentID: inc.ID, Kind: model.TimelineKindComment, Body: "first"}
+	require.NoError(t, s.Append(ctx, e))
+
+	assert.ErrorIs(t, gormDB.Model(e).Update("body", "edited").Error, model.ErrTimelineImmutable)
+	assert.ErrorIs(t, gormDB.Delete(e).Error, model.ErrTimelineImmutable)
+	// Raw SQL skips the hooks and is stopped by the trigger.
+	assert.ErrorContains(t, gormDB.Exec("UPDATE timeline_entries SET body = 'edited'").Error, "append-only")
+	assert.ErrorContains(t, gormDB.Exec("DELETE FROM timeline_entries").Error, "append-only")
+
+	var got model.TimelineEntry
+	require.NoError(t, gormDB.First(&got, "id = ?", e.ID).Error)
+	assert.Equal(t, "first", got.Body)
+}
+
+func TestAppend_UnknownIncident(t *testing.T) {
+	err := newService(t).Append(context.Background(), &model.TimelineEntry{IncidentID: "missing", Kind: model.TimelineKindComment})
+	assert.ErrorIs(t, err, incident.ErrNotFound)
+}
+
+func TestAssignRole_Handover(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	inc := declare(t, s)
+	alice := &model.User{Email: "alice@example.com"}
+	bob := &model.User{Email: "bob@example.com"}
+	require.NoError(t, gormDB.Create(alice).Error)
+	require.NoError(t, gormDB.Create(bob).Error)
+
+	got, err := s.AssignRole(ctx, inc.ID, model.IncidentRoleCommander, &alice.ID, &alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &alice.ID, got.CommanderID)
+	got, err = s.AssignRole(ctx, inc.ID, model.IncidentRoleCommander, &bob.ID, &alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &bob.ID, got.CommanderID)
+	_, err = s.AssignRole(ctx, inc.ID, model.IncidentRoleCommander, &bob.ID, &alice.ID)
+	require.NoError(t, err, "assigning the holder again is a no-op")
+	got, err = s.AssignRole(ctx, inc.ID, model.IncidentRoleScribe, &alice.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &alice.ID, got.ScribeID)
+	got, err = s.AssignRole(ctx, inc.ID, model.IncidentRoleScribe, nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, got.ScribeID)
+
+	var entries []model.TimelineEntry
+	require.NoError(t, gormDB.Where("incident_id = ? AND kind = ?", inc.ID, model.TimelineKindRoleChange).
+		Order("occurred_at").Find(&entries).Error)
+	require.Len(t, entries, 4)
+	assert.Equal(t, map[string]string{"role": "commander", "to": alice.ID}, entries[0].Details)
+	assert.Equal(t, map[string]string{"role": "commander", "from": alice.ID, "to": bob.ID}, entries[1].Details)
+	assert.Equal(t, map[string]string{"role": "scribe", "from": alice.ID}, entries[3].Details)
+}
+
+func TestAssignRole_Errors(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	inc := declare(t, s)
+	missing := "missing"
+
+	_, err := s.AssignRole(ctx, inc.ID, "chef", &missing, nil)
+	assert.ErrorIs(t, err, incident.ErrUnknownRole)
+	_, err = s.AssignRole(ctx, inc.ID, model.IncidentRoleCommander, &missing, nil)
+	assert.ErrorIs(t, err, incident.ErrUserNotFound)
+}
+
+func TestTransition_RecordsResponseTimestamps(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	inc := declare(t, s)
+	require.NotNil(t, inc.DetectedAt)
+	assert.Equal(t, inc.DeclaredAt, *inc.DetectedAt)
+
+	ack, err := s.Transition(ctx, inc.ID, model.IncidentStatusInvestigating, nil)
+	require.NoError(t, err)
+	require.NotNil(t, ack.AcknowledgedAt)
+	assert.Nil(t, ack.MitigatedAt)
+
+	_, err = s.Transition(ctx, inc.ID, model.IncidentStatusIdentified, nil)
+	require.NoError(t, err)
+	mitigated, err := s.Transition(ctx, inc.ID, model.IncidentStatusMonitoring, nil)
+	require.NoError(t, err)
+	require.NotNil(t, mitigated.MitigatedAt)
+	assert.Equal(t, ack.AcknowledgedAt.UnixNano(), mitigated.AcknowledgedAt.UnixNano(), "acknowledged once")
+
+	resolved, err := s.Transition(ctx, inc.ID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+	assert.Equal(t, mitigated.MitigatedAt.UnixNano(), resolved.MitigatedAt.UnixNano(), "mitigated once")
+
+	reopened, err := s.Reopen(ctx, inc.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, reopened.MitigatedAt)
+	assert.NotNil(t, reopened.AcknowledgedAt)
+}
+
+func TestMetrics(t *testing.T) {
+	at := func(min int) *time.Time {
+		ts := time.Date(2026, 3, 1, 12, min, 0, 0, time.UTC)
+		return &ts
+	}
+	inc := model.Incident{
+		ImpactStartedAt: at(0),
+		DetectedAt:      at(5),
+		AcknowledgedAt:  at(7),
+		MitigatedAt:     at(20),
+	}
+	m := inc.Metrics()
+	require.NotNil(t, m.TimeToDetect)
+	assert.Equal(t, 5*time.Minute, *m.TimeToDetect)
+	assert.Equal(t, 2*time.Minute, *m.TimeToAcknowledge)
+	assert.Equal(t, 15*time.Minute, *m.TimeToMitigate)
+	assert.Nil(t, m.TimeToResolve, "not resolved yet")
+
+	inc.ImpactStartedAt = nil
+	assert.Nil(t, inc.Metrics().TimeToDetect, "impact start unknown")
+}
+
+func TestUpdate_ReplacesComponents(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	api := model.Component{Name: "api"}
+	database := model.Component{Name: "database"}
+	require.NoError(t, gormDB.Create(&api).Error)
+	require.NoError(t, gormDB.Create(&database).Error)
+
+	inc := &model.Incident{Title: "Slow queries", Severity: model.SeveritySEV3, Components: []model.Component{database, api}}
+	require.NoError(t, s.Declare(ctx, inc, nil))
+	got, err := s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Components, 2)
+	assert.Equal(t, "api", got.Components[0].Name, "ordered by name")
+
+	got.Components = []model.Component{database}
+	require.NoError(t, s.Update(ctx, got, "", nil))
+	got, err = s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Components, 1)
+	assert.Equal(t, database.ID, got.Components[0].ID)
+
+	var names []string
+	require.NoError(t, gormDB.Model(&model.Component{}).Order("name").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"api", "database"}, names, "catalog untouched")
+}
+
+func TestUpdate_MovesWithFieldChanges(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	s := incident.NewService(gormDB)
+	inc := declare(t, s)
+
+	actor := "user-1"
+	inc.Title = "Checkout down"
+	require.NoError(t, s.Update(ctx, inc, model.IncidentStatusResolved, &actor))
+	assert.Equal(t, "Checkout down", inc.Title)
+	assert.Equal(t, model.IncidentStatusResolved, inc.Status)
+	require.NotNil(t, inc.ResolvedAt)
+	assert.NotNil(t, inc.MitigatedAt, "resolving marks the incident mitigated")
+	assert.Equal(t, &actor, inc.ResolvedBy)
+
+	var entries int64
+	require.NoError(t, gormDB.Model(&model.TimelineEntry{}).Where("incident_id = ?", inc.ID).Count(&entries).Error)
+	assert.Equal(t, int64(2), entries, "declared and resolved")
+}
+
+func TestUpdate_LostMoveSavesNothing(t *testing.T) {
+	ctx := context.Background()
+	s := newService(t)
+	inc := declare(t, s)
+	stale, err := s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	_, err = s.Transition(ctx, inc.ID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+
+	stale.Title = "Checkout down"
+	err = s.Update(ctx, stale, model.IncidentStatusInvestigating, nil)
+	var te *incident.TransitionError
+	require.True(t, errors.As(err, &te))
+	assert.Equal(t, model.IncidentStatusResolved, te.From)
+
+	got, err := s.Get(ctx, inc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Checkout errors", got.Title, "the field changes roll back with the move")
+	assert.Equal(t, model.IncidentStatusResolved, got.Status)
+}
@@ -0,0 +1,103 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownRole is returned for a role outside model.IncidentRoles.
+var ErrUnknownRole = errors.New("incident: unknown role")
+
+// ErrUserNotFound is returned when a role is handed to a user that does not
+// exist or is deactivated.
+var ErrUserNotFound = errors.New("incident: user not found")
+
+// ErrRoleChanged is returned when the role changed hands between reading
+// and updating the incident.
+var ErrRoleChanged = errors.New("incident: role was reassigned concurrently")
+
+// AssignRole hands role on incident id to userID on behalf of actor, or
+// unassigns it when userID is nil, and records the handover on the timeline.
+// Assigning the current holder is a no-op.
+func (s *Service) AssignRole(ctx context.Context, id, role string, userID, actor *string) (*model.Incident, error) {
+	column, ok := model.IncidentRoleColumns[role]
+	if !ok {
+		return nil, ErrUnknownRole
+	}
+	inc, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	prev := inc.RoleHolder(role)
+	if equalIDs(prev, userID) {
+		return inc, nil
+	}
+	if userID != nil {
+		var n int64
+		if err := s.db.WithContext(ctx).Model(&model.User{}).
+			Where("id = ? AND deactivated_at IS NULL", *userID).
+			Count(&n).Error; err != nil {
+			return nil, fmt.Errorf("load user: %w", err)
+		}
+		if n == 0 {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	now := time.Now()
+	details := map[string]string{"role": role}
+	if prev != nil {
+		details["from"] = *prev
+	}
+	if userID != nil {
+		details["to"] = *userID
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only replace the holder read above, so concurrent handovers
+		// cannot both be recorded as taking over from the same user.
+		query := tx.Model(&model.Incident{}).Where("id = ?", id)
+		if prev == nil {
+			query = query.Where(column + " IS NULL")
+		} else {
+			query = query.Where(column+" = ?", *prev)
+		}
+		res := query.Updates(map[string]any{column: userID, "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRoleChanged
+		}
+		if err := events.Record(tx, inc.OrganizationID, model.EventIncidentUpdated, "incident", inc.ID); err != nil {
+			return err
+		}
+		return AppendEntry(tx, &model.TimelineEntry{
+			OrganizationID: inc.OrganizationID,
+			IncidentID:     inc.ID,
+			Kind:           model.TimelineKindRoleChange,
+			AuthorID:       actor,
+			Details:        details,
+			OccurredAt:     now,
+		})
+	})
+	switch {
+	case errors.Is(err, ErrRoleChanged):
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("assign incident role: %w", err)
+	}
+	return s.Get(ctx, id)
+}
+
+func equalIDs(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// alertmanagerPayload is the Prometheus Alertmanager webhook body (version 4).
+type alertmanagerPayload struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []alertmanagerAlert `json:"alerts"`
+}
+
+type alertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// AlertmanagerParser reads Alertmanager webhook notifications. Every alert in
+// the group becomes its own Alert; group-level labels and annotations are
+// used as defaults.
+type AlertmanagerParser struct{}
+
+// Parse implements Parser.
+func (AlertmanagerParser) Parse(body []byte) ([]Alert, error) {
+	var p alertmanagerPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, invalid("body is not a valid Alertmanager payload: %v", err)
+	}
+	if p.Version != "" && p.Version != "4" {
+		return nil, invalid("unsupported Alertmanager payload version %q", p.Version)
+	}
+	if len(p.Alerts) == 0 {
+		return nil, invalid("payload contains no alerts")
+	}
+
+	raws, err := rawAlerts(body)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(p.Alerts))
+	for i := range p.Alerts {
+		alerts = append(alerts, p.normalise(&p.Alerts[i], raws[i]))
+	}
+	return alerts, nil
+}
+
+func (p *alertmanagerPayload) normalise(am *alertmanagerAlert, raw json.RawMessage) Alert {
+	labels := mergeMaps(p.CommonLabels, am.Labels)
+	annotations := mergeMaps(p.CommonAnnotations, am.Annotations)
+
+	status := am.Status
+	if status == "" {
+		status = p.Status
+	}
+	if status != model.AlertStatusResolved {
+		status = model.AlertStatusFiring
+	}
+
+	a := Alert{
+		Fingerprint:  am.Fingerprint,
+		Title:        firstNonEmpty(annotations["summary"], annotations["title"], labels["alertname"], "Alertmanager alert"),
+		Description:  firstNonEmpty(annotations["description"], annotations["message"]),
+		SeverityHint: normaliseSeverity(labels["severity"]),
+		Status:       status,
+		Labels:       labels,
+		Annotations:  annotations,
+		GeneratorURL: am.GeneratorURL,
+		OccurredAt:   am.StartsAt,
+		Raw:          raw,
+	}
+	if status == model.AlertStatusResolved {
+		a.EndedAt = am.EndsAt
+	}
+	if a.Fingerprint == "" {
+		a.Fingerprint = defaultFingerprint(a.Title, labels)
+	}
+	return a
+}
+
+// rawAlerts returns each element of the payload's "alerts" array verbatim so
+// it can be stored alongside the normalised alert.
+func rawAlerts(body []byte) ([]json.RawMessage, error) {
+	var envelope struct {
+		Alerts []json.RawMessage `json:"alerts"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, invalid("body is not valid JSON: %v", err)
+	}
+	return envelope.Alerts, nil
+}
+
+// mergeMaps returns a new map holding base overlaid with override.
+func mergeMaps(base, override map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(override))
+	maps.Copy(out, base)
+	maps.Copy(out, override)
+	return out
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
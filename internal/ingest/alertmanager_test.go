@@ -0,0 +1,90 @@
+package ingest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighLatency\"}",
+  "truncatedAlerts": 0,
+  "status": "firing",
+  "receiver": "autopsy",
+  "groupLabels": {"alertname": "HighLatency"},
+  "commonLabels": {"alertname": "HighLatency", "severity": "Critical", "team": "payments"},
+  "commonAnnotations": {"runbook_url": "https://runbooks.example.com/latency"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "severity": "Critical", "instance": "api-1"},
+      "annotations": {"summary": "p99 latency above 2s on api-1", "description": "Latency has been high for 10m"},
+      "startsAt": "2026-03-01T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "http://prometheus:9090/graph?g0.expr=latency",
+      "fingerprint": "c0ffee01"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "HighLatency", "severity": "Critical", "instance": "api-2"},
+      "annotations": {},
+      "startsAt": "2026-03-01T09:00:00Z",
+      "endsAt": "2026-03-01T09:45:00Z",
+      "generatorURL": "http://prometheus:9090/graph?g0.expr=latency",
+      "fingerprint": "c0ffee02"
+    }
+  ]
+}`
+
+func TestAlertmanagerParser_GroupedAlerts(t *testing.T) {
+	alerts, err := ingest.AlertmanagerParser{}.Parse([]byte(alertmanagerPayload))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+
+	firing := alerts[0]
+	assert.Equal(t, "c0ffee01", firing.Fingerprint)
+	assert.Equal(t, "p99 latency above 2s on api-1", firing.Title)
+	assert.Equal(t, "Latency has been high for 10m", firing.Description)
+	assert.Equal(t, "critical", firing.SeverityHint)
+	assert.Equal(t, model.AlertStatusFiring, firing.Status)
+	assert.Equal(t, "api-1", firing.Labels["instance"])
+	assert.Equal(t, "payments", firing.Labels["team"], "common labels are merged in")
+	assert.Equal(t, "https://runbooks.example.com/latency", firing.Annotations["runbook_url"])
+	assert.Equal(t, "http://prometheus:9090/graph?g0.expr=latency", firing.GeneratorURL)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), firing.OccurredAt)
+	assert.True(t, firing.EndedAt.IsZero())
+	assert.Contains(t, string(firing.Raw), `"c0ffee01"`)
+
+	resolved := alerts[1]
+	assert.Equal(t, model.AlertStatusResolved, resolved.Status)
+	assert.Equal(t, "HighLatency", resolved.Title, "falls back to alertname without a summary")
+	assert.Equal(t, time.Date(2026, 3, 1, 9, 45, 0, 0, time.UTC), resolved.EndedAt)
+}
+
+func TestAlertmanagerParser_Invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":      `nope`,
+		"no alerts":     `{"version": "4", "alerts": []}`,
+		"wrong version": `{"version": "3", "alerts": [{"status": "firing"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ingest.AlertmanagerParser{}.Parse([]byte(body))
+			assert.ErrorIs(t, err, ingest.ErrInvalidPayload)
+		})
+	}
+}
+
+func TestParserFor_Alertmanager(t *testing.T) {
+	p, err := ingest.ParserFor(&model.WebhookSource{SourceType: model.SourceTypeAlertmanager})
+	require.NoError(t, err)
+	assert.IsType(t, ingest.AlertmanagerParser{}, p)
+
+	_, err = ingest.ParserFor(&model.WebhookSource{SourceType: "pagerduty"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/tidwall/gjson"
+)
+
+// Default gjson paths used by GenericParser when the mapping leaves a field
+// empty.
+const (
+	defaultTitleKey       = "title"
+	defaultDescriptionKey = "description"
+	defaultSeverityKey    = "severity"
+	defaultFingerprintKey = "fingerprint"
+	defaultLabelsKey      = "labels"
+	defaultOccurredAtKey  = "occurred_at"
+)
+
+// GenericParser reads arbitrary JSON objects using a per-source FieldMapping
+// of gjson path expressions (https://github.com/tidwall/gjson/blob/master/SYNTAX.md).
+// The body may be a single object or an array of objects.
+type GenericParser struct {
+	Mapping model.FieldMapping
+}
+
+// Parse implements Parser.
+func (p *GenericParser) Parse(body []byte) ([]Alert, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, invalid("body must be a JSON object or array of objects")
+	}
+	doc := gjson.ParseBytes(body)
+
+	var items []gjson.Result
+	switch {
+	case doc.IsArray():
+		items = doc.Array()
+	case doc.IsObject():
+		items = []gjson.Result{doc}
+	default:
+		return nil, invalid("body must be a JSON object or array of objects")
+	}
+	if len(items) == 0 {
+		return nil, invalid("payload contains no alerts")
+	}
+
+	alerts := make([]Alert, 0, len(items))
+	for i, item := range items {
+		a, err := p.parseOne(item)
+		if err != nil {
+			if len(items) > 1 {
+				return nil, fmt.Errorf("alert %d: %w", i, err)
+			}
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+func (p *GenericParser) parseOne(item gjson.Result) (Alert, error) {
+	if !item.IsObject() {
+		return Alert{}, invalid("body must be a JSON object or array of objects")
+	}
+
+	a := Alert{
+		Title:        stringField(item, keyOr(p.Mapping.Title, defaultTitleKey)),
+		Description:  stringField(item, keyOr(p.Mapping.Description, defaultDescriptionKey)),
+		SeverityHint: normaliseSeverity(stringField(item, keyOr(p.Mapping.SeverityHint, defaultSeverityKey))),
+		Fingerprint:  stringField(item, keyOr(p.Mapping.Fingerprint, defaultFingerprintKey)),
+		Status:       model.AlertStatusFiring,
+		Labels:       map[string]string{},
+		Raw:          []byte(item.Raw),
+	}
+	if a.Title == "" {
+		return Alert{}, invalid("title is required (path %q)", keyOr(p.Mapping.Title, defaultTitleKey))
+	}
+
+	labelsPath := keyOr(p.Mapping.Labels, defaultLabelsKey)
+	if labels := item.Get(labelsPath); labels.IsObject() {
+		labels.ForEach(func(k, v gjson.Result) bool {
+			a.Labels[k.String()] = scalarString(v)
+			return true
+		})
+	} else if labels.Exists() && p.Mapping.Labels != "" {
+		return Alert{}, invalid("labels path %q must select a JSON object", labelsPath)
+	}
+
+	occurredPath := keyOr(p.Mapping.OccurredAt, defaultOccurredAtKey)
+	if v := item.Get(occurredPath); v.Exists() && v.Type != gjson.Null {
+		t, err := parseTimestamp(v)
+		if err != nil {
+			return Alert{}, invalid("occurred_at (path %q): %v", occurredPath, err)
+		}
+		a.OccurredAt = t
+	}
+
+	if a.Fingerprint == "" {
+		a.Fingerprint = defaultFingerprint(a.Title, a.Labels)
+	}
+	return a, nil
+}
+
+func keyOr(key, def string) string {
+	if key != "" {
+		return key
+	}
+	return def
+}
+
+func stringField(item gjson.Result, path string) string {
+	return strings.TrimSpace(scalarString(item.Get(path)))
+}
+
+// scalarString renders JSON scalars as strings; objects and arrays are
+// returned as their raw JSON.
+func scalarString(v gjson.Result) string {
+	switch v.Type {
+	case gjson.Null:
+		return ""
+	case gjson.String:
+		return v.Str
+	case gjson.Number, gjson.True, gjson.False:
+		return v.String()
+	default:
+		return v.Raw
+	}
+}
+
+// parseTimestamp accepts RFC 3339 strings and Unix epoch numbers. Epoch values
+// above 1e12 are treated as milliseconds.
+func parseTimestamp(v gjson.Result) (time.Time, error) {
+	switch v.Type {
+	case gjson.String:
+		if t, err := time.Parse(time.RFC3339Nano, v.Str); err == nil {
+			return t.UTC(), nil
+		}
+		if n, err := strconv.ParseFloat(v.Str, 64); err == nil {
+			return epoch(n), nil
+		}
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 timestamp or Unix epoch", v.Str)
+	case gjson.Number:
+		return epoch(v.Num), nil
+	default:
+		return time.Time{}, fmt.Errorf("expected a timestamp, got %s", v.Type)
+	}
+}
+
+func epoch(n float64) time.Time {
+	if n > 1e12 {
+		return time.UnixMilli(int64(n)).UTC()
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*1e9)).UTC()
+}
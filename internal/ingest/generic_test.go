@@ -0,0 +1,142 @@
+package ingest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenericParser_Defaults(t *testing.T) {
+	p := &ingest.GenericParser{}
+	alerts, err := p.Parse([]byte(`{
+		"title": "Disk full",
+		"description": "/var is at 99%",
+		"severity": " CRITICAL ",
+		"labels": {"host": "db-1", "port": 5432}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	a := alerts[0]
+	assert.Equal(t, "Disk full", a.Title)
+	assert.Equal(t, "/var is at 99%", a.Description)
+	assert.Equal(t, "critical", a.SeverityHint)
+	assert.Equal(t, model.AlertStatusFiring, a.Status)
+	assert.Equal(t, map[string]string{"host": "db-1", "port": "5432"}, a.Labels)
+	assert.NotEmpty(t, a.Fingerprint)
+}
+
+func TestGenericParser_Mapping(t *testing.T) {
+	p := &ingest.GenericParser{Mapping: model.FieldMapping{
+		Title:        "name",
+		SeverityHint: "level",
+		Fingerprint:  "id",
+	}}
+	alerts, err := p.Parse([]byte(`{"name": "Queue backlog", "level": "warning", "id": "q-42"}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Queue backlog", alerts[0].Title)
+	assert.Equal(t, "warning", alerts[0].SeverityHint)
+	assert.Equal(t, "q-42", alerts[0].Fingerprint)
+}
+
+func TestGenericParser_Array(t *testing.T) {
+	p := &ingest.GenericParser{}
+	alerts, err := p.Parse([]byte(`[{"title": "a"}, {"title": "b"}]`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "a", alerts[0].Title)
+	assert.Equal(t, "b", alerts[1].Title)
+}
+
+func TestGenericParser_FingerprintIsStable(t *testing.T) {
+	p := &ingest.GenericParser{}
+	first, err := p.Parse([]byte(`{"title": "x", "labels": {"a": "1", "b": "2"}}`))
+	require.NoError(t, err)
+	second, err := p.Parse([]byte(`{"labels": {"b": "2", "a": "1"}, "title": "x"}`))
+	require.NoError(t, err)
+	assert.Equal(t, first[0].Fingerprint, second[0].Fingerprint)
+}
+
+func TestGenericParser_Invalid(t *testing.T) {
+	p := &ingest.GenericParser{}
+	for name, body := range map[string]string{
+		"not json":      `nope`,
+		"missing title": `{"description": "no title"}`,
+		"empty array":   `[]`,
+		"scalar":        `42`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := p.Parse([]byte(body))
+			assert.ErrorIs(t, err, ingest.ErrInvalidPayload)
+		})
+	}
+}
+
+func TestGenericParser_GJSONPaths(t *testing.T) {
+	p := &ingest.GenericParser{Mapping: model.FieldMapping{
+		Title:        "event.summary",
+		Description:  "event.details.text",
+		SeverityHint: "event.priority",
+		Fingerprint:  "event.dedup_key",
+		Labels:       "{service:event.service,region:meta.region}",
+		OccurredAt:   "event.timestamp",
+	}}
+	alerts, err := p.Parse([]byte(`{
+		"event": {
+			"summary": "Checkout errors",
+			"details": {"text": "5xx rate above 5%"},
+			"priority": "High",
+			"dedup_key": "checkout-5xx",
+			"service": "checkout",
+			"timestamp": "2026-03-03T12:30:00+01:00"
+		},
+		"meta": {"region": "eu-west-1"}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	a := alerts[0]
+	assert.Equal(t, "Checkout errors", a.Title)
+	assert.Equal(t, "5xx rate above 5%", a.Description)
+	assert.Equal(t, "high", a.SeverityHint)
+	assert.Equal(t, "checkout-5xx", a.Fingerprint)
+	assert.Equal(t, map[string]string{"service": "checkout", "region": "eu-west-1"}, a.Labels)
+	assert.Equal(t, time.Date(2026, 3, 3, 11, 30, 0, 0, time.UTC), a.OccurredAt)
+}
+
+func TestGenericParser_OccurredAtEpoch(t *testing.T) {
+	p := &ingest.GenericParser{Mapping: model.FieldMapping{OccurredAt: "ts"}}
+	for name, body := range map[string]string{
+		"seconds":      `{"title": "x", "ts": 1772535600}`,
+		"milliseconds": `{"title": "x", "ts": 1772535600000}`,
+		"string":       `{"title": "x", "ts": "1772535600"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			alerts, err := p.Parse([]byte(body))
+			require.NoError(t, err)
+			assert.Equal(t, time.Unix(1772535600, 0).UTC(), alerts[0].OccurredAt)
+		})
+	}
+}
+
+func TestGenericParser_MappingErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		mapping model.FieldMapping
+		body    string
+	}{
+		"bad timestamp":      {model.FieldMapping{OccurredAt: "ts"}, `{"title": "x", "ts": "yesterday"}`},
+		"labels not object":  {model.FieldMapping{Labels: "tags"}, `{"title": "x", "tags": ["a", "b"]}`},
+		"title path missing": {model.FieldMapping{Title: "a.b.c"}, `{"title": "x"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := &ingest.GenericParser{Mapping: tc.mapping}
+			_, err := p.Parse([]byte(tc.body))
+			assert.ErrorIs(t, err, ingest.ErrInvalidPayload)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package ingest
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// grafanaRuleUIDLabel is the reserved label Grafana attaches to alerts from
+// Grafana-managed rules.
+const grafanaRuleUIDLabel = "__alert_rule_uid__"
+
+// grafanaRuleURLPattern extracts the rule UID from a Grafana generatorURL such
+// as https://grafana.example.com/alerting/grafana/<uid>/view.
+var grafanaRuleURLPattern = regexp.MustCompile(`/alerting/(?:grafana/)?([A-Za-z0-9_-]+)/(?:view|edit)`)
+
+// grafanaPayload is the Grafana Unified Alerting webhook contact-point body.
+type grafanaPayload struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int64             `json:"orgId"`
+	Alerts            []grafanaAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+type grafanaAlert struct {
+	Status       string             `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	Values       map[string]float64 `json:"values"`
+	RuleUID      string             `json:"ruleUID"`
+}
+
+// GrafanaParser reads Grafana Unified Alerting webhook notifications. It
+// keeps the rule UID, evaluated values and the dashboard, panel and silence
+// deep links on each alert.
+type GrafanaParser struct{}
+
+// Parse implements Parser.
+func (GrafanaParser) Parse(body []byte) ([]Alert, error) {
+	var p grafanaPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, invalid("body is not a valid Grafana payload: %v", err)
+	}
+	if len(p.Alerts) == 0 {
+		return nil, invalid("payload contains no alerts")
+	}
+
+	raws, err := rawAlerts(body)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(p.Alerts))
+	for i := range p.Alerts {
+		alerts = append(alerts, p.normalise(&p.Alerts[i], raws[i]))
+	}
+	return alerts, nil
+}
+
+func (p *grafanaPayload) normalise(ga *grafanaAlert, raw json.RawMessage) Alert {
+	labels := mergeMaps(p.CommonLabels, ga.Labels)
+	annotations := mergeMaps(p.CommonAnnotations, ga.Annotations)
+
+	status := ga.Status
+	if status == "" {
+		status = p.Status
+	}
+	if status != model.AlertStatusResolved {
+		status = model.AlertStatusFiring
+	}
+
+	a := Alert{
+		Fingerprint:  ga.Fingerprint,
+		Title:        firstNonEmpty(annotations["summary"], labels["alertname"], p.Title, "Grafana alert"),
+		Description:  firstNonEmpty(annotations["description"], annotations["message"]),
+		SeverityHint: normaliseSeverity(labels["severity"]),
+		Status:       status,
+		Labels:       labels,
+		Annotations:  annotations,
+		GeneratorURL: ga.GeneratorURL,
+		RuleUID:      grafanaRuleUID(ga, labels),
+		Values:       ga.Values,
+		DashboardURL: ga.DashboardURL,
+		PanelURL:     ga.PanelURL,
+		SilenceURL:   ga.SilenceURL,
+		OccurredAt:   ga.StartsAt,
+		Raw:          raw,
+	}
+	if status == model.AlertStatusResolved {
+		a.EndedAt = ga.EndsAt
+	}
+	if a.Fingerprint == "" {
+		a.Fingerprint = defaultFingerprint(a.Title, labels)
+	}
+	return a
+}
+
+// grafanaRuleUID prefers the explicit ruleUID field, then the reserved label,
+// then the UID embedded in the generator URL.
+func grafanaRuleUID(ga *grafanaAlert, labels map[string]string) string {
+	if ga.RuleUID != "" {
+		return ga.RuleUID
+	}
+	if uid := labels[grafanaRuleUIDLabel]; uid != "" {
+		return uid
+	}
+	if m := grafanaRuleURLPattern.FindStringSubmatch(ga.GeneratorURL); m != nil {
+		return m[1]
+	}
+	return ""
+}
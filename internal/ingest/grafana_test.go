@@ -0,0 +1,96 @@
+package ingest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const grafanaPayload = `{
+  "receiver": "autopsy",
+  "status": "firing",
+  "orgId": 1,
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "CPUHigh", "grafana_folder": "infra", "severity": "warning", "__alert_rule_uid__": "cpu-rule"},
+      "annotations": {"summary": "CPU above 90% on web-1"},
+      "startsAt": "2026-03-02T08:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "https://grafana.example.com/alerting/grafana/cpu-rule/view",
+      "fingerprint": "57c6d9296de2ad39",
+      "silenceURL": "https://grafana.example.com/alerting/silence/new?matcher=alertname%3DCPUHigh",
+      "dashboardURL": "https://grafana.example.com/d/abc123",
+      "panelURL": "https://grafana.example.com/d/abc123?viewPanel=4",
+      "values": {"A": 93.5, "B": 1},
+      "valueString": "[ var='A' value=93.5 ]"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "DiskLow"},
+      "annotations": {},
+      "startsAt": "2026-03-02T07:00:00Z",
+      "endsAt": "2026-03-02T07:30:00Z",
+      "generatorURL": "https://grafana.example.com/alerting/disk-rule/edit",
+      "fingerprint": "",
+      "values": null
+    }
+  ],
+  "groupLabels": {"alertname": "CPUHigh"},
+  "commonLabels": {"team": "platform"},
+  "commonAnnotations": {},
+  "externalURL": "https://grafana.example.com/",
+  "version": "1",
+  "title": "[FIRING:1] CPUHigh",
+  "message": "**Firing**"
+}`
+
+func TestGrafanaParser_DeepLinks(t *testing.T) {
+	alerts, err := ingest.GrafanaParser{}.Parse([]byte(grafanaPayload))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+
+	firing := alerts[0]
+	assert.Equal(t, "57c6d9296de2ad39", firing.Fingerprint)
+	assert.Equal(t, "CPU above 90% on web-1", firing.Title)
+	assert.Equal(t, "warning", firing.SeverityHint)
+	assert.Equal(t, model.AlertStatusFiring, firing.Status)
+	assert.Equal(t, "cpu-rule", firing.RuleUID)
+	assert.Equal(t, map[string]float64{"A": 93.5, "B": 1}, firing.Values)
+	assert.Equal(t, "https://grafana.example.com/d/abc123", firing.DashboardURL)
+	assert.Equal(t, "https://grafana.example.com/d/abc123?viewPanel=4", firing.PanelURL)
+	assert.Contains(t, firing.SilenceURL, "/alerting/silence/new")
+	assert.Equal(t, "platform", firing.Labels["team"], "common labels are merged in")
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), firing.OccurredAt)
+	assert.True(t, firing.EndedAt.IsZero())
+
+	resolved := alerts[1]
+	assert.Equal(t, model.AlertStatusResolved, resolved.Status)
+	assert.Equal(t, "DiskLow", resolved.Title)
+	assert.Equal(t, "disk-rule", resolved.RuleUID, "rule UID is taken from the generator URL")
+	assert.NotEmpty(t, resolved.Fingerprint)
+	assert.Equal(t, time.Date(2026, 3, 2, 7, 30, 0, 0, time.UTC), resolved.EndedAt)
+}
+
+func TestGrafanaParser_Invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"not json":  `nope`,
+		"no alerts": `{"version": "1", "alerts": []}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ingest.GrafanaParser{}.Parse([]byte(body))
+			assert.ErrorIs(t, err, ingest.ErrInvalidPayload)
+		})
+	}
+}
+
+func TestParserFor_Grafana(t *testing.T) {
+	p, err := ingest.ParserFor(&model.WebhookSource{SourceType: model.SourceTypeGrafana})
+	require.NoError(t, err)
+	assert.IsType(t, ingest.GrafanaParser{}, p)
+	assert.True(t, ingest.SupportedSourceType(model.SourceTypeGrafana))
+}
@@ -0,0 +1,99 @@
+// Package ingest turns inbound webhook payloads into normalised alerts.
+// Each WebhookSource type has a Parser; the Store persists the result.
+package ingest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// ErrInvalidPayload wraps every parse failure so handlers can map it to 422.
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// Alert is the source-independent form of a single inbound alert.
+type Alert struct {
+	Fingerprint  string
+	Title        string
+	Description  string
+	SeverityHint string
+	Status       string // model.AlertStatusFiring or model.AlertStatusResolved
+	Labels       map[string]string
+	Annotations  map[string]string
+	GeneratorURL string
+	RuleUID      string
+	Values       map[string]float64
+	DashboardURL string
+	PanelURL     string
+	SilenceURL   string
+	OccurredAt   time.Time
+	EndedAt      time.Time // set on resolved alerts when the source reports it
+	Raw          json.RawMessage
+}
+
+// Parser converts a raw request body into one or more alerts.
+type Parser interface {
+	Parse(body []byte) ([]Alert, error)
+}
+
+// ParserFor returns the Parser for the source's type.
+func ParserFor(src *model.WebhookSource) (Parser, error) {
+	switch src.SourceType {
+	case model.SourceTypeGeneric, "":
+		return &GenericParser{Mapping: src.FieldMapping}, nil
+	case model.SourceTypeAlertmanager:
+		return AlertmanagerParser{}, nil
+	case model.SourceTypeGrafana:
+		return GrafanaParser{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source type %q", src.SourceType)
+	}
+}
+
+// SupportedSourceType reports whether ParserFor understands sourceType.
+func SupportedSourceType(sourceType string) bool {
+	switch sourceType {
+	case model.SourceTypeGeneric, model.SourceTypeAlertmanager, model.SourceTypeGrafana:
+		return true
+	default:
+		return false
+	}
+}
+
+// invalid wraps a parse failure in ErrInvalidPayload.
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPayload, fmt.Sprintf(format, args...))
+}
+
+// defaultFingerprint derives a stable fingerprint from the title and labels
+// for payloads that do not carry one.
+func defaultFingerprint(title string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(title)
+	for _, k := range keys {
+		b.WriteString("\x00")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(labels[k])
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// normaliseSeverity lower-cases and trims a raw severity hint.
+func normaliseSeverity(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
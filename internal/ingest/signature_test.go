@@ -0,0 +1,86 @@
+package ingest_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hmacSecret = "webhook-secret"
+
+func signedHeader(t *testing.T, secret string, ts time.Time, body []byte) http.Header {
+	t.Helper()
+	h := http.Header{}
+	h.Set(ingest.SignatureHeader, ingest.Sign(secret, ts, body))
+	h.Set(ingest.TimestampHeader, strconv.FormatInt(ts.Unix(), 10))
+	return h
+}
+
+func TestVerifySignature_Valid(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"title":"disk full"}`)
+	h := signedHeader(t, hmacSecret, now, body)
+
+	require.NoError(t, ingest.VerifySignature(hmacSecret, h, body, now, 5*time.Minute))
+}
+
+func TestVerifySignature_WrongSecret(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"title":"disk full"}`)
+	h := signedHeader(t, "other-secret", now, body)
+
+	err := ingest.VerifySignature(hmacSecret, h, body, now, 5*time.Minute)
+	assert.ErrorIs(t, err, ingest.ErrInvalidSignature)
+}
+
+func TestVerifySignature_TamperedBody(t *testing.T) {
+	now := time.Now()
+	h := signedHeader(t, hmacSecret, now, []byte(`{"title":"disk full"}`))
+
+	err := ingest.VerifySignature(hmacSecret, h, []byte(`{"title":"all good"}`), now, 5*time.Minute)
+	assert.ErrorIs(t, err, ingest.ErrInvalidSignature)
+}
+
+func TestVerifySignature_TamperedTimestamp(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{}`)
+	h := signedHeader(t, hmacSecret, now.Add(-time.Minute), body)
+	// Re-stamp without re-signing: the timestamp is part of the MAC.
+	h.Set(ingest.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+
+	err := ingest.VerifySignature(hmacSecret, h, body, now, 5*time.Minute)
+	assert.ErrorIs(t, err, ingest.ErrInvalidSignature)
+}
+
+func TestVerifySignature_Replay(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{}`)
+
+	old := signedHeader(t, hmacSecret, now.Add(-10*time.Minute), body)
+	assert.ErrorIs(t, ingest.VerifySignature(hmacSecret, old, body, now, 5*time.Minute), ingest.ErrStaleTimestamp)
+
+	future := signedHeader(t, hmacSecret, now.Add(10*time.Minute), body)
+	assert.ErrorIs(t, ingest.VerifySignature(hmacSecret, future, body, now, 5*time.Minute), ingest.ErrStaleTimestamp)
+}
+
+func TestVerifySignature_MissingHeaders(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{}`)
+
+	h := signedHeader(t, hmacSecret, now, body)
+	h.Del(ingest.SignatureHeader)
+	assert.ErrorIs(t, ingest.VerifySignature(hmacSecret, h, body, now, time.Minute), ingest.ErrMissingSignature)
+
+	h = signedHeader(t, hmacSecret, now, body)
+	h.Set(ingest.SignatureHeader, "md5=abc")
+	assert.ErrorIs(t, ingest.VerifySignature(hmacSecret, h, body, now, time.Minute), ingest.ErrMissingSignature)
+
+	h = signedHeader(t, hmacSecret, now, body)
+	h.Del(ingest.TimestampHeader)
+	assert.ErrorIs(t, ingest.VerifySignature(hmacSecret, h, body, now, time.Minute), ingest.ErrMissingTimestamp)
+}
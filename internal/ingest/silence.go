@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// ValidateMatcher reports why m cannot be used in a silence, or nil.
+func ValidateMatcher(m model.LabelMatcher) error {
+	if m.Label == "" {
+		return fmt.Errorf("label is required")
+	}
+	switch m.Op {
+	case model.MatchEqual, model.MatchNotEqual:
+	case model.MatchRegexp, model.MatchNotRegexp:
+		if _, err := compileMatcher(m.Value); err != nil {
+			return fmt.Errorf("value is not a valid regular expression: %w", err)
+		}
+	default:
+		return fmt.Errorf("op must be one of =, !=, =~, !~")
+	}
+	return nil
+}
+
+// SilenceMatches reports whether labels satisfy every matcher of s. A silence
+// without matchers matches nothing.
+func SilenceMatches(s *model.Silence, labels map[string]string) bool {
+	if len(s.Matchers) == 0 {
+		return false
+	}
+	for _, m := range s.Matchers {
+		if !matcherMatches(m, labels[m.Label]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matcherMatches(m model.LabelMatcher, v string) bool {
+	switch m.Op {
+	case model.MatchEqual:
+		return v == m.Value
+	case model.MatchNotEqual:
+		return v != m.Value
+	case model.MatchRegexp, model.MatchNotRegexp:
+		re, err := compileMatcher(m.Value)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(v) == (m.Op == model.MatchRegexp)
+	}
+	return false
+}
+
+func compileMatcher(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// activeSilences returns the silences of organization orgID in effect at t.
+// A nil orgID selects the silences that belong to no organization.
+func activeSilences(tx *gorm.DB, orgID *string, t time.Time) ([]model.Silence, error) {
+	q := tx.Where("starts_at <= ? AND ends_at > ?", t, t)
+	if orgID == nil {
+		q = q.Where("organization_id IS NULL")
+	} else {
+		q = q.Where("organization_id = ?", *orgID)
+	}
+	var silences []model.Silence
+	if err := q.Find(&silences).Error; err != nil {
+		return nil, fmt.Errorf("load active silences: %w", err)
+	}
+	return silences, nil
+}
+
+// matchingSilence returns the first of silences matching labels, or nil.
+func matchingSilence(silences []model.Silence, labels map[string]string) *model.Silence {
+	for i := range silences {
+		if SilenceMatches(&silences[i], labels) {
+			return &silences[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package ingest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceMatches(t *testing.T) {
+	labels := map[string]string{"alertname": "DiskFull", "env": "staging", "host": "db-12"}
+
+	tests := []struct {
+		name     string
+		matchers []model.LabelMatcher
+		want     bool
+	}{
+		{"equal", []model.LabelMatcher{{Label: "alertname", Op: "=", Value: "DiskFull"}}, true},
+		{"equal mismatch", []model.LabelMatcher{{Label: "alertname", Op: "=", Value: "CPU"}}, false},
+		{"not equal", []model.LabelMatcher{{Label: "env", Op: "!=", Value: "production"}}, true},
+		{"regexp is anchored", []model.LabelMatcher{{Label: "host", Op: "=~", Value: "db-1"}}, false},
+		{"regexp", []model.LabelMatcher{{Label: "host", Op: "=~", Value: "db-[0-9]+"}}, true},
+		{"negative regexp", []model.LabelMatcher{{Label: "env", Op: "!~", Value: "prod.*"}}, true},
+		{"missing label equals empty", []model.LabelMatcher{{Label: "team", Op: "=", Value: ""}}, true},
+		{"all matchers must match", []model.LabelMatcher{
+			{Label: "alertname", Op: "=", Value: "DiskFull"},
+			{Label: "env", Op: "=", Value: "production"},
+		}, false},
+		{"no matchers", nil, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &model.Silence{Matchers: tc.matchers}
+			assert.Equal(t, tc.want, ingest.SilenceMatches(s, labels))
+		})
+	}
+}
+
+func TestValidateMatcher(t *testing.T) {
+	assert.NoError(t, ingest.ValidateMatcher(model.LabelMatcher{Label: "env", Op: "=~", Value: "prod|staging"}))
+	assert.Error(t, ingest.ValidateMatcher(model.LabelMatcher{Label: "", Op: "=", Value: "x"}))
+	assert.Error(t, ingest.ValidateMatcher(model.LabelMatcher{Label: "env", Op: "~", Value: "x"}))
+	assert.Error(t, ingest.ValidateMatcher(model.LabelMatcher{Label: "env", Op: "=~", Value: "("}))
+}
+
+func TestStore_SilencedAlertIsSuppressed(t *testing.T) {
+	store, gormDB, src := newTestStore(t)
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	silence := model.Silence{
+		Matchers: []model.LabelMatcher{{Label: "env", Op: "=", Value: "staging"}},
+		StartsAt: t0.Add(-time.Hour),
+		EndsAt:   t0.Add(time.Hour),
+	}
+	require.NoError(t, gormDB.Create(&silence).Error)
+
+	saved, err := store.Save(t.Context(), src, []ingest.Alert{
+		{Fingerprint: "a", Title: "staging", Status: model.AlertStatusFiring, Labels: map[string]string{"env": "staging"}},
+		{Fingerprint: "b", Title: "prod", Status: model.AlertStatusFiring, Labels: map[string]string{"env": "production"}},
+	}, t0)
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	assert.True(t, saved[0].Suppressed)
+	require.NotNil(t, saved[0].SilenceID)
+	assert.Equal(t, silence.ID, *saved[0].SilenceID)
+	assert.False(t, saved[1].Suppressed)
+
+	later, err := store.Save(t.Context(), src, []ingest.Alert{
+		{Fingerprint: "c", Title: "staging", Status: model.AlertStatusFiring, Labels: map[string]string{"env": "staging"}},
+	}, t0.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, later[0].Suppressed, "expired silences no longer apply")
+}
+
+func TestStore_SilencesApplyWithinOrganization(t *testing.T) {
+	store, gormDB, src := newTestStore(t)
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	org := "org-b"
+	require.NoError(t, gormDB.Create(&model.Silence{
+		OrganizationID: &org,
+		Matchers:       []model.LabelMatcher{{Label: "severity", Op: "=~", Value: ".*"}},
+		StartsAt:       t0.Add(-time.Hour),
+		EndsAt:         t0.Add(time.Hour),
+	}).Error)
+
+	saved, err := store.Save(t.Context(), src, firing("a"), t0)
+	require.NoError(t, err)
+	assert.False(t, saved[0].Suppressed, "other organizations' silences do not apply")
+
+	src.OrganizationID = &org
+	saved, err = store.Save(t.Context(), src, firing("b"), t0)
+	require.NoError(t, err)
+	assert.True(t, saved[0].Suppressed)
+}
+
+func TestStore_RepeatAfterSilenceEndsIsNotFolded(t *testing.T) {
+	store, gormDB, src := newTestStore(t)
+	ctx := t.Context()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	silence := model.Silence{
+		Matchers: []model.LabelMatcher{{Label: "alertname", Op: "=", Value: ""}},
+		StartsAt: t0.Add(-time.Hour),
+		EndsAt:   t0.Add(time.Hour),
+	}
+	require.NoError(t, gormDB.Create(&silence).Error)
+
+	first, err := store.Save(ctx, src, firing("fp"), t0)
+	require.NoError(t, err)
+	require.True(t, first[0].Suppressed)
+	repeat, err := store.Save(ctx, src, firing("fp"), t0.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, first[0].ID, repeat[0].ID, "repeats fold while the silence is active")
+
+	require.NoError(t, gormDB.Model(&silence).Update("ends_at", t0.Add(2*time.Minute)).Error)
+	after, err := store.Save(ctx, src, firing("fp"), t0.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.NotEqual(t, first[0].ID, after[0].ID, "the repeat is a new alert")
+	assert.False(t, after[0].Suppressed)
+	require.NotNil(t, after[0].TriageStatus)
+	assert.Equal(t, model.TriageStatusPending, *after[0].TriageStatus)
+
+	again, err := store.Save(ctx, src, firing("fp"), t0.Add(4*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, after[0].ID, again[0].ID, "later repeats fold into the new alert")
+}
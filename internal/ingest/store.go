@@ -0,0 +1,256 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/events"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// TriageQueuer queues AI triage of alert alertID. Save calls it with the
+// transaction that creates the alert, so the job should be written in tx and
+// run only once it commits; if it fails, the delivery is not stored.
+type TriageQueuer func(tx *gorm.DB, alertID string) error
+
+// Store persists normalised alerts via GORM.
+type Store struct {
+	db     *gorm.DB
+	triage TriageQueuer
+}
+
+// NewStore creates a Store backed by the given GORM DB that has triage queue
+// the triage of new alerts. A nil triage queues nothing.
+func NewStore(db *gorm.DB, triage TriageQueuer) *Store {
+	return &Store{db: db, triage: triage}
+}
+
+// Save records alerts received from src in a single transaction and returns
+// the affected rows in input order. Firing alerts are inserted unless an
+// alert with the same fingerprint was seen within the source's dedup window,
+// in which case that alert's occurrence count is bumped instead. New alerts
+// are stored with a pending triage status and queued for triage, or as
+// suppressed (and never triaged) if they match an active silence of src's
+// organization. Resolved alerts close
+// the matching open alerts instead of creating new rows.
+func (s *Store) Save(ctx context.Context, src *model.WebhookSource, alerts []Alert, receivedAt time.Time) ([]model.Alert, error) {
+	out := make([]model.Alert, 0, len(alerts))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		silences, err := activeSilences(tx, src.OrganizationID, receivedAt)
+		if err != nil {
+			return err
+		}
+		for i := range alerts {
+			if alerts[i].Status == model.AlertStatusResolved {
+				resolved, err := resolveOpen(tx, src, &alerts[i], receivedAt)
+				if err != nil {
+					return err
+				}
+				out = append(out, resolved...)
+				continue
+			}
+			row, err := s.insertOrFold(tx, src, &alerts[i], receivedAt, silences)
+			if err != nil {
+				return err
+			}
+			out = append(out, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// maxDedupAttempts bounds the fold/insert loop in insertOrFold. Each retry
+// follows a lost insert race, after which the fold should succeed.
+const maxDedupAttempts = 3
+
+// insertOrFold folds a into the open alert sharing its dedup key or, failing
+// that, inserts it as a new row owning the key.
+//
+// Concurrent deliveries are serialised by the unique index on dedup_key: if
+// two requests both miss the fold and race to insert, the loser gets
+// gorm.ErrDuplicatedKey, rolls back to its savepoint and retries the fold
+// against the winner's row.
+func (s *Store) insertOrFold(tx *gorm.DB, src *model.WebhookSource, a *Alert, receivedAt time.Time, silences []model.Silence) (model.Alert, error) {
+	key := model.DedupKey(src.ID, a.Fingerprint)
+	for range maxDedupAttempts {
+		row, folded, err := foldRepeat(tx, key, receivedAt.Add(-src.DedupWindow()), receivedAt, silences)
+		if err != nil || folded {
+			return row, err
+		}
+
+		row = newAlertRow(src, a, receivedAt)
+		row.DedupKey = &key
+		triage := model.TriageStatusPending
+		if sil := matchingSilence(silences, row.Labels); sil != nil {
+			row.Suppressed = true
+			row.SilenceID = &sil.ID
+			triage = model.TriageStatusSkipped
+		}
+		row.TriageStatus = &triage
+		err = tx.Transaction(func(sp *gorm.DB) error {
+			// The previous owner of the key fell outside the window.
+			if err := sp.Model(&model.Alert{}).
+				Where("dedup_key = ?", key).
+				Update("dedup_key", nil).Error; err != nil {
+				return fmt.Errorf("release dedup key: %w", err)
+			}
+			if err := sp.Create(&row).Error; err != nil {
+				return err
+			}
+			if triage == model.TriageStatusPending && s.triage != nil {
+				if err := s.triage(sp, row.ID); err != nil {
+					return err
+				}
+			}
+			return events.Record(sp, row.OrganizationID, model.EventAlertCreated, "alert", row.ID)
+		})
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			continue
+		case err != nil:
+			return model.Alert{}, fmt.Errorf("insert alert: %w", err)
+		}
+		return row, nil
+	}
+	return model.Alert{}, fmt.Errorf("insert alert: dedup key %s still contended after %d attempts", key, maxDedupAttempts)
+}
+
+// foldRepeat bumps the occurrence count of the alert holding key if it was
+// last seen at or after cutoff. An alert suppressed by a silence that is no
+// longer among the active silences is left alone, so the repeat is stored
+// as a new alert and triaged.
+func foldRepeat(tx *gorm.DB, key string, cutoff, receivedAt time.Time, silences []model.Silence) (model.Alert, bool, error) {
+	active := make([]string, len(silences))
+	for i := range silences {
+		active[i] = silences[i].ID
+	}
+	res := tx.Model(&model.Alert{}).
+		Where("dedup_key = ? AND last_seen_at >= ?", key, cutoff).
+		Where("silence_id IS NULL OR silence_id IN ?", active).
+		Updates(map[string]any{
+			"occurrence_count": gorm.Expr("occurrence_count + 1"),
+			"last_seen_at":     receivedAt,
+			"updated_at":       receivedAt,
+		})
+	if res.Error != nil {
+		return model.Alert{}, false, fmt.Errorf("fold repeat alert: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return model.Alert{}, false, nil
+	}
+	var row model.Alert
+	if err := tx.Where("dedup_key = ?", key).First(&row).Error; err != nil {
+		return model.Alert{}, false, fmt.Errorf("load folded alert: %w", err)
+	}
+	return row, true, nil
+}
+
+// resolveOpen marks every open alert from src with a's fingerprint as
+// resolved. A resolution for an alert we never saw firing is ignored.
+func resolveOpen(tx *gorm.DB, src *model.WebhookSource, a *Alert, receivedAt time.Time) ([]model.Alert, error) {
+	var open []model.Alert
+	if err := tx.Where("source_id = ? AND fingerprint = ? AND status IN ?",
+		src.ID, a.Fingerprint, model.OpenAlertStatuses).
+		Find(&open).Error; err != nil {
+		return nil, fmt.Errorf("find open alerts: %w", err)
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	resolvedAt := a.EndedAt
+	if resolvedAt.IsZero() {
+		resolvedAt = receivedAt
+	}
+	ids := make([]string, 0, len(open))
+	for i := range open {
+		ids = append(ids, open[i].ID)
+		open[i].Status = model.AlertStatusResolved
+		open[i].ResolvedAt = &resolvedAt
+		open[i].DedupKey = nil
+	}
+	if err := tx.Model(&model.Alert{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":      model.AlertStatusResolved,
+			"resolved_at": resolvedAt,
+			"dedup_key":   nil,
+			"updated_at":  receivedAt,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("resolve alerts: %w", err)
+	}
+	return open, nil
+}
+
+// Preview returns the rows Save would produce for alerts without touching the
+// database. Resolved alerts are shown as resolved rather than matched against
+// open alerts.
+func Preview(src *model.WebhookSource, alerts []Alert, receivedAt time.Time) []model.Alert {
+	out := make([]model.Alert, 0, len(alerts))
+	for i := range alerts {
+		row := newAlertRow(src, &alerts[i], receivedAt)
+		if alerts[i].Status == model.AlertStatusResolved {
+			resolvedAt := alerts[i].EndedAt
+			if resolvedAt.IsZero() {
+				resolvedAt = receivedAt
+			}
+			row.Status = model.AlertStatusResolved
+			row.ResolvedAt = &resolvedAt
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func newAlertRow(src *model.WebhookSource, a *Alert, receivedAt time.Time) model.Alert {
+	occurredAt := a.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = receivedAt
+	}
+	labels := model.Labels(a.Labels)
+	if labels == nil {
+		labels = model.Labels{}
+	}
+	annotations := model.Labels(a.Annotations)
+	if annotations == nil {
+		annotations = model.Labels{}
+	}
+	values := model.AlertValues(a.Values)
+	if values == nil {
+		values = model.AlertValues{}
+	}
+	payload := string(a.Raw)
+	if payload == "" {
+		payload = "{}"
+	}
+	return model.Alert{
+		OrganizationID:  src.OrganizationID,
+		SourceID:        src.ID,
+		Source:          src.Name,
+		Fingerprint:     a.Fingerprint,
+		Title:           a.Title,
+		Description:     a.Description,
+		SeverityHint:    a.SeverityHint,
+		Status:          model.AlertStatusFiring,
+		Labels:          labels,
+		Annotations:     annotations,
+		GeneratorURL:    a.GeneratorURL,
+		RuleUID:         a.RuleUID,
+		Values:          values,
+		DashboardURL:    a.DashboardURL,
+		PanelURL:        a.PanelURL,
+		SilenceURL:      a.SilenceURL,
+		Payload:         payload,
+		OccurrenceCount: 1,
+		OccurredAt:      occurredAt,
+		ReceivedAt:      receivedAt,
+		LastSeenAt:      receivedAt,
+	}
+}
@@ -0,0 +1,153 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/ingest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) (*ingest.Store, *gorm.DB, *model.WebhookSource) {
+	t.Helper()
+	gormDB := dbtest.New(t)
+
+	src := &model.WebhookSource{
+		Name:               "noisy",
+		SourceType:         model.SourceTypeGeneric,
+		HMACSecret:         "secret",
+		Enabled:            true,
+		DedupWindowSeconds: 300,
+	}
+	require.NoError(t, gormDB.Create(src).Error)
+	return ingest.NewStore(gormDB, nil), gormDB, src
+}
+
+func firing(fingerprint string) []ingest.Alert {
+	return []ingest.Alert{{Fingerprint: fingerprint, Title: "CPU high", Status: model.AlertStatusFiring}}
+}
+
+func TestStore_DedupWithinWindow(t *testing.T) {
+	store, gormDB, src := newTestStore(t)
+	ctx := context.Background()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	first, err := store.Save(ctx, src, firing("fp"), t0)
+	require.NoError(t, err)
+	second, err := store.Save(ctx, src, firing("fp"), t0.Add(4*time.Minute))
+	require.NoError(t, err)
+	// The window slides: 8 minutes after the first delivery is still within
+	// 5 minutes of the last one.
+	third, err := store.Save(ctx, src, firing("fp"), t0.Add(8*time.Minute))
+	require.NoError(t, err)
+
+	assert.Equal(t, first[0].ID, second[0].ID)
+	assert.Equal(t, first[0].ID, third[0].ID)
+	assert.Equal(t, 3, third[0].OccurrenceCount)
+	assert.True(t, third[0].LastSeenAt.Equal(t0.Add(8*time.Minute)))
+
+	var count int64
+	require.NoError(t, gormDB.Model(&model.Alert{}).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestStore_DedupWindowExpires(t *testing.T) {
+	store, _, src := newTestStore(t)
+	ctx := context.Background()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	first, err := store.Save(ctx, src, firing("fp"), t0)
+	require.NoError(t, err)
+	later, err := store.Save(ctx, src, firing("fp"), t0.Add(6*time.Minute))
+	require.NoError(t, err)
+	assert.NotEqual(t, first[0].ID, later[0].ID)
+	assert.Equal(t, 1, later[0].OccurrenceCount)
+
+	again, err := store.Save(ctx, src, firing("fp"), t0.Add(7*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, later[0].ID, again[0].ID, "repeats fold into the newest alert")
+}
+
+func TestStore_ResolvedAlertIsNotReused(t *testing.T) {
+	store, _, src := newTestStore(t)
+	ctx := context.Background()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	first, err := store.Save(ctx, src, firing("fp"), t0)
+	require.NoError(t, err)
+	_, err = store.Save(ctx, src, []ingest.Alert{{Fingerprint: "fp", Status: model.AlertStatusResolved}}, t0.Add(time.Minute))
+	require.NoError(t, err)
+	refired, err := store.Save(ctx, src, firing("fp"), t0.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.NotEqual(t, first[0].ID, refired[0].ID)
+}
+
+func TestStore_QueuesTriageForNewAlerts(t *testing.T) {
+	_, gormDB, src := newTestStore(t)
+	ctx := context.Background()
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, gormDB.Create(&model.Silence{
+		Matchers: []model.LabelMatcher{{Label: "env", Op: "=", Value: "staging"}},
+		StartsAt: t0.Add(-time.Hour),
+		EndsAt:   t0.Add(time.Hour),
+	}).Error)
+
+	var queued []string
+	store := ingest.NewStore(gormDB, func(tx *gorm.DB, alertID string) error {
+		var n int64
+		require.NoError(t, tx.Model(&model.Alert{}).Where("id = ?", alertID).Count(&n).Error)
+		assert.EqualValues(t, 1, n, "triage is queued in the transaction creating the alert")
+		queued = append(queued, alertID)
+		return nil
+	})
+	saved, err := store.Save(ctx, src, []ingest.Alert{
+		{Fingerprint: "a", Title: "prod", Status: model.AlertStatusFiring},
+		{Fingerprint: "a", Title: "prod", Status: model.AlertStatusFiring},
+		{Fingerprint: "b", Title: "staging", Status: model.AlertStatusFiring, Labels: map[string]string{"env": "staging"}},
+	}, t0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{saved[0].ID}, queued, "repeats and suppressed alerts are not triaged")
+
+	errQueue := errors.New("queue unavailable")
+	store = ingest.NewStore(gormDB, func(*gorm.DB, string) error { return errQueue })
+	_, err = store.Save(ctx, src, firing("c"), t0)
+	require.ErrorIs(t, err, errQueue)
+	var n int64
+	require.NoError(t, gormDB.Model(&model.Alert{}).Where("fingerprint = ?", "c").Count(&n).Error)
+	assert.Zero(t, n, "the alert is not stored without its triage job")
+}
+
+func TestStore_ConcurrentDeliveries(t *testing.T) {
+	store, gormDB, src := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	const deliveries = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, deliveries)
+	for range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Save(ctx, src, firing("fp"), now)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var alerts []model.Alert
+	require.NoError(t, gormDB.Find(&alerts).Error)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, deliveries, alerts[0].OccurrenceCount)
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook source types understood by the ingest package.
+const (
+	SourceTypeGeneric      = "generic"
+	SourceTypeAlertmanager = "alertmanager"
+	SourceTypeGrafana      = "grafana"
+)
+
+// Alert statuses.
+const (
+	AlertStatusFiring       = "firing"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// AlertStatuses lists every valid Alert.Status value.
+var AlertStatuses = []string{AlertStatusFiring, AlertStatusAcknowledged, AlertStatusResolved}
+
+// OpenAlertStatuses are the statuses of alerts that have not resolved yet.
+var OpenAlertStatuses = []string{AlertStatusFiring, AlertStatusAcknowledged}
+
+// Labels is a string map that GORM serialises as a JSON object in a TEXT
+// column on both drivers.
+type Labels map[string]string
+
+// AlertValues holds the evaluated query values attached to a Grafana alert,
+// keyed by query/expression reference ID.
+type AlertValues map[string]float64
+
+// FieldMapping tells the generic JSON adapter where the normalised alert
+// fields live in the payload. Each entry is a gjson path expression, so plain
+// top-level keys keep working. Empty entries fall back to the adapter
+// defaults.
+type FieldMapping struct {
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	SeverityHint string `json:"severity_hint,omitempty"`
+	Fingerprint  string `json:"fingerprint,omitempty"`
+	Labels       string `json:"labels,omitempty"`
+	OccurredAt   string `json:"occurred_at,omitempty"`
+}
+
+// WebhookSource is a configured inbound webhook, addressed by Name in
+// POST /api/v1/webhooks/{source}.
+type WebhookSource struct {
+	ID             string       `gorm:"type:text;primaryKey"`
+	OrganizationID *string      `gorm:"type:text"`
+	Name           string       `gorm:"type:text;not null;uniqueIndex"`
+	SourceType     string       `gorm:"type:text;not null;default:'generic'"`
+	HMACSecret     string       `gorm:"column:hmac_secret;type:text;not null"`
+	Enabled        bool         `gorm:"not null"`
+	FieldMapping   FieldMapping `gorm:"type:text;not null;default:'{}';serializer:json"`
+	// DedupWindowSeconds is how long after an alert was last seen a repeat
+	// with the same fingerprint is folded into it.
+	DedupWindowSeconds int `gorm:"not null;default:300"`
+	// RateLimitRPS is the sustained number of webhook requests per second
+	// accepted from this source before it is answered with 429.
+	RateLimitRPS int `gorm:"column:rate_limit_rps;not null;default:100"`
+	// PromptTemplate is a text/template for the AI triage prompt of this
+	// source's alerts; empty uses the built-in template. AIModel and
+	// AIMaxTokens override AI_MODEL and AI_MAX_TOKENS when set.
+	PromptTemplate string `gorm:"type:text;not null;default:''"`
+	AIModel        string `gorm:"column:ai_model;type:text;not null;default:''"`
+	AIMaxTokens    int    `gorm:"column:ai_max_tokens;not null;default:0"`
+	// AutoDeclareSeverity is the least urgent AI severity that declares an
+	// incident for this source's alerts, e.g. SEV2 for SEV1 and SEV2 alerts.
+	// Empty disables automatic incidents.
+	AutoDeclareSeverity string    `gorm:"type:text;not null;default:''"`
+	CreatedAt           time.Time `gorm:"not null"`
+	UpdatedAt           time.Time `gorm:"not null"`
+}
+
+// DefaultDedupWindow is the dedup window given to new webhook sources.
+const DefaultDedupWindow = 5 * time.Minute
+
+// DefaultRateLimitRPS is the request rate limit given to new webhook sources.
+const DefaultRateLimitRPS = 100
+
+// DefaultAutoDeclareSeverity is the auto-declare threshold given to new
+// webhook sources.
+const DefaultAutoDeclareSeverity = SeveritySEV2
+
+// DedupWindow returns the source's dedup window as a duration.
+func (s *WebhookSource) DedupWindow() time.Duration {
+	return time.Duration(s.DedupWindowSeconds) * time.Second
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (s *WebhookSource) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Alert is a normalised event received from a WebhookSource.
+type Alert struct {
+	ID             string      `gorm:"type:text;primaryKey"`
+	OrganizationID *string     `gorm:"type:text"`
+	SourceID       string      `gorm:"type:text;not null;index"`
+	Source         string      `gorm:"type:text;not null"`
+	Fingerprint    string      `gorm:"type:text;not null;index"`
+	Title          string      `gorm:"type:text;not null"`
+	Description    string      `gorm:"type:text;not null;default:''"`
+	SeverityHint   string      `gorm:"type:text;not null;default:''"`
+	Status         string      `gorm:"type:text;not null;default:'firing';index"`
+	Labels         Labels      `gorm:"type:text;not null;default:'{}';serializer:json"`
+	Annotations    Labels      `gorm:"type:text;not null;default:'{}';serializer:json"`
+	GeneratorURL   string      `gorm:"type:text;not null;default:''"`
+	RuleUID        string      `gorm:"type:text;not null;default:''"`
+	Values         AlertValues `gorm:"column:eval_values;type:text;not null;default:'{}';serializer:json"`
+	DashboardURL   string      `gorm:"type:text;not null;default:''"`
+	PanelURL       string      `gorm:"type:text;not null;default:''"`
+	SilenceURL     string      `gorm:"type:text;not null;default:''"`
+	Payload        string      `gorm:"type:text;not null;default:'{}'"`
+	// OccurrenceCount counts deliveries folded into this alert by dedup.
+	OccurrenceCount int       `gorm:"not null;default:1"`
+	OccurredAt      time.Time `gorm:"not null"`
+	ReceivedAt      time.Time `gorm:"not null"`
+	LastSeenAt      time.Time
+	AcknowledgedAt  *time.Time
+	AcknowledgedBy  *string `gorm:"type:text"`
+	ResolvedAt      *time.Time
+	ResolvedBy      *string `gorm:"type:text"`
+	// Suppressed alerts matched an active Silence on arrival. They are stored
+	// for the record but must never open incidents or send pages.
+	Suppressed bool    `gorm:"not null;default:false;index"`
+	SilenceID  *string `gorm:"type:text"`
+	// TriageStatus follows the alert's AI triage job; nil for alerts that
+	// were never queued (repeats folded by dedup keep the first value).
+	TriageStatus *string `gorm:"type:text"`
+	// IncidentID is the incident this alert opened or was attached to.
+	IncidentID *string `gorm:"type:text;index"`
+	// DedupKey is set while the alert can absorb repeats and cleared once it
+	// resolves or its window lapses. The unique index makes concurrent
+	// deliveries of the same fingerprint converge on one row.
+	DedupKey  *string   `gorm:"type:text;uniqueIndex"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (a *Alert) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// DedupKey identifies the (source, fingerprint) pair shared by repeats of an
+// alert. It is hashed so arbitrarily long fingerprints stay within index key
+// limits.
+func DedupKey(sourceID, fingerprint string) string {
+	sum := sha256.Sum256([]byte(sourceID + "\x00" + fingerprint))
+	return hex.EncodeToString(sum[:])
+}
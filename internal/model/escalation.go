@@ -0,0 +1,121 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Escalation target types.
+const (
+	EscalationTargetUser     = "user"
+	EscalationTargetSchedule = "schedule"
+)
+
+// EscalationTargetTypes lists every valid EscalationTarget.Type value.
+var EscalationTargetTypes = []string{EscalationTargetUser, EscalationTargetSchedule}
+
+// EscalationPolicy decides who is paged for an incident and in what order.
+// A page notifies the first tier and moves to the next one when nobody
+// acknowledges within the tier's timeout. After the last tier it starts
+// again from the first, RepeatCount more times, and then gives up.
+type EscalationPolicy struct {
+	ID             string  `gorm:"type:text;primaryKey"`
+	OrganizationID *string `gorm:"type:text"`
+	Name           string  `gorm:"type:text;not null;uniqueIndex"`
+	Description    string  `gorm:"type:text;not null;default:''"`
+	// Tiers are notified in order; a policy has at least one.
+	Tiers       []EscalationTier `gorm:"type:text;not null;default:'[]';serializer:json"`
+	RepeatCount int              `gorm:"not null;default:0"`
+	CreatedAt   time.Time        `gorm:"not null"`
+	UpdatedAt   time.Time        `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (p *EscalationPolicy) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EscalationTier is one step of an escalation policy.
+type EscalationTier struct {
+	Targets []EscalationTarget `json:"targets"`
+	// TimeoutMinutes is how long the tier has to acknowledge before the
+	// page escalates.
+	TimeoutMinutes int `json:"timeout_minutes"`
+}
+
+// EscalationTarget is a user, or a schedule whose on-call users are paged.
+type EscalationTarget struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Page statuses.
+const (
+	// PageStatusTriggered pages are escalating.
+	PageStatusTriggered    = "triggered"
+	PageStatusAcknowledged = "acknowledged"
+	// PageStatusResolved pages stopped because their incident was resolved.
+	PageStatusResolved = "resolved"
+	// PageStatusExhausted pages ran through every tier and repeat unanswered.
+	PageStatusExhausted = "exhausted"
+)
+
+// Page is one run of an escalation policy for an incident. Tier and Cycle
+// locate the tier last notified; NextEscalationAt is when the page moves on
+// unless acknowledged, and is nil once the page has stopped.
+type Page struct {
+	ID               string  `gorm:"type:text;primaryKey"`
+	OrganizationID   *string `gorm:"type:text"`
+	IncidentID       string  `gorm:"type:text;not null;index"`
+	PolicyID         string  `gorm:"type:text;not null"`
+	Status           string  `gorm:"type:text;not null;default:'triggered'"`
+	Tier             int     `gorm:"not null;default:0"`
+	Cycle            int     `gorm:"not null;default:0"`
+	NextEscalationAt *time.Time
+	AcknowledgedAt   *time.Time
+	AcknowledgedBy   *string   `gorm:"type:text"`
+	CreatedBy        *string   `gorm:"type:text"`
+	CreatedAt        time.Time `gorm:"not null"`
+	UpdatedAt        time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (p *Page) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// PageToken actions.
+const (
+	PageActionAck     = "ack"
+	PageActionResolve = "resolve"
+)
+
+// PageToken is a single-use token behind a link in a page notification that
+// lets UserID acknowledge the page, or resolve its incident, without
+// logging in. Only the token's SHA-256 hash is stored, as for RefreshToken.
+type PageToken struct {
+	ID        string    `gorm:"type:text;primaryKey"`
+	PageID    string    `gorm:"type:text;not null;index"`
+	UserID    string    `gorm:"type:text;not null"`
+	Action    string    `gorm:"type:text;not null"`
+	TokenHash string    `gorm:"type:text;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (t *PageToken) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
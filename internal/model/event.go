@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Stream event types published on GET /api/v1/stream.
+const (
+	EventIncidentCreated      = "incident.created"
+	EventIncidentUpdated      = "incident.updated"
+	EventTimelineEntryCreated = "timeline_entry.created"
+	EventAlertCreated         = "alert.created"
+)
+
+// Event is one entry in the change feed behind the live stream. It names the
+// changed resource rather than embedding it; subscribers render the resource
+// when the event is delivered. IDs increase with every event and double as
+// SSE event IDs for Last-Event-ID resume.
+type Event struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement"`
+	OrganizationID *string   `gorm:"type:text;index"`
+	Type           string    `gorm:"type:text;not null"`
+	ResourceType   string    `gorm:"type:text;not null"`
+	ResourceID     string    `gorm:"type:text;not null"`
+	CreatedAt      time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Incident statuses, in lifecycle order. The allowed transitions between
+// them are enforced by the incident package.
+const (
+	IncidentStatusDeclared      = "declared"
+	IncidentStatusInvestigating = "investigating"
+	IncidentStatusIdentified    = "identified"
+	IncidentStatusMonitoring    = "monitoring"
+	IncidentStatusResolved      = "resolved"
+)
+
+// IncidentStatuses lists every valid Incident.Status value.
+var IncidentStatuses = []string{
+	IncidentStatusDeclared,
+	IncidentStatusInvestigating,
+	IncidentStatusIdentified,
+	IncidentStatusMonitoring,
+	IncidentStatusResolved,
+}
+
+// Incident roles held by responders for the lifetime of one incident. They
+// are unrelated to the RBAC roles on User.
+const (
+	IncidentRoleCommander = "commander"
+	IncidentRoleScribe    = "scribe"
+	IncidentRoleCommsLead = "comms_lead"
+)
+
+// IncidentRoles lists every valid incident role.
+var IncidentRoles = []string{IncidentRoleCommander, IncidentRoleScribe, IncidentRoleCommsLead}
+
+// IncidentRoleColumns maps each incident role to the column holding its
+// user ID.
+var IncidentRoleColumns = map[string]string{
+	IncidentRoleCommander: "commander_id",
+	IncidentRoleScribe:    "scribe_id",
+	IncidentRoleCommsLead: "comms_lead_id",
+}
+
+// Incident is a declared service disruption being worked by responders.
+// Severity is one of Severities.
+type Incident struct {
+	ID             string    `gorm:"type:text;primaryKey"`
+	OrganizationID *string   `gorm:"type:text"`
+	Title          string    `gorm:"type:text;not null"`
+	Summary        string    `gorm:"type:text;not null;default:''"`
+	Severity       string    `gorm:"type:text;not null;index"`
+	Status         string    `gorm:"type:text;not null;index"`
+	DeclaredBy     *string   `gorm:"type:text"`
+	DeclaredAt     time.Time `gorm:"not null;index"`
+	// ResolvedAt and ResolvedBy are cleared when the incident is reopened.
+	ResolvedAt *time.Time
+	ResolvedBy *string `gorm:"type:text"`
+	// DedupKey is the model.DedupKey of the alert that declared the incident
+	// automatically. It is held while the incident is open so later alerts
+	// with the same fingerprint attach to it, and is not restored on reopen.
+	DedupKey *string `gorm:"type:text;uniqueIndex"`
+	// The users holding each incident role; nil while unassigned.
+	CommanderID *string `gorm:"type:text;index"`
+	ScribeID    *string `gorm:"type:text"`
+	CommsLeadID *string `gorm:"type:text"`
+	// Impact timeline. ImpactStartedAt and ImpactEndedAt bound the customer
+	// impact and are entered by responders. DetectedAt is when the first
+	// signal arrived: the alert's occurrence for automatic incidents, the
+	// declaration otherwise. AcknowledgedAt is set when the incident first
+	// leaves declared, and MitigatedAt when it first reaches monitoring or
+	// resolved; reopening clears MitigatedAt.
+	ImpactStartedAt *time.Time
+	ImpactEndedAt   *time.Time
+	DetectedAt      *time.Time
+	AcknowledgedAt  *time.Time
+	MitigatedAt     *time.Time
+	// Components are the catalog entries affected by the incident.
+	Components []Component `gorm:"many2many:incident_components"`
+	CreatedAt  time.Time   `gorm:"not null"`
+	UpdatedAt  time.Time   `gorm:"not null"`
+}
+
+// IncidentMetrics are the response-time measures of an incident. A nil
+// measure has not happened yet or lacks its starting timestamp.
+type IncidentMetrics struct {
+	// TimeToDetect runs from the start of impact to detection.
+	TimeToDetect *time.Duration
+	// TimeToAcknowledge, TimeToMitigate and TimeToResolve run from detection.
+	TimeToAcknowledge *time.Duration
+	TimeToMitigate    *time.Duration
+	TimeToResolve     *time.Duration
+}
+
+// Metrics computes the incident's response-time measures.
+func (i *Incident) Metrics() IncidentMetrics {
+	return IncidentMetrics{
+		TimeToDetect:      between(i.ImpactStartedAt, i.DetectedAt),
+		TimeToAcknowledge: between(i.DetectedAt, i.AcknowledgedAt),
+		TimeToMitigate:    between(i.DetectedAt, i.MitigatedAt),
+		TimeToResolve:     between(i.DetectedAt, i.ResolvedAt),
+	}
+}
+
+func between(from, to *time.Time) *time.Duration {
+	if from == nil || to == nil {
+		return nil
+	}
+	d := to.Sub(*from)
+	return &d
+}
+
+// Component is an entry in the catalog of services and components that
+// incidents can affect.
+type Component struct {
+	ID             string    `gorm:"type:text;primaryKey"`
+	OrganizationID *string   `gorm:"type:text"`
+	Name           string    `gorm:"type:text;not null;uniqueIndex"`
+	Description    string    `gorm:"type:text;not null;default:''"`
+	CreatedAt      time.Time `gorm:"not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (c *Component) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// RoleHolder returns the ID of the user holding role, or nil.
+func (i *Incident) RoleHolder(role string) *string {
+	switch role {
+	case IncidentRoleCommander:
+		return i.CommanderID
+	case IncidentRoleScribe:
+		return i.ScribeID
+	case IncidentRoleCommsLead:
+		return i.CommsLeadID
+	}
+	return nil
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (i *Incident) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -31,14 +31,15 @@ type StringSlice []string
 
 // User is the GORM model for the users table.
 type User struct {
-	ID                   string      `gorm:"type:text;primaryKey"`
-	OrganizationID       *string     `gorm:"type:text"`
-	Email                string      `gorm:"type:text;not null;uniqueIndex"`
-	Name                 string      `gorm:"type:text;not null;default:''"`
-	PasswordHash         string      `gorm:"type:text;not null;default:''"`
-	Roles                StringSlice `gorm:"type:text;not null;default:'[]';serializer:json"`
-	NotificationChannels string      `gorm:"type:text;not null;default:'[]'"`
-	OIDCSub              *string     `gorm:"type:text"`
+	ID             string      `gorm:"type:text;primaryKey"`
+	OrganizationID *string     `gorm:"type:text"`
+	Email          string      `gorm:"type:text;not null;uniqueIndex"`
+	Name           string      `gorm:"type:text;not null;default:''"`
+	PasswordHash   string      `gorm:"type:text;not null;default:''"`
+	Roles          StringSlice `gorm:"type:text;not null;default:'[]';serializer:json"`
+	// NotificationChannels are where the user's pages are sent.
+	NotificationChannels []NotificationChannel `gorm:"type:text;not null;default:'[]';serializer:json"`
+	OIDCSub              *string               `gorm:"type:text"`
 	DeactivatedAt        *time.Time
 	CreatedAt            time.Time `gorm:"not null"`
 	UpdatedAt            time.Time `gorm:"not null"`
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification channel types.
+const (
+	// ChannelWebhook posts a JSON description of the page to any URL.
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelTeams   = "teams"
+	ChannelEmail   = "email"
+)
+
+// ChannelTypes lists every valid NotificationChannel.Type value.
+var ChannelTypes = []string{ChannelWebhook, ChannelSlack, ChannelTeams, ChannelEmail}
+
+// NotificationChannel is one way of reaching a user. Target is the
+// incoming-webhook URL for webhook, Slack and Teams channels, and the
+// address for email.
+type NotificationChannel struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+}
+
+// Notification delivery statuses.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusFailed deliveries hit a permanent error or ran out of
+	// attempts.
+	DeliveryStatusFailed = "failed"
+	// DeliveryStatusCanceled deliveries were not sent because their page
+	// stopped first.
+	DeliveryStatusCanceled = "canceled"
+)
+
+// NotificationDelivery sends one page notification to one of a user's
+// channels. Tier and Cycle locate the escalation step that paged the user.
+// Every try is logged as a DeliveryAttempt.
+type NotificationDelivery struct {
+	ID             string  `gorm:"type:text;primaryKey"`
+	OrganizationID *string `gorm:"type:text"`
+	PageID         string  `gorm:"type:text;not null;index"`
+	IncidentID     string  `gorm:"type:text;not null"`
+	UserID         string  `gorm:"type:text;not null"`
+	Channel        string  `gorm:"type:text;not null"`
+	Target         string  `gorm:"type:text;not null"`
+	Tier           int     `gorm:"not null;default:0"`
+	Cycle          int     `gorm:"not null;default:0"`
+	Status         string  `gorm:"type:text;not null;default:'pending'"`
+	Attempts       int     `gorm:"not null;default:0"`
+	LastError      string  `gorm:"type:text;not null;default:''"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time         `gorm:"not null"`
+	UpdatedAt      time.Time         `gorm:"not null"`
+	AttemptLog     []DeliveryAttempt `gorm:"foreignKey:DeliveryID"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (d *NotificationDelivery) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// DeliveryAttempt records one try at sending a NotificationDelivery.
+type DeliveryAttempt struct {
+	ID         string `gorm:"type:text;primaryKey"`
+	DeliveryID string `gorm:"type:text;not null;index"`
+	Attempt    int    `gorm:"not null"`
+	// StatusCode is the HTTP status or SMTP reply code of a failed try; nil
+	// when the try succeeded or got no answer.
+	StatusCode *int
+	Error      string    `gorm:"type:text;not null;default:''"`
+	DurationMS int64     `gorm:"not null;default:0"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (a *DeliveryAttempt) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
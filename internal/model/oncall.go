@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Rotation handoff types.
+const (
+	RotationDaily  = "daily"
+	RotationWeekly = "weekly"
+	// RotationCustom hands off every Rotation.ShiftLengthHours.
+	RotationCustom = "custom"
+)
+
+// RotationTypes lists every valid rotation type.
+var RotationTypes = []string{RotationDaily, RotationWeekly, RotationCustom}
+
+// OnCallSchedule decides who is on call. Its rotations hand off at
+// wall-clock times in Timezone, so a 09:00 handoff stays at 09:00 across
+// daylight saving changes. Overrides replace the rotations for their
+// duration.
+type OnCallSchedule struct {
+	ID             string  `gorm:"type:text;primaryKey"`
+	OrganizationID *string `gorm:"type:text"`
+	Name           string  `gorm:"type:text;not null;uniqueIndex"`
+	Description    string  `gorm:"type:text;not null;default:''"`
+	// Timezone is an IANA time zone name such as Europe/London.
+	Timezone  string     `gorm:"type:text;not null;default:'UTC'"`
+	Rotations []Rotation `gorm:"foreignKey:ScheduleID"`
+	Overrides []Override `gorm:"foreignKey:ScheduleID"`
+	CreatedAt time.Time  `gorm:"not null"`
+	UpdatedAt time.Time  `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (s *OnCallSchedule) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Rotation cycles through ParticipantIDs, one shift each, starting with the
+// first participant at StartAt. Shift boundaries fall on the wall-clock time
+// of StartAt in the schedule's time zone.
+//
+// A schedule's rotations are layered: at any instant, only the rotations in
+// the highest Layer that has someone on call apply. Restrictions confine a
+// rotation to weekly time-of-day windows, so layers can cover business hours
+// or hand off between regions.
+type Rotation struct {
+	ID         string `gorm:"type:text;primaryKey"`
+	ScheduleID string `gorm:"type:text;not null;index"`
+	Name       string `gorm:"type:text;not null;default:''"`
+	Type       string `gorm:"type:text;not null"`
+	// ShiftLengthHours is only used by custom rotations.
+	ShiftLengthHours int       `gorm:"not null;default:0"`
+	StartAt          time.Time `gorm:"not null"`
+	// ParticipantIDs are user IDs in handoff order.
+	ParticipantIDs StringSlice `gorm:"type:text;not null;default:'[]';serializer:json"`
+	Layer          int         `gorm:"not null;default:0"`
+	// Restrictions are the windows the rotation is on call in; none means
+	// always.
+	Restrictions []TimeRestriction `gorm:"type:text;not null;default:'[]';serializer:json"`
+	CreatedAt    time.Time         `gorm:"not null"`
+	UpdatedAt    time.Time         `gorm:"not null"`
+}
+
+// TimeRestriction is a daily window in the schedule's time zone, on Days
+// (lowercase English weekday names; empty means every day). Start and End
+// are HH:MM wall-clock times; an End at or before Start ends the window the
+// next day, so "22:00"-"06:00" on friday runs into Saturday morning.
+type TimeRestriction struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (r *Rotation) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ShiftHours returns the length of one shift in wall-clock hours.
+func (r *Rotation) ShiftHours() int {
+	switch r.Type {
+	case RotationDaily:
+		return 24
+	case RotationWeekly:
+		return 7 * 24
+	default:
+		return r.ShiftLengthHours
+	}
+}
+
+// Override puts UserID on call between StartAt and EndAt in place of the
+// schedule's rotations.
+type Override struct {
+	ID         string    `gorm:"type:text;primaryKey"`
+	ScheduleID string    `gorm:"type:text;not null;index"`
+	UserID     string    `gorm:"type:text;not null"`
+	CreatedBy  *string   `gorm:"type:text"`
+	StartAt    time.Time `gorm:"not null"`
+	EndAt      time.Time `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (o *Override) BeforeCreate(_ *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Active reports whether the override applies at t.
+func (o *Override) Active(t time.Time) bool {
+	return !t.Before(o.StartAt) && t.Before(o.EndAt)
+}
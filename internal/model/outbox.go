@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// OutboxJob is a background job staged in the transaction of the change
+// that calls for it. The worker moves staged jobs onto the queue once they
+// commit, so a job is queued if and only if its change commits. Args holds
+// the job's JSON-encoded arguments.
+type OutboxJob struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	Kind      string    `gorm:"type:text;not null"`
+	Args      string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
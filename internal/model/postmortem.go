@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Postmortem statuses. Only published postmortems are offered to AI triage
+// as related context.
+const (
+	PostmortemStatusDraft     = "draft"
+	PostmortemStatusPublished = "published"
+)
+
+// Postmortem is the written review of a past incident. Title and RootCause
+// are full-text indexed: a generated tsvector column with a GIN index on
+// Postgres, the postmortems_fts FTS5 table on SQLite.
+type Postmortem struct {
+	ID             string    `gorm:"type:text;primaryKey"`
+	OrganizationID *string   `gorm:"type:text"`
+	IncidentID     *string   `gorm:"type:text;index"`
+	Title          string    `gorm:"type:text;not null"`
+	RootCause      string    `gorm:"type:text;not null;default:''"`
+	Status         string    `gorm:"type:text;not null;default:'draft';index"`
+	CreatedAt      time.Time `gorm:"not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (p *Postmortem) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// RelatedPostmortem is a postmortem matched to an alert by full-text search,
+// as recorded on a TriageResult.
+type RelatedPostmortem struct {
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	RootCause string  `json:"root_cause"`
+	Score     float64 `json:"score"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Label matcher operators, as in Prometheus Alertmanager.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// LabelMatcher selects alerts by one label. Regular expressions are
+// anchored at both ends.
+type LabelMatcher struct {
+	Label string `json:"label"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// Silence suppresses incoming alerts whose labels satisfy every matcher
+// between StartsAt and EndsAt.
+type Silence struct {
+	ID             string         `gorm:"type:text;primaryKey"`
+	OrganizationID *string        `gorm:"type:text"`
+	Matchers       []LabelMatcher `gorm:"type:text;not null;serializer:json"`
+	Comment        string         `gorm:"type:text;not null;default:''"`
+	CreatedBy      *string        `gorm:"type:text"`
+	StartsAt       time.Time      `gorm:"not null"`
+	EndsAt         time.Time      `gorm:"not null;index"`
+	CreatedAt      time.Time      `gorm:"not null"`
+	UpdatedAt      time.Time      `gorm:"not null"`
+}
+
+// Active reports whether the silence applies at t.
+func (s *Silence) Active(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (s *Silence) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
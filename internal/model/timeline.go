@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Timeline entry kinds.
+const (
+	TimelineKindStatusChange = "status_change"
+	TimelineKindComment      = "comment"
+	TimelineKindPageSent     = "page_sent"
+	TimelineKindAck          = "ack"
+	TimelineKindAIHypothesis = "ai_hypothesis"
+	TimelineKindAlertLinked  = "alert_linked"
+	TimelineKindRoleChange   = "role_change"
+	// TimelineKindEscalation records a page that stopped escalating without
+	// an acknowledgement.
+	TimelineKindEscalation = "escalation"
+)
+
+// TimelineKinds lists every valid TimelineEntry.Kind value.
+var TimelineKinds = []string{
+	TimelineKindStatusChange,
+	TimelineKindComment,
+	TimelineKindPageSent,
+	TimelineKindAck,
+	TimelineKindAIHypothesis,
+	TimelineKindAlertLinked,
+	TimelineKindRoleChange,
+	TimelineKindEscalation,
+}
+
+// ErrTimelineImmutable is returned when a timeline entry is updated or
+// deleted.
+var ErrTimelineImmutable = errors.New("timeline entries are append-only")
+
+// TimelineEntry is one event in an incident's history. Entries are only ever
+// appended: the hooks below reject updates and deletes through GORM, and
+// database triggers reject them for any other client.
+type TimelineEntry struct {
+	ID             string  `gorm:"type:text;primaryKey"`
+	OrganizationID *string `gorm:"type:text"`
+	IncidentID     string  `gorm:"type:text;not null;index:idx_timeline_entries_incident,priority:1"`
+	Kind           string  `gorm:"type:text;not null"`
+	// AuthorID is the user who caused the entry; nil for system entries.
+	AuthorID *string `gorm:"type:text"`
+	Body     string  `gorm:"type:text;not null;default:''"`
+	// Details holds kind-specific fields, e.g. from and to for status
+	// changes or alert_id for linked alerts.
+	Details    map[string]string `gorm:"type:text;not null;default:'{}';serializer:json"`
+	OccurredAt time.Time         `gorm:"not null;index:idx_timeline_entries_incident,priority:2"`
+	CreatedAt  time.Time         `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (e *TimelineEntry) BeforeCreate(_ *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// BeforeUpdate rejects every update.
+func (e *TimelineEntry) BeforeUpdate(_ *gorm.DB) error { return ErrTimelineImmutable }
+
+// BeforeDelete rejects every delete.
+func (e *TimelineEntry) BeforeDelete(_ *gorm.DB) error { return ErrTimelineImmutable }
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AI-assigned severities, most to least urgent.
+const (
+	SeveritySEV1 = "SEV1"
+	SeveritySEV2 = "SEV2"
+	SeveritySEV3 = "SEV3"
+	SeveritySEV4 = "SEV4"
+)
+
+// Severities lists every valid AI-assigned severity.
+var Severities = []string{SeveritySEV1, SeveritySEV2, SeveritySEV3, SeveritySEV4}
+
+// SeverityAtLeast reports whether sev is as urgent as threshold or more.
+// Unknown severities never qualify.
+func SeverityAtLeast(sev, threshold string) bool {
+	i, j := slices.Index(Severities, sev), slices.Index(Severities, threshold)
+	return i >= 0 && j >= 0 && i <= j
+}
+
+// Alert triage statuses, tracked on Alert.TriageStatus and TriageResult.Status.
+const (
+	TriageStatusPending   = "pending"
+	TriageStatusCompleted = "completed"
+	TriageStatusFailed    = "failed"
+	// TriageStatusSkipped marks alerts that were not sent to the AI provider:
+	// suppressed alerts, or any alert while AI_PROVIDER=noop.
+	TriageStatusSkipped = "skipped"
+)
+
+// TriageResult is one AI triage run for an alert. Every attempt is kept, so
+// an alert that needed retries has several rows; the latest completed one is
+// authoritative.
+type TriageResult struct {
+	ID             string  `gorm:"type:text;primaryKey"`
+	OrganizationID *string `gorm:"type:text"`
+	AlertID        string  `gorm:"type:text;not null;index"`
+	// Attempt is the 1-based job attempt that produced this run.
+	Attempt          int      `gorm:"not null"`
+	Status           string   `gorm:"type:text;not null"`
+	Provider         string   `gorm:"type:text;not null"`
+	Severity         string   `gorm:"column:ai_severity;type:text;not null;default:''"`
+	Summary          string   `gorm:"column:ai_summary;type:text;not null;default:''"`
+	ProbableCause    string   `gorm:"type:text;not null;default:''"`
+	SuggestedActions []string `gorm:"type:text;not null;default:'[]';serializer:json"`
+	// ConfidenceScore is the model's self-reported confidence in [0, 1].
+	ConfidenceScore float64 `gorm:"not null;default:0"`
+	Error           string  `gorm:"type:text;not null;default:''"`
+	// The exchange with the provider, kept verbatim for audit and prompt
+	// tuning. Response is the raw model answer before parsing.
+	SystemPrompt string `gorm:"type:text;not null;default:''"`
+	Prompt       string `gorm:"type:text;not null;default:''"`
+	Response     string `gorm:"type:text;not null;default:''"`
+	Model        string `gorm:"type:text;not null;default:''"`
+	LatencyMS    int64  `gorm:"column:latency_ms;not null;default:0"`
+	InputTokens  int    `gorm:"not null;default:0"`
+	OutputTokens int    `gorm:"not null;default:0"`
+	// RelatedPostmortems are the past postmortems included in the prompt.
+	RelatedPostmortems []RelatedPostmortem `gorm:"type:text;not null;default:'[]';serializer:json"`
+	CreatedAt          time.Time           `gorm:"not null"`
+	UpdatedAt          time.Time           `gorm:"not null"`
+}
+
+// BeforeCreate generates a UUID primary key if not set.
+func (t *TriageResult) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// publicDialer returns a dial function that only connects to public
+// addresses, so webhook URLs supplied by users cannot reach the server's
+// own network. The check runs on the address being dialled, after DNS
+// resolution, so names that resolve to private addresses are caught too.
+// Hosts in allowed are dialled without the check.
+func publicDialer(timeout time.Duration, allowed []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	guarded := &net.Dialer{Timeout: timeout, Control: checkPublic}
+	open := &net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil && slices.Contains(allowed, strings.ToLower(host)) {
+			return open.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// checkPublic is a net.Dialer Control function that refuses connections
+// to addresses that are not public.
+func checkPublic(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedTarget, address)
+	}
+	if !isPublic(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedTarget, ap.Addr())
+	}
+	return nil
+}
+
+// isPublic reports whether addr is a global unicast address outside the
+// private ranges. Loopback, link-local, multicast and unspecified addresses
+// are not global unicast.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP sends email through an SMTP server, upgrading the connection with
+// STARTTLS when the server offers it.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// Timeout bounds the whole exchange with the server.
+	Timeout time.Duration
+}
+
+// Notify implements Notifier.
+func (n *SMTP) Notify(ctx context.Context, to string, msg Message) error {
+	if n.Host == "" {
+		return ErrEmailDisabled
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("%w: %q is not an email address", ErrInvalidTarget, to)
+	}
+	deadline := time.Now().Add(timeoutOr(n.Timeout))
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.Host, strconv.Itoa(n.Port)))
+	if err != nil {
+		return fmt.Errorf("notify: connect to smtp server: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("notify: connect to smtp server: %w", err)
+	}
+	c, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError(err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: n.Host}); err != nil {
+			return smtpError(err)
+		}
+	}
+	if n.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			return smtpError(err)
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return smtpError(err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return smtpError(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(n.message(to, msg)); err != nil {
+		return smtpError(err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	// The server has accepted the message; failing to say goodbye must not
+	// send it again.
+	_ = c.Quit()
+	return nil
+}
+
+// message renders msg as a plain-text RFC 5322 message.
+func (n *SMTP) message(to string, msg Message) []byte {
+	var b strings.Builder
+	header := func(k, v string) { b.WriteString(k + ": " + v + "\r\n") }
+	header("From", n.From)
+	header("To", to)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text+msg.links(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// smtpError turns SMTP replies into *Error: 4xx replies are temporary, 5xx
+// replies permanent. Other errors, such as timeouts, pass through.
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return fmt.Errorf("notify: smtp: %w", err)
+	}
+	return &Error{
+		Channel:    "email",
+		StatusCode: reply.Code,
+		Message:    reply.Msg,
+		Temporary:  reply.Code < 500,
+	}
+}
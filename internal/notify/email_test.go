@@ -0,0 +1,161 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a minimal local SMTP stand-in. It accepts one session at a
+// time and answers RCPT TO with rcptReply.
+type smtpServer struct {
+	ln        net.Listener
+	rcptReply string
+
+	mu   sync.Mutex
+	rcpt []string
+	data []string
+}
+
+func newSMTPServer(t *testing.T, rcptReply string) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{ln: ln, rcptReply: rcptReply}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.session(conn)
+	}
+}
+
+func (s *smtpServer) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.TrimSpace(line[len("RCPT TO:"):]))
+			s.mu.Unlock()
+			reply(s.rcptReply)
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, b.String())
+			s.mu.Unlock()
+			reply("250 OK: queued")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) notifier(t *testing.T) *notify.SMTP {
+	t.Helper()
+	host, port, err := net.SplitHostPort(s.ln.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return &notify.SMTP{Host: host, Port: p, From: "autopsy@example.com", Timeout: 5 * time.Second}
+}
+
+func TestSMTP_SendsMessage(t *testing.T) {
+	srv := newSMTPServer(t, "250 OK")
+
+	require.NoError(t, srv.notifier(t).Notify(context.Background(), "oncall@example.com", testMessage))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, []string{"<oncall@example.com>"}, srv.rcpt)
+	require.Len(t, srv.data, 1)
+	msg := srv.data[0]
+	assert.Contains(t, msg, "From: autopsy@example.com\r\n")
+	assert.Contains(t, msg, "To: oncall@example.com\r\n")
+	assert.Contains(t, msg, "Subject: [SEV2] Checkout errors\r\n")
+	assert.Contains(t, msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	assert.Contains(t, msg, "\r\n\r\nYou are being paged.\r\n\r\n"+
+		"Acknowledge: https://autopsy.example.com/api/v1/pages/t1/ack\r\n"+
+		"Resolve: https://autopsy.example.com/api/v1/pages/t2/resolve\r\n")
+}
+
+func TestSMTP_Rejections(t *testing.T) {
+	for _, tc := range []struct {
+		reply     string
+		code      int
+		retryable bool
+	}{
+		{"450 Mailbox busy", 450, true},
+		{"550 No such user", 550, false},
+	} {
+		t.Run(tc.reply, func(t *testing.T) {
+			srv := newSMTPServer(t, tc.reply)
+			err := srv.notifier(t).Notify(context.Background(), "oncall@example.com", testMessage)
+
+			var e *notify.Error
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, tc.code, e.StatusCode)
+			assert.Equal(t, tc.retryable, notify.Retryable(err))
+		})
+	}
+}
+
+func TestSMTP_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	n := &notify.SMTP{Host: "127.0.0.1", Port: port, From: "autopsy@example.com", Timeout: time.Second}
+	err = n.Notify(context.Background(), "oncall@example.com", testMessage)
+	require.Error(t, err)
+	assert.True(t, notify.Retryable(err), "connection failures are retried")
+}
+
+func TestSMTP_Disabled(t *testing.T) {
+	err := (&notify.SMTP{}).Notify(context.Background(), "oncall@example.com", testMessage)
+	require.ErrorIs(t, err, notify.ErrEmailDisabled)
+	assert.False(t, notify.Retryable(err))
+}
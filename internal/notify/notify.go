@@ -0,0 +1,153 @@
+// Package notify sends page notifications over the channels users set up:
+// generic webhooks, Slack and Microsoft Teams incoming webhooks, and SMTP
+// email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+var (
+	// ErrEmailDisabled is returned by the email notifier when SMTP_HOST is
+	// not set.
+	ErrEmailDisabled = errors.New("notify: email is disabled (SMTP_HOST is not set)")
+	// ErrInvalidTarget is returned when a channel's target is not a usable
+	// URL or address.
+	ErrInvalidTarget = errors.New("notify: invalid target")
+	// ErrUnknownChannel is returned for channel types without a notifier.
+	ErrUnknownChannel = errors.New("notify: unknown channel type")
+	// ErrBlockedTarget is returned when a webhook target resolves to a
+	// private, loopback or link-local address and its host is not in
+	// NOTIFY_PRIVATE_HOSTS.
+	ErrBlockedTarget = errors.New("notify: target address is not public")
+)
+
+// Message is a page notification.
+type Message struct {
+	Subject string
+	Text    string
+
+	IncidentID     string
+	IncidentTitle  string
+	Severity       string
+	IncidentStatus string
+	PageID         string
+	Tier           int
+
+	// AckURL and ResolveURL are single-use links that acknowledge the page
+	// and resolve its incident. Both are empty when links are disabled.
+	AckURL     string
+	ResolveURL string
+}
+
+// links renders msg's action links as plain-text lines, or returns "".
+func (m Message) links() string {
+	if m.AckURL == "" {
+		return ""
+	}
+	return "\n\nAcknowledge: " + m.AckURL + "\nResolve: " + m.ResolveURL
+}
+
+// Notifier sends messages over one type of channel.
+type Notifier interface {
+	// Notify sends msg to target, the channel's URL or email address.
+	Notify(ctx context.Context, target string, msg Message) error
+}
+
+// Error is a delivery rejected by the receiving server.
+type Error struct {
+	Channel string
+	// StatusCode is the HTTP status or SMTP reply code.
+	StatusCode int
+	Message    string
+	// Temporary is set for server-side failures that may clear up: HTTP 5xx
+	// and SMTP 4xx replies.
+	Temporary bool
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("notify: %s returned %d: %s", e.Channel, e.StatusCode, e.Message)
+}
+
+// Retryable reports whether a delivery may succeed if repeated later.
+// Timeouts, network errors and temporary server failures are worth
+// retrying; rejected requests and disabled channels are not.
+func Retryable(err error) bool {
+	if errors.Is(err, ErrEmailDisabled) || errors.Is(err, ErrInvalidTarget) || errors.Is(err, ErrUnknownChannel) ||
+		errors.Is(err, ErrBlockedTarget) {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Temporary
+	}
+	return true
+}
+
+// New returns a notifier for every channel type in model.ChannelTypes,
+// configured by cfg. The webhook notifiers only connect to public
+// addresses, apart from the hosts in cfg.PrivateHosts.
+func New(cfg config.NotifyConfig) map[string]Notifier {
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		// No proxy, so the dialer checks the real destination.
+		Transport: &http.Transport{
+			DialContext:         publicDialer(cfg.Timeout, cfg.PrivateHosts),
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+	return map[string]Notifier{
+		model.ChannelWebhook: &Webhook{Client: client},
+		model.ChannelSlack:   &Slack{Client: client},
+		model.ChannelTeams:   &Teams{Client: client},
+		model.ChannelEmail: &SMTP{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.Timeout,
+		},
+	}
+}
+
+// ValidateChannel checks that c has a known type and a target of the right
+// kind: an absolute https URL, or an email address.
+func ValidateChannel(c model.NotificationChannel) error {
+	switch c.Type {
+	case model.ChannelWebhook, model.ChannelSlack, model.ChannelTeams:
+		u, err := url.Parse(c.Target)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("target must be an https URL")
+		}
+	case model.ChannelEmail:
+		addr, err := mail.ParseAddress(c.Target)
+		if err != nil || addr.Address != c.Target {
+			return errors.New("target must be a bare email address such as oncall@example.com")
+		}
+	default:
+		return fmt.Errorf("type must be one of %s", strings.Join(model.ChannelTypes, ", "))
+	}
+	return nil
+}
+
+// timeoutOr returns d, or a minute if d is not positive.
+func timeoutOr(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Minute
+	}
+	return d
+}
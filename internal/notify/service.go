@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrDeliveryNotFound is returned when a delivery does not exist.
+var ErrDeliveryNotFound = errors.New("notify: delivery not found")
+
+// Links configures the acknowledge and resolve links added to
+// notifications.
+type Links struct {
+	Tokens *auth.PageTokenStore
+	// BaseURL is the server's public URL, such as https://autopsy.example.com.
+	BaseURL string
+	// TTL is how long each link can be used.
+	TTL time.Duration
+	// CanAct reports whether a user with roles may use the links. Users it
+	// refuses, and every user while it is nil, get none.
+	CanAct func(roles []string) bool
+}
+
+// Service sends notification deliveries and logs every attempt.
+type Service struct {
+	db        *gorm.DB
+	notifiers map[string]Notifier
+	links     *Links
+}
+
+// NewService creates a Service that sends through notifiers, keyed by
+// channel type. Each notification gets fresh action links from links; a
+// nil links leaves them out.
+func NewService(db *gorm.DB, notifiers map[string]Notifier, links *Links) *Service {
+	return &Service{db: db, notifiers: notifiers, links: links}
+}
+
+// Queue creates a pending delivery to each channel of each of users for
+// page's current tier and returns their IDs. tx is the caller's
+// transaction, so the deliveries commit with the page step that caused
+// them. Users without channels get none.
+func Queue(tx *gorm.DB, page *model.Page, users []string) ([]string, error) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+	var found []model.User
+	if err := tx.Where("id IN ?", users).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("load users: %w", err)
+	}
+	byID := make(map[string]*model.User, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+
+	var ids []string
+	for _, id := range users {
+		u, ok := byID[id]
+		if !ok {
+			continue
+		}
+		for _, c := range u.NotificationChannels {
+			d := model.NotificationDelivery{
+				OrganizationID: page.OrganizationID,
+				PageID:         page.ID,
+				IncidentID:     page.IncidentID,
+				UserID:         u.ID,
+				Channel:        c.Type,
+				Target:         c.Target,
+				Tier:           page.Tier,
+				Cycle:          page.Cycle,
+				Status:         model.DeliveryStatusPending,
+			}
+			if err := tx.Create(&d).Error; err != nil {
+				return nil, fmt.Errorf("create delivery: %w", err)
+			}
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids, nil
+}
+
+// Deliver makes try number attempt at sending delivery id, logs it, and
+// returns the notifier's error. A failed try marks the delivery failed when
+// it cannot be retried or final is set, and leaves it pending otherwise.
+// Deliveries that are no longer pending are skipped, and those whose page
+// was acknowledged or resolved, or whose incident was resolved, in the
+// meantime are canceled unsent.
+func (s *Service) Deliver(ctx context.Context, id string, attempt int, final bool) error {
+	db := s.db.WithContext(ctx)
+	var d model.NotificationDelivery
+	err := db.Where("id = ?", id).First(&d).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrDeliveryNotFound
+	case err != nil:
+		return fmt.Errorf("load delivery: %w", err)
+	}
+	if d.Status != model.DeliveryStatusPending {
+		return nil
+	}
+	var page model.Page
+	if err := db.Where("id = ?", d.PageID).First(&page).Error; err != nil {
+		return fmt.Errorf("load page: %w", err)
+	}
+	if page.Status == model.PageStatusAcknowledged || page.Status == model.PageStatusResolved {
+		return s.finish(ctx, &d, model.DeliveryStatusCanceled, nil)
+	}
+	var inc model.Incident
+	if err := db.Where("id = ?", d.IncidentID).First(&inc).Error; err != nil {
+		return fmt.Errorf("load incident: %w", err)
+	}
+	// Pages only stop at their next escalation step, which can be after
+	// the incident was resolved.
+	if inc.Status == model.IncidentStatusResolved {
+		return s.finish(ctx, &d, model.DeliveryStatusCanceled, nil)
+	}
+
+	msg := message(&d, &inc)
+	if err := s.addLinks(ctx, &d, &msg); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	sendErr := ErrUnknownChannel
+	if n, ok := s.notifiers[d.Channel]; ok {
+		sendErr = n.Notify(ctx, d.Target, msg)
+	}
+	record := model.DeliveryAttempt{
+		DeliveryID: d.ID,
+		Attempt:    attempt,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	status := model.DeliveryStatusDelivered
+	if sendErr != nil {
+		record.Error = sendErr.Error()
+		var e *Error
+		if errors.As(sendErr, &e) {
+			record.StatusCode = &e.StatusCode
+		}
+		status = model.DeliveryStatusPending
+		if final || !Retryable(sendErr) {
+			status = model.DeliveryStatusFailed
+		}
+	}
+	d.Attempts = attempt
+	if err := s.finish(ctx, &d, status, &record); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// finish records the attempt, if any, and moves d to status.
+func (s *Service) finish(ctx context.Context, d *model.NotificationDelivery, status string, record *model.DeliveryAttempt) error {
+	now := time.Now()
+	updates := map[string]any{"status": status, "attempts": d.Attempts, "updated_at": now}
+	if status == model.DeliveryStatusDelivered {
+		updates["delivered_at"] = now
+	}
+	if record != nil {
+		updates["last_error"] = record.Error
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if record != nil {
+			if err := tx.Create(record).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(d).Updates(updates).Error
+	})
+	if err != nil {
+		return fmt.Errorf("record delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// addLinks adds the URLs of d's page action links to msg, if d's user may
+// act on pages. Each delivery has one token per action, which its retries
+// send again.
+func (s *Service) addLinks(ctx context.Context, d *model.NotificationDelivery, msg *Message) error {
+	if s.links == nil || s.links.CanAct == nil {
+		return nil
+	}
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("roles").Where("id = ?", d.UserID).First(&user).Error; err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	if !s.links.CanAct(user.Roles) {
+		return nil
+	}
+	base := strings.TrimSuffix(s.links.BaseURL, "/") + "/api/v1/pages/"
+	for action, url := range map[string]*string{
+		model.PageActionAck:     &msg.AckURL,
+		model.PageActionResolve: &msg.ResolveURL,
+	} {
+		token, err := s.links.Tokens.IssueOnce(ctx, d.ID, d.PageID, d.UserID, action, s.links.TTL)
+		if err != nil {
+			return err
+		}
+		*url = base + token + "/" + action
+	}
+	return nil
+}
+
+// message renders the notification for d.
+func message(d *model.NotificationDelivery, inc *model.Incident) Message {
+	return Message{
+		Subject: fmt.Sprintf("[%s] %s", inc.Severity, inc.Title),
+		Text: fmt.Sprintf("You are being paged for incident %q (%s, %s) at escalation tier %d.",
+			inc.Title, inc.Severity, inc.Status, d.Tier+1),
+		IncidentID:     inc.ID,
+		IncidentTitle:  inc.Title,
+		Severity:       inc.Severity,
+		IncidentStatus: inc.Status,
+		PageID:         d.PageID,
+		Tier:           d.Tier,
+	}
+}
@@ -0,0 +1,212 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/auth"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/notify"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeNotifier returns errs in order, then nil, and records what it sent.
+type fakeNotifier struct {
+	errs []error
+	sent []notify.Message
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, _ string, msg notify.Message) error {
+	n.sent = append(n.sent, msg)
+	if len(n.errs) == 0 {
+		return nil
+	}
+	err := n.errs[0]
+	n.errs = n.errs[1:]
+	return err
+}
+
+type deliveryFixture struct {
+	db       *gorm.DB
+	pages    *escalation.Service
+	notifier *fakeNotifier
+	svc      *notify.Service
+	page     *model.Page
+	delivery string
+}
+
+// setupDelivery pages alice, who has one webhook channel, for a new incident.
+func setupDelivery(t *testing.T) *deliveryFixture {
+	t.Helper()
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	dbtest.CreateAlice(t, gormDB)
+	policy := &model.EscalationPolicy{Name: "Checkout", Tiers: []model.EscalationTier{
+		{Targets: []model.EscalationTarget{{Type: model.EscalationTargetUser, ID: "alice"}}, TimeoutMinutes: 5},
+	}}
+	require.NoError(t, gormDB.Create(policy).Error)
+	inc := &model.Incident{Title: "Checkout errors", Severity: model.SeveritySEV2}
+	require.NoError(t, incident.NewService(gormDB).Declare(ctx, inc, nil))
+
+	pages := escalation.NewService(gormDB, oncall.NewService(gormDB))
+	page, out, err := pages.Start(ctx, inc.ID, policy.ID, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, out.Deliveries, 1)
+
+	n := &fakeNotifier{}
+	return &deliveryFixture{
+		db:       gormDB,
+		pages:    pages,
+		notifier: n,
+		svc: notify.NewService(gormDB, map[string]notify.Notifier{model.ChannelWebhook: n}, &notify.Links{
+			Tokens:  auth.NewPageTokenStore(gormDB, "test-secret-at-least-32-bytes-long"),
+			BaseURL: "https://autopsy.example.com/",
+			TTL:     time.Hour,
+			CanAct:  func(roles []string) bool { return slices.Contains(roles, "Responder") },
+		}),
+		page:     page,
+		delivery: out.Deliveries[0],
+	}
+}
+
+func (f *deliveryFixture) load(t *testing.T) *model.NotificationDelivery {
+	t.Helper()
+	var d model.NotificationDelivery
+	require.NoError(t, f.db.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt")
+	}).Where("id = ?", f.delivery).First(&d).Error)
+	return &d
+}
+
+func TestDeliver_RetriesThenDelivers(t *testing.T) {
+	f := setupDelivery(t)
+	ctx := context.Background()
+	f.notifier.errs = []error{&notify.Error{Channel: "webhook", StatusCode: 503, Message: "busy", Temporary: true}}
+
+	err := f.svc.Deliver(ctx, f.delivery, 1, false)
+	require.Error(t, err)
+	assert.True(t, notify.Retryable(err))
+	d := f.load(t)
+	assert.Equal(t, model.DeliveryStatusPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+
+	require.NoError(t, f.svc.Deliver(ctx, f.delivery, 2, false))
+	d = f.load(t)
+	assert.Equal(t, model.DeliveryStatusDelivered, d.Status)
+	assert.Equal(t, 2, d.Attempts)
+	assert.NotNil(t, d.DeliveredAt)
+	require.Len(t, d.AttemptLog, 2)
+	assert.Equal(t, 503, *d.AttemptLog[0].StatusCode)
+	assert.Contains(t, d.AttemptLog[0].Error, "busy")
+	assert.Nil(t, d.AttemptLog[1].StatusCode)
+	assert.Empty(t, d.AttemptLog[1].Error)
+
+	msg := f.notifier.sent[1]
+	assert.Equal(t, "[SEV2] Checkout errors", msg.Subject)
+	assert.Equal(t, f.page.ID, msg.PageID)
+
+	require.NoError(t, f.svc.Deliver(ctx, f.delivery, 3, false))
+	assert.Len(t, f.notifier.sent, 2, "delivered notifications are not sent again")
+}
+
+func TestDeliver_AddsActionLinks(t *testing.T) {
+	f := setupDelivery(t)
+	ctx := context.Background()
+	f.notifier.errs = []error{errors.New("connection reset")}
+
+	require.Error(t, f.svc.Deliver(ctx, f.delivery, 1, false))
+	require.NoError(t, f.svc.Deliver(ctx, f.delivery, 2, false))
+	require.Len(t, f.notifier.sent, 2)
+
+	first, second := f.notifier.sent[0], f.notifier.sent[1]
+	for _, msg := range f.notifier.sent {
+		assert.True(t, strings.HasPrefix(msg.AckURL, "https://autopsy.example.com/api/v1/pages/"), msg.AckURL)
+		assert.True(t, strings.HasSuffix(msg.AckURL, "/ack"), msg.AckURL)
+		assert.True(t, strings.HasSuffix(msg.ResolveURL, "/resolve"), msg.ResolveURL)
+	}
+	assert.Equal(t, first, second, "retries resend the same links")
+	assert.NotEqual(t, first.AckURL, first.ResolveURL)
+
+	var tokens []model.PageToken
+	require.NoError(t, f.db.Order("created_at").Find(&tokens).Error)
+	require.Len(t, tokens, 2)
+	for _, pt := range tokens {
+		assert.Equal(t, f.page.ID, pt.PageID)
+		assert.Equal(t, "alice", pt.UserID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), pt.ExpiresAt, time.Minute)
+	}
+}
+
+func TestDeliver_NoLinksWithoutPermission(t *testing.T) {
+	f := setupDelivery(t)
+	require.NoError(t, f.db.Model(&model.User{}).Where("id = ?", "alice").Update("roles", `["Viewer"]`).Error)
+
+	require.NoError(t, f.svc.Deliver(context.Background(), f.delivery, 1, false))
+	require.Len(t, f.notifier.sent, 1)
+	assert.Empty(t, f.notifier.sent[0].AckURL)
+	assert.Empty(t, f.notifier.sent[0].ResolveURL)
+	var n int64
+	require.NoError(t, f.db.Model(&model.PageToken{}).Count(&n).Error)
+	assert.Zero(t, n)
+}
+
+func TestDeliver_FailsOnPermanentErrorOrLastAttempt(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err   error
+		final bool
+	}{
+		"permanent": {&notify.Error{Channel: "webhook", StatusCode: 404, Message: "gone"}, false},
+		"final":     {errors.New("connection reset"), true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := setupDelivery(t)
+			f.notifier.errs = []error{tc.err}
+
+			require.Error(t, f.svc.Deliver(context.Background(), f.delivery, 1, tc.final))
+			d := f.load(t)
+			assert.Equal(t, model.DeliveryStatusFailed, d.Status)
+			assert.Len(t, d.AttemptLog, 1)
+		})
+	}
+}
+
+func TestDeliver_CanceledOnceAcknowledged(t *testing.T) {
+	f := setupDelivery(t)
+	ctx := context.Background()
+	_, err := f.pages.Acknowledge(ctx, f.page.ID, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.svc.Deliver(ctx, f.delivery, 1, false))
+	d := f.load(t)
+	assert.Equal(t, model.DeliveryStatusCanceled, d.Status)
+	assert.Empty(t, d.AttemptLog)
+	assert.Empty(t, f.notifier.sent)
+}
+
+func TestDeliver_CanceledOnceIncidentResolved(t *testing.T) {
+	f := setupDelivery(t)
+	ctx := context.Background()
+	_, err := incident.NewService(f.db).Transition(ctx, f.page.IncidentID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, f.svc.Deliver(ctx, f.delivery, 1, false))
+	d := f.load(t)
+	assert.Equal(t, model.DeliveryStatusCanceled, d.Status)
+	assert.Empty(t, f.notifier.sent)
+}
+
+func TestDeliver_NotFound(t *testing.T) {
+	f := setupDelivery(t)
+	err := f.svc.Deliver(context.Background(), "missing", 1, false)
+	require.ErrorIs(t, err, notify.ErrDeliveryNotFound)
+}
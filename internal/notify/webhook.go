@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBytes caps how much of an error response is kept.
+const maxErrorBytes = 512
+
+// Webhook posts a JSON description of the page to any URL.
+type Webhook struct {
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Event    string          `json:"event"`
+	Subject  string          `json:"subject"`
+	Text     string          `json:"text"`
+	Incident webhookIncident `json:"incident"`
+	Page     webhookPage     `json:"page"`
+}
+
+type webhookIncident struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+}
+
+type webhookPage struct {
+	ID         string `json:"id"`
+	Tier       int    `json:"tier"`
+	AckURL     string `json:"ack_url,omitempty"`
+	ResolveURL string `json:"resolve_url,omitempty"`
+}
+
+// Notify implements Notifier.
+func (n *Webhook) Notify(ctx context.Context, url string, msg Message) error {
+	return postJSON(ctx, n.Client, "webhook", url, webhookPayload{
+		Event:   "page",
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		Incident: webhookIncident{
+			ID:       msg.IncidentID,
+			Title:    msg.IncidentTitle,
+			Severity: msg.Severity,
+			Status:   msg.IncidentStatus,
+		},
+		Page: webhookPage{
+			ID:         msg.PageID,
+			Tier:       msg.Tier,
+			AckURL:     msg.AckURL,
+			ResolveURL: msg.ResolveURL,
+		},
+	})
+}
+
+// Slack posts to a Slack incoming webhook.
+type Slack struct {
+	Client *http.Client
+}
+
+// slackEscaper escapes the characters Slack's mrkdwn gives meaning to, so
+// text from alert payloads cannot add links or mentions.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Notify implements Notifier.
+func (n *Slack) Notify(ctx context.Context, url string, msg Message) error {
+	text := "*" + slackEscaper.Replace(msg.Subject) + "*\n" + slackEscaper.Replace(msg.Text)
+	if msg.AckURL != "" {
+		text += "\n<" + msg.AckURL + "|Acknowledge> | <" + msg.ResolveURL + "|Resolve>"
+	}
+	// Unfurling would fetch the action links.
+	return postJSON(ctx, n.Client, "slack", url, map[string]any{"text": text, "unfurl_links": false, "unfurl_media": false})
+}
+
+// Teams posts an Adaptive Card to a Microsoft Teams incoming webhook.
+type Teams struct {
+	Client *http.Client
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []teamsTextBlock `json:"body"`
+	Actions []teamsAction    `json:"actions,omitempty"`
+}
+
+type teamsTextBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Wrap   bool   `json:"wrap"`
+}
+
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Notify implements Notifier.
+func (n *Teams) Notify(ctx context.Context, url string, msg Message) error {
+	var actions []teamsAction
+	if msg.AckURL != "" {
+		actions = []teamsAction{
+			{Type: "Action.OpenUrl", Title: "Acknowledge", URL: msg.AckURL},
+			{Type: "Action.OpenUrl", Title: "Resolve", URL: msg.ResolveURL},
+		}
+	}
+	return postJSON(ctx, n.Client, "teams", url, map[string]any{
+		"type": "message",
+		"attachments": []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []teamsTextBlock{
+					{Type: "TextBlock", Text: msg.Subject, Weight: "Bolder", Size: "Medium", Wrap: true},
+					{Type: "TextBlock", Text: msg.Text, Wrap: true},
+				},
+				Actions: actions,
+			},
+		}},
+	})
+}
+
+// postJSON sends body to url. Non-2xx answers become *Error.
+func postJSON(ctx context.Context, client *http.Client, channel, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("notify: encode %s request: %w", channel, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTarget, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %s request: %w", channel, err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBytes))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &Error{
+			Channel:    channel,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(raw)),
+			Temporary:  resp.StatusCode >= 500,
+		}
+	}
+	return nil
+}
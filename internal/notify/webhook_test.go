@@ -0,0 +1,192 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = notify.Message{
+	Subject:        "[SEV2] Checkout errors",
+	Text:           "You are being paged.",
+	IncidentID:     "inc-1",
+	IncidentTitle:  "Checkout errors",
+	Severity:       model.SeveritySEV2,
+	IncidentStatus: model.IncidentStatusDeclared,
+	PageID:         "page-1",
+	Tier:           1,
+	AckURL:         "https://autopsy.example.com/api/v1/pages/t1/ack",
+	ResolveURL:     "https://autopsy.example.com/api/v1/pages/t2/resolve",
+}
+
+// capture starts a server that answers status and records the last request
+// body.
+func capture(t *testing.T, status int) (*httptest.Server, *map[string]any) {
+	t.Helper()
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		raw, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		body = nil
+		assert.NoError(t, json.Unmarshal(raw, &body))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("upstream says no"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &body
+}
+
+func TestWebhook_Payload(t *testing.T) {
+	srv, body := capture(t, http.StatusNoContent)
+	n := &notify.Webhook{Client: srv.Client()}
+
+	require.NoError(t, n.Notify(context.Background(), srv.URL, testMessage))
+	assert.Equal(t, "page", (*body)["event"])
+	assert.Equal(t, testMessage.Subject, (*body)["subject"])
+	assert.Equal(t, map[string]any{
+		"id": "inc-1", "title": "Checkout errors", "severity": "SEV2", "status": "declared",
+	}, (*body)["incident"])
+	assert.Equal(t, map[string]any{
+		"id":          "page-1",
+		"tier":        float64(1),
+		"ack_url":     testMessage.AckURL,
+		"resolve_url": testMessage.ResolveURL,
+	}, (*body)["page"])
+}
+
+func TestSlack_Payload(t *testing.T) {
+	srv, body := capture(t, http.StatusOK)
+	n := &notify.Slack{Client: srv.Client()}
+
+	require.NoError(t, n.Notify(context.Background(), srv.URL, testMessage))
+	assert.Equal(t, map[string]any{"text": "*[SEV2] Checkout errors*\nYou are being paged.\n" +
+		"<https://autopsy.example.com/api/v1/pages/t1/ack|Acknowledge> | " +
+		"<https://autopsy.example.com/api/v1/pages/t2/resolve|Resolve>",
+		"unfurl_links": false, "unfurl_media": false}, *body)
+}
+
+func TestSlack_EscapesMessageText(t *testing.T) {
+	srv, body := capture(t, http.StatusOK)
+	n := &notify.Slack{Client: srv.Client()}
+	msg := testMessage
+	msg.Subject = "[SEV2] <https://evil.example.com|Acknowledge> & <!channel>"
+	msg.Text = "a > b"
+
+	require.NoError(t, n.Notify(context.Background(), srv.URL, msg))
+	assert.Equal(t, "*[SEV2] &lt;https://evil.example.com|Acknowledge&gt; &amp; &lt;!channel&gt;*\n"+
+		"a &gt; b\n"+
+		"<https://autopsy.example.com/api/v1/pages/t1/ack|Acknowledge> | "+
+		"<https://autopsy.example.com/api/v1/pages/t2/resolve|Resolve>", (*body)["text"])
+}
+
+func TestTeams_Payload(t *testing.T) {
+	srv, body := capture(t, http.StatusOK)
+	n := &notify.Teams{Client: srv.Client()}
+
+	require.NoError(t, n.Notify(context.Background(), srv.URL, testMessage))
+	assert.Equal(t, "message", (*body)["type"])
+	attachments := (*body)["attachments"].([]any)
+	require.Len(t, attachments, 1)
+	att := attachments[0].(map[string]any)
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", att["contentType"])
+	card := att["content"].(map[string]any)
+	assert.Equal(t, "AdaptiveCard", card["type"])
+	blocks := card["body"].([]any)
+	require.Len(t, blocks, 2)
+	assert.Equal(t, testMessage.Subject, blocks[0].(map[string]any)["text"])
+	assert.Equal(t, testMessage.Text, blocks[1].(map[string]any)["text"])
+	assert.Equal(t, []any{
+		map[string]any{"type": "Action.OpenUrl", "title": "Acknowledge", "url": testMessage.AckURL},
+		map[string]any{"type": "Action.OpenUrl", "title": "Resolve", "url": testMessage.ResolveURL},
+	}, card["actions"])
+}
+
+func TestPostJSON_Failures(t *testing.T) {
+	for _, tc := range []struct {
+		status    int
+		retryable bool
+	}{
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusNotFound, false},
+		{http.StatusBadRequest, false},
+	} {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			srv, _ := capture(t, tc.status)
+			err := (&notify.Slack{Client: srv.Client()}).Notify(context.Background(), srv.URL, testMessage)
+
+			var e *notify.Error
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, tc.status, e.StatusCode)
+			assert.Equal(t, "upstream says no", e.Message)
+			assert.Equal(t, tc.retryable, notify.Retryable(err))
+		})
+	}
+}
+
+func TestPostJSON_TimeoutIsRetryable(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := srv.Client()
+	client.Timeout = 50 * time.Millisecond
+	err := (&notify.Webhook{Client: client}).Notify(context.Background(), srv.URL, testMessage)
+	require.Error(t, err)
+	assert.True(t, notify.Retryable(err))
+}
+
+func TestPostJSON_InvalidTarget(t *testing.T) {
+	err := (&notify.Webhook{Client: http.DefaultClient}).Notify(context.Background(), "http://bad host", testMessage)
+	require.ErrorIs(t, err, notify.ErrInvalidTarget)
+	assert.False(t, notify.Retryable(err))
+}
+
+func TestNew_BlocksPrivateTargets(t *testing.T) {
+	srv, body := capture(t, http.StatusNoContent)
+	ctx := context.Background()
+
+	n := notify.New(config.NotifyConfig{Timeout: 5 * time.Second})[model.ChannelWebhook]
+	err := n.Notify(ctx, srv.URL, testMessage)
+	require.ErrorIs(t, err, notify.ErrBlockedTarget)
+	assert.False(t, notify.Retryable(err))
+	assert.Nil(t, *body, "nothing was sent")
+
+	n = notify.New(config.NotifyConfig{Timeout: 5 * time.Second, PrivateHosts: []string{"127.0.0.1"}})[model.ChannelWebhook]
+	require.NoError(t, n.Notify(ctx, srv.URL, testMessage))
+}
+
+func TestValidateChannel(t *testing.T) {
+	for _, tc := range []struct {
+		channel model.NotificationChannel
+		valid   bool
+	}{
+		{model.NotificationChannel{Type: model.ChannelWebhook, Target: "https://hooks.example.com/x"}, true},
+		{model.NotificationChannel{Type: model.ChannelSlack, Target: "https://hooks.slack.com/services/T/B/X"}, true},
+		{model.NotificationChannel{Type: model.ChannelTeams, Target: "ftp://example.com"}, false},
+		{model.NotificationChannel{Type: model.ChannelWebhook, Target: "http://hooks.example.com/x"}, false},
+		{model.NotificationChannel{Type: model.ChannelWebhook, Target: "/relative"}, false},
+		{model.NotificationChannel{Type: model.ChannelEmail, Target: "oncall@example.com"}, true},
+		{model.NotificationChannel{Type: model.ChannelEmail, Target: "On Call <oncall@example.com>"}, false},
+		{model.NotificationChannel{Type: model.ChannelEmail, Target: "not an address"}, false},
+		{model.NotificationChannel{Type: "pager", Target: "https://example.com"}, false},
+	} {
+		err := notify.ValidateChannel(tc.channel)
+		assert.Equal(t, tc.valid, err == nil, "%+v: %v", tc.channel, err)
+	}
+}
@@ -0,0 +1,238 @@
+// Package oncall resolves who is on call for a schedule at a given instant.
+package oncall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a schedule does not exist.
+var ErrNotFound = errors.New("oncall: schedule not found")
+
+// Shift is one user's continuous stretch on call.
+type Shift struct {
+	// ID identifies the shift: the override ID, or the rotation ID and the
+	// shift's index in the rotation.
+	ID     string
+	UserID string
+	// Exactly one of RotationID and OverrideID is set.
+	RotationID *string
+	OverrideID *string
+	Start      time.Time
+	End        time.Time
+}
+
+// LoadLocation loads the IANA time zone name. Unlike time.LoadLocation it
+// rejects "" and "Local", whose meaning depends on the server.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("oncall: %q is not an IANA time zone", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("oncall: unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// Rezone moves s's rotations from the time zone named from to s.Timezone.
+// Each StartAt keeps its wall-clock time, so handoffs keep their time of
+// day in the new zone.
+func Rezone(s *model.OnCallSchedule, from string) error {
+	oldLoc, err := LoadLocation(from)
+	if err != nil {
+		return err
+	}
+	loc, err := LoadLocation(s.Timezone)
+	if err != nil {
+		return err
+	}
+	for i := range s.Rotations {
+		t := s.Rotations[i].StartAt.In(oldLoc)
+		s.Rotations[i].StartAt = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc).UTC()
+	}
+	return nil
+}
+
+// Resolve returns the shifts covering t. An active override wins over the
+// rotations; if several overlap, the newest one does. Otherwise the
+// rotations in the highest layer with someone on call at t each contribute
+// their current participant. A restricted rotation's shift is cut to the
+// window it is in. s must carry its rotations and the overrides that may
+// cover t.
+func Resolve(s *model.OnCallSchedule, t time.Time) ([]Shift, error) {
+	loc, err := LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(s, loc, t), nil
+}
+
+func resolve(s *model.OnCallSchedule, loc *time.Location, t time.Time) []Shift {
+	var override *model.Override
+	for i := range s.Overrides {
+		o := &s.Overrides[i]
+		if o.Active(t) && (override == nil || o.CreatedAt.After(override.CreatedAt)) {
+			override = o
+		}
+	}
+	if override != nil {
+		return []Shift{{
+			ID:         override.ID,
+			UserID:     override.UserID,
+			OverrideID: &override.ID,
+			Start:      override.StartAt,
+			End:        override.EndAt,
+		}}
+	}
+
+	var shifts []Shift
+	layer := 0
+	for i := range s.Rotations {
+		rot := &s.Rotations[i]
+		if len(shifts) > 0 && rot.Layer < layer {
+			continue
+		}
+		shift, ok := rotationShift(rot, loc, t)
+		if !ok {
+			continue
+		}
+		ws, we, ok := allowed(rot, loc, t)
+		if !ok {
+			continue
+		}
+		if !ws.IsZero() {
+			shift.Start = later(shift.Start, ws)
+			shift.End = earlier(shift.End, we)
+		}
+		if len(shifts) == 0 || rot.Layer > layer {
+			shifts, layer = shifts[:0], rot.Layer
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts
+}
+
+// rotationShift returns r's shift covering t. It reports false if r has no
+// participants or has not started by t.
+func rotationShift(r *model.Rotation, loc *time.Location, t time.Time) (Shift, bool) {
+	k, ok := shiftIndex(r, loc, t)
+	if !ok {
+		return Shift{}, false
+	}
+	return Shift{
+		ID:         fmt.Sprintf("%s:%d", r.ID, k),
+		UserID:     r.ParticipantIDs[k%len(r.ParticipantIDs)],
+		RotationID: &r.ID,
+		Start:      handoff(r, loc, k),
+		End:        handoff(r, loc, k+1),
+	}, true
+}
+
+// shiftIndex returns the index of r's shift covering t.
+func shiftIndex(r *model.Rotation, loc *time.Location, t time.Time) (int, bool) {
+	hours := r.ShiftHours()
+	if len(r.ParticipantIDs) == 0 || hours <= 0 || t.Before(r.StartAt) {
+		return 0, false
+	}
+	// Wall-clock shifts drift from elapsed time by at most the zone's
+	// offset changes, so the estimate is off by one shift at most.
+	k := int(t.Sub(r.StartAt) / (time.Duration(hours) * time.Hour))
+	for k > 0 && handoff(r, loc, k).After(t) {
+		k--
+	}
+	for !handoff(r, loc, k+1).After(t) {
+		k++
+	}
+	return k, true
+}
+
+// handoff returns the start of r's k-th shift: k shift lengths of wall-clock
+// time after StartAt in loc. time.Date normalises the hour overflow in
+// local time, so handoffs keep their time of day across DST changes.
+func handoff(r *model.Rotation, loc *time.Location, k int) time.Time {
+	s := r.StartAt.In(loc)
+	return time.Date(s.Year(), s.Month(), s.Day(), s.Hour()+k*r.ShiftHours(), s.Minute(), s.Second(), s.Nanosecond(), loc)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// Service loads schedules and resolves their on-call users.
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a Service.
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Get loads schedule id with its rotations, oldest first. Overrides are not
+// loaded.
+func (s *Service) Get(ctx context.Context, id string) (*model.OnCallSchedule, error) {
+	var sched model.OnCallSchedule
+	err := s.db.WithContext(ctx).
+		Preload("Rotations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Where("id = ?", id).First(&sched).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load schedule: %w", err)
+	}
+	return &sched, nil
+}
+
+// OnCall returns schedule id and the shifts covering t.
+func (s *Service) OnCall(ctx context.Context, id string, t time.Time) (*model.OnCallSchedule, []Shift, error) {
+	sched, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.db.WithContext(ctx).
+		Where("schedule_id = ? AND start_at <= ? AND end_at > ?", id, t, t).
+		Find(&sched.Overrides).Error; err != nil {
+		return nil, nil, fmt.Errorf("load overrides: %w", err)
+	}
+	shifts, err := Resolve(sched, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sched, shifts, nil
+}
+
+// Shifts returns schedule id with its final shifts and gaps between from
+// and to.
+func (s *Service) Shifts(ctx context.Context, id string, from, to time.Time) (*model.OnCallSchedule, []Shift, []Gap, error) {
+	sched, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := s.db.WithContext(ctx).
+		Where("schedule_id = ? AND start_at < ? AND end_at > ?", id, to, from).
+		Find(&sched.Overrides).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("load overrides: %w", err)
+	}
+	shifts, gaps, err := Shifts(sched, from, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return sched, shifts, gaps, nil
+}
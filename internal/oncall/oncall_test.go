@@ -0,0 +1,318 @@
+package oncall_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/oncall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func onCall(t *testing.T, s *model.OnCallSchedule, at time.Time) []oncall.Shift {
+	t.Helper()
+	shifts, err := oncall.Resolve(s, at)
+	require.NoError(t, err)
+	return shifts
+}
+
+func TestResolve_DailyRotation(t *testing.T) {
+	s := &model.OnCallSchedule{
+		Timezone: "UTC",
+		Rotations: []model.Rotation{{
+			ID:             "r1",
+			Type:           model.RotationDaily,
+			StartAt:        time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			ParticipantIDs: model.StringSlice{"alice", "bob", "carol"},
+		}},
+	}
+
+	assert.Empty(t, onCall(t, s, time.Date(2026, 1, 5, 8, 59, 0, 0, time.UTC)), "before the rotation starts")
+
+	shifts := onCall(t, s, time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "alice", shifts[0].UserID)
+	assert.Equal(t, time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC), shifts[0].End)
+
+	assert.Equal(t, "bob", onCall(t, s, time.Date(2026, 1, 6, 12, 0, 0, 0, time.UTC))[0].UserID)
+	assert.Equal(t, "carol", onCall(t, s, time.Date(2026, 1, 8, 8, 0, 0, 0, time.UTC))[0].UserID)
+	assert.Equal(t, "alice", onCall(t, s, time.Date(2026, 1, 8, 9, 0, 0, 0, time.UTC))[0].UserID, "wraps around")
+}
+
+func TestResolve_HandoffKeepsWallClockAcrossDST(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	s := &model.OnCallSchedule{
+		Timezone: "America/New_York",
+		Rotations: []model.Rotation{{
+			ID:             "r1",
+			Type:           model.RotationWeekly,
+			StartAt:        time.Date(2026, 3, 2, 9, 0, 0, 0, ny),
+			ParticipantIDs: model.StringSlice{"alice", "bob"},
+		}},
+	}
+
+	// Clocks spring forward on 8 March 2026; the handoff stays at 09:00 EDT.
+	shifts := onCall(t, s, time.Date(2026, 3, 9, 8, 59, 0, 0, ny))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "alice", shifts[0].UserID)
+	assert.Equal(t, time.Date(2026, 3, 9, 9, 0, 0, 0, ny), shifts[0].End)
+	assert.Equal(t, 7*24*time.Hour-time.Hour, shifts[0].End.Sub(shifts[0].Start), "the DST week is an hour short")
+	assert.Equal(t, "bob", onCall(t, s, time.Date(2026, 3, 9, 9, 0, 0, 0, ny))[0].UserID)
+
+	// Clocks fall back on 1 November 2026, during shift 34.
+	shifts = onCall(t, s, time.Date(2026, 11, 2, 8, 59, 0, 0, ny))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "alice", shifts[0].UserID)
+	assert.Equal(t, "r1:34", shifts[0].ID)
+	assert.Equal(t, 7*24*time.Hour+time.Hour, shifts[0].End.Sub(shifts[0].Start), "the DST week is an hour long")
+	assert.Equal(t, "bob", onCall(t, s, time.Date(2026, 11, 2, 9, 0, 0, 0, ny))[0].UserID)
+}
+
+func TestResolve_CustomRotationAcrossDST(t *testing.T) {
+	london := mustLoad(t, "Europe/London")
+	s := &model.OnCallSchedule{
+		Timezone: "Europe/London",
+		Rotations: []model.Rotation{{
+			ID:               "r1",
+			Type:             model.RotationCustom,
+			ShiftLengthHours: 12,
+			StartAt:          time.Date(2026, 3, 28, 8, 0, 0, 0, london),
+			ParticipantIDs:   model.StringSlice{"day", "night"},
+		}},
+	}
+
+	// BST starts at 01:00 on 29 March 2026: the night shift is 11 hours.
+	night := onCall(t, s, time.Date(2026, 3, 29, 3, 0, 0, 0, london))
+	require.Len(t, night, 1)
+	assert.Equal(t, "night", night[0].UserID)
+	assert.Equal(t, 11*time.Hour, night[0].End.Sub(night[0].Start))
+	assert.Equal(t, time.Date(2026, 3, 29, 8, 0, 0, 0, london), night[0].End)
+	assert.Equal(t, "day", onCall(t, s, time.Date(2026, 3, 29, 8, 0, 0, 0, london))[0].UserID)
+}
+
+func TestResolve_TimezoneInterpretsStartAt(t *testing.T) {
+	s := &model.OnCallSchedule{
+		Timezone: "Asia/Tokyo",
+		Rotations: []model.Rotation{{
+			ID:             "r1",
+			Type:           model.RotationDaily,
+			StartAt:        time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), // 09:00 JST
+			ParticipantIDs: model.StringSlice{"alice", "bob"},
+		}},
+	}
+	shifts := onCall(t, s, time.Date(2026, 6, 2, 1, 0, 0, 0, time.UTC))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "bob", shifts[0].UserID)
+	assert.Equal(t, time.Date(2026, 6, 2, 9, 0, 0, 0, mustLoad(t, "Asia/Tokyo")), shifts[0].Start)
+}
+
+func TestResolve_OverrideWins(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	s := &model.OnCallSchedule{
+		Timezone: "UTC",
+		Rotations: []model.Rotation{
+			{ID: "r1", Type: model.RotationDaily, StartAt: start, ParticipantIDs: model.StringSlice{"alice"}},
+			{ID: "r2", Type: model.RotationDaily, StartAt: start, ParticipantIDs: model.StringSlice{"bob"}},
+		},
+		Overrides: []model.Override{
+			{ID: "o1", UserID: "carol", StartAt: start.Add(time.Hour), EndAt: start.Add(3 * time.Hour), CreatedAt: start},
+			{ID: "o2", UserID: "dave", StartAt: start.Add(2 * time.Hour), EndAt: start.Add(4 * time.Hour), CreatedAt: start.Add(time.Minute)},
+		},
+	}
+
+	assert.Len(t, onCall(t, s, start), 2, "every rotation contributes")
+
+	shifts := onCall(t, s, start.Add(90*time.Minute))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "carol", shifts[0].UserID)
+	require.NotNil(t, shifts[0].OverrideID)
+	assert.Nil(t, shifts[0].RotationID)
+
+	assert.Equal(t, "dave", onCall(t, s, start.Add(150*time.Minute))[0].UserID, "newest override wins")
+	assert.Len(t, onCall(t, s, start.Add(4*time.Hour)), 2, "override has ended")
+}
+
+func TestRezone_KeepsHandoffWallClock(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	s := &model.OnCallSchedule{
+		Timezone: "UTC",
+		Rotations: []model.Rotation{{
+			ID:             "r1",
+			Type:           model.RotationDaily,
+			StartAt:        time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			ParticipantIDs: model.StringSlice{"alice", "bob"},
+		}},
+	}
+
+	s.Timezone = "Asia/Tokyo"
+	require.NoError(t, oncall.Rezone(s, "UTC"))
+	assert.Equal(t, time.Date(2026, 1, 5, 9, 0, 0, 0, tokyo).UTC(), s.Rotations[0].StartAt)
+	shifts := onCall(t, s, time.Date(2026, 1, 6, 12, 0, 0, 0, tokyo))
+	require.Len(t, shifts, 1)
+	assert.Equal(t, "bob", shifts[0].UserID)
+	assert.True(t, time.Date(2026, 1, 6, 9, 0, 0, 0, tokyo).Equal(shifts[0].Start), "hands off at 09:00 Tokyo time")
+
+	assert.Error(t, oncall.Rezone(s, "Mars/Olympus_Mons"))
+}
+
+func TestLoadLocation(t *testing.T) {
+	_, err := oncall.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		_, err := oncall.LoadLocation(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestService_OnCall(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	alice := model.User{Email: "alice@example.com"}
+	bob := model.User{Email: "bob@example.com"}
+	require.NoError(t, gormDB.Create(&alice).Error)
+	require.NoError(t, gormDB.Create(&bob).Error)
+
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	sched := model.OnCallSchedule{Name: "primary", Timezone: "Europe/Paris"}
+	require.NoError(t, gormDB.Create(&sched).Error)
+	require.NoError(t, gormDB.Create(&model.Rotation{
+		ScheduleID: sched.ID, Type: model.RotationDaily, StartAt: start, ParticipantIDs: model.StringSlice{alice.ID},
+	}).Error)
+	require.NoError(t, gormDB.Create(&model.Override{
+		ScheduleID: sched.ID, UserID: bob.ID, StartAt: start.Add(time.Hour), EndAt: start.Add(2 * time.Hour),
+	}).Error)
+
+	svc := oncall.NewService(gormDB)
+	_, shifts, err := svc.OnCall(ctx, sched.ID, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, alice.ID, shifts[0].UserID)
+
+	_, shifts, err = svc.OnCall(ctx, sched.ID, start.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, bob.ID, shifts[0].UserID)
+
+	_, _, err = svc.OnCall(ctx, "missing", start)
+	assert.ErrorIs(t, err, oncall.ErrNotFound)
+}
+
+// followTheSun hands off between three regional rotations in UTC, with a
+// weekday business-hours layer on top.
+func followTheSun() *model.OnCallSchedule {
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC) // a Monday
+	region := func(id, user, from, to string) model.Rotation {
+		return model.Rotation{
+			ID: id, Type: model.RotationDaily, StartAt: start, ParticipantIDs: model.StringSlice{user},
+			Restrictions: []model.TimeRestriction{{Start: from, End: to}},
+		}
+	}
+	return &model.OnCallSchedule{
+		Timezone: "UTC",
+		Rotations: []model.Rotation{
+			region("apac", "kenji", "00:00", "08:00"),
+			region("emea", "ingrid", "08:00", "16:00"),
+			region("amer", "maria", "16:00", "00:00"),
+			{
+				ID: "office", Type: model.RotationWeekly, StartAt: start, ParticipantIDs: model.StringSlice{"lead"}, Layer: 1,
+				Restrictions: []model.TimeRestriction{{Days: []string{"monday", "tuesday", "wednesday", "thursday", "friday"}, Start: "09:00", End: "17:00"}},
+			},
+		},
+	}
+}
+
+func TestResolve_LayersAndRestrictions(t *testing.T) {
+	s := followTheSun()
+	at := func(day, hour int) string {
+		shifts := onCall(t, s, time.Date(2026, 1, day, hour, 0, 0, 0, time.UTC))
+		require.Len(t, shifts, 1)
+		return shifts[0].UserID
+	}
+	assert.Equal(t, "kenji", at(6, 3))
+	assert.Equal(t, "ingrid", at(6, 8))
+	assert.Equal(t, "lead", at(6, 9), "the higher layer wins in office hours")
+	assert.Equal(t, "ingrid", at(10, 9), "office layer is off on Saturday")
+	assert.Equal(t, "maria", at(6, 17))
+	assert.Equal(t, "maria", at(6, 23))
+
+	shifts := onCall(t, s, time.Date(2026, 1, 6, 20, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 1, 6, 16, 0, 0, 0, time.UTC), shifts[0].Start)
+	assert.Equal(t, time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), shifts[0].End, "cut to the window")
+}
+
+func TestRestriction_OvernightWindow(t *testing.T) {
+	s := &model.OnCallSchedule{
+		Timezone: "UTC",
+		Rotations: []model.Rotation{{
+			ID: "r1", Type: model.RotationWeekly, StartAt: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			ParticipantIDs: model.StringSlice{"night"},
+			Restrictions:   []model.TimeRestriction{{Days: []string{"friday"}, Start: "22:00", End: "06:00"}},
+		}},
+	}
+	assert.Len(t, onCall(t, s, time.Date(2026, 1, 10, 5, 0, 0, 0, time.UTC)), 1, "Friday's window runs into Saturday")
+	assert.Empty(t, onCall(t, s, time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)), "not on Saturday night")
+}
+
+func TestValidateRestriction(t *testing.T) {
+	assert.NoError(t, oncall.ValidateRestriction(model.TimeRestriction{Days: []string{"monday"}, Start: "09:00", End: "17:30"}))
+	assert.Error(t, oncall.ValidateRestriction(model.TimeRestriction{Days: []string{"mon"}, Start: "09:00", End: "17:00"}))
+	assert.Error(t, oncall.ValidateRestriction(model.TimeRestriction{Start: "25:00", End: "17:00"}))
+	assert.Error(t, oncall.ValidateRestriction(model.TimeRestriction{Start: "09:00"}))
+}
+
+func TestShifts_FinalScheduleAndGaps(t *testing.T) {
+	s := followTheSun()
+	// Nobody covers EMEA on Tuesday morning before the office opens.
+	s.Rotations[1].Restrictions[0].Days = []string{"monday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+	from := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	shifts, gaps, err := oncall.Shifts(s, from, from.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	type span struct {
+		user       string
+		start, end int
+	}
+	var got []span
+	for _, sh := range shifts {
+		got = append(got, span{sh.UserID, sh.Start.Hour(), sh.End.Hour()})
+	}
+	assert.Equal(t, []span{
+		{"kenji", 0, 8},
+		{"lead", 9, 17},
+		{"maria", 17, 0},
+	}, got)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, from.Add(8*time.Hour), gaps[0].Start)
+	assert.Equal(t, from.Add(9*time.Hour), gaps[0].End)
+}
+
+func TestShifts_OverrideSplitsShift(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	s := &model.OnCallSchedule{
+		Timezone:  "UTC",
+		Rotations: []model.Rotation{{ID: "r1", Type: model.RotationDaily, StartAt: start, ParticipantIDs: model.StringSlice{"alice", "bob"}}},
+		Overrides: []model.Override{{ID: "o1", UserID: "carol", StartAt: start.Add(2 * time.Hour), EndAt: start.Add(3 * time.Hour)}},
+	}
+	shifts, gaps, err := oncall.Shifts(s, start.Add(-time.Hour), start.Add(50*time.Hour))
+	require.NoError(t, err)
+	var users []string
+	for _, sh := range shifts {
+		users = append(users, sh.UserID)
+	}
+	assert.Equal(t, []string{"alice", "carol", "alice", "bob", "alice"}, users)
+	assert.NotEqual(t, shifts[0].ID, shifts[2].ID, "each piece has its own ID")
+	assert.Equal(t, start.Add(3*time.Hour), shifts[2].Start)
+	require.Len(t, gaps, 1, "before the rotation starts")
+	assert.Equal(t, start, gaps[0].End)
+}
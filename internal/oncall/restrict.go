@@ -0,0 +1,88 @@
+package oncall
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// Weekdays are the day names accepted in model.TimeRestriction.Days, indexed
+// by time.Weekday.
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ValidateRestriction checks that r names known days and HH:MM times.
+func ValidateRestriction(r model.TimeRestriction) error {
+	for _, d := range r.Days {
+		if !slices.Contains(Weekdays, d) {
+			return fmt.Errorf("unknown day %q; use one of %s", d, strings.Join(Weekdays, ", "))
+		}
+	}
+	if _, err := clock(r.Start); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	if _, err := clock(r.End); err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	return nil
+}
+
+// clock parses an HH:MM time of day into minutes after midnight.
+func clock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an HH:MM time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// windowOn returns r's window that opens on the calendar day of day in loc,
+// and false if r does not apply on that weekday. r must be valid.
+func windowOn(r model.TimeRestriction, loc *time.Location, day time.Time) (start, end time.Time, ok bool) {
+	if len(r.Days) > 0 && !slices.Contains(r.Days, Weekdays[day.Weekday()]) {
+		return time.Time{}, time.Time{}, false
+	}
+	from, _ := clock(r.Start)
+	to, _ := clock(r.End)
+	if to <= from {
+		to += 24 * 60
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, from, 0, 0, loc), time.Date(y, m, d, 0, to, 0, 0, loc), true
+}
+
+// allowed reports whether rot's restrictions let it be on call at t, and
+// the window that allows it. A rotation without restrictions is allowed
+// everywhere, with zero window bounds.
+func allowed(rot *model.Rotation, loc *time.Location, t time.Time) (start, end time.Time, ok bool) {
+	if len(rot.Restrictions) == 0 {
+		return time.Time{}, time.Time{}, true
+	}
+	local := t.In(loc)
+	for _, r := range rot.Restrictions {
+		// A window that opened yesterday may still be open.
+		for _, day := range []time.Time{local.AddDate(0, 0, -1), local} {
+			ws, we, match := windowOn(r, loc, day)
+			if match && !t.Before(ws) && t.Before(we) && (!ok || we.After(end)) {
+				start, end, ok = ws, we, true
+			}
+		}
+	}
+	return start, end, ok
+}
+
+// restrictionEdges returns the opening and closing instants of rot's
+// windows that fall inside (from, to).
+func restrictionEdges(rot *model.Rotation, loc *time.Location, from, to time.Time) []time.Time {
+	var edges []time.Time
+	for day := from.In(loc).AddDate(0, 0, -1); !day.After(to.In(loc)); day = day.AddDate(0, 0, 1) {
+		for _, r := range rot.Restrictions {
+			if ws, we, ok := windowOn(r, loc, day); ok {
+				edges = append(edges, ws, we)
+			}
+		}
+	}
+	return slices.DeleteFunc(edges, func(t time.Time) bool { return !t.After(from) || !t.Before(to) })
+}
@@ -0,0 +1,96 @@
+package oncall
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// Gap is a period in which nobody is on call.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Shifts computes the final on-call shifts of s between from and to, after
+// layers, restrictions and overrides, cut to the range. Gaps lists the
+// periods with nobody on call. s must carry its rotations and the overrides
+// that overlap the range.
+func Shifts(s *model.OnCallSchedule, from, to time.Time) ([]Shift, []Gap, error) {
+	loc, err := LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Who is on call can only change at a handoff, a restriction edge or an
+	// override boundary, so resolving once per interval between them is
+	// exact.
+	bounds := []time.Time{from, to}
+	for i := range s.Rotations {
+		rot := &s.Rotations[i]
+		bounds = append(bounds, handoffs(rot, loc, from, to)...)
+		bounds = append(bounds, restrictionEdges(rot, loc, from, to)...)
+	}
+	for _, o := range s.Overrides {
+		bounds = append(bounds, o.StartAt, o.EndAt)
+	}
+	bounds = slices.DeleteFunc(bounds, func(t time.Time) bool { return t.Before(from) || t.After(to) })
+	slices.SortFunc(bounds, func(a, b time.Time) int { return a.Compare(b) })
+	bounds = slices.CompactFunc(bounds, time.Time.Equal)
+
+	var (
+		shifts []Shift
+		gaps   []Gap
+		// open maps a source shift ID to its entry in shifts while it
+		// continues into the next interval.
+		open = map[string]int{}
+	)
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		current := resolve(s, loc, start)
+		next := map[string]int{}
+		for _, sh := range current {
+			if j, ok := open[sh.ID]; ok {
+				shifts[j].End = end
+				next[sh.ID] = j
+				continue
+			}
+			source := sh.ID
+			sh.ID = fmt.Sprintf("%s@%d", source, start.Unix())
+			sh.Start, sh.End = start, end
+			next[source] = len(shifts)
+			shifts = append(shifts, sh)
+		}
+		open = next
+		if len(current) == 0 {
+			if n := len(gaps); n > 0 && gaps[n-1].End.Equal(start) {
+				gaps[n-1].End = end
+			} else {
+				gaps = append(gaps, Gap{Start: start, End: end})
+			}
+		}
+	}
+	return shifts, gaps, nil
+}
+
+// handoffs returns rot's shift boundaries inside (from, to), including its
+// first shift's start.
+func handoffs(rot *model.Rotation, loc *time.Location, from, to time.Time) []time.Time {
+	var out []time.Time
+	at := from
+	if rot.StartAt.After(from) {
+		at = rot.StartAt
+		out = append(out, at)
+	}
+	k, ok := shiftIndex(rot, loc, at)
+	if !ok {
+		return out
+	}
+	for h := handoff(rot, loc, k+1); h.Before(to); h = handoff(rot, loc, k+1) {
+		out = append(out, h)
+		k++
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+// Package postmortem finds past postmortems relevant to an alert using the
+// database's native full-text search.
+package postmortem
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"gorm.io/gorm"
+)
+
+// maxQueryTerms caps the number of search terms taken from an alert.
+const maxQueryTerms = 32
+
+var termPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Searcher ranks published postmortems against free text. On Postgres it
+// queries the GIN-indexed search_vector column and ranks with ts_rank; on
+// SQLite it queries the postmortems_fts FTS5 table and ranks with the
+// negated bm25 rank, so that higher is better on both. Neither rank is
+// bounded, so both are mapped to a score in [0, 1) by rank/(1+rank), which
+// keeps their order.
+type Searcher struct {
+	db        *gorm.DB
+	limit     int
+	threshold float64
+}
+
+// NewSearcher creates a Searcher returning at most limit matches that score
+// at least threshold. A limit of zero or less disables search.
+func NewSearcher(db *gorm.DB, limit int, threshold float64) *Searcher {
+	return &Searcher{db: db, limit: limit, threshold: threshold}
+}
+
+// Search returns the best matches for text, best first. Postmortems match if
+// they contain any of the words in text.
+func (s *Searcher) Search(ctx context.Context, text string) ([]model.RelatedPostmortem, error) {
+	terms := queryTerms(text)
+	if s.limit <= 0 || len(terms) == 0 {
+		return nil, nil
+	}
+
+	var (
+		matches []model.RelatedPostmortem
+		err     error
+	)
+	db := s.db.WithContext(ctx)
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		err = db.Raw(`
+			SELECT p.id, p.title, p.root_cause, ts_rank(p.search_vector, q.query) AS score
+			FROM postmortems p, to_tsquery('english', ?) AS q(query)
+			WHERE p.search_vector @@ q.query AND p.status = ?
+			ORDER BY score DESC, p.id
+			LIMIT ?`,
+			strings.Join(terms, " | "), model.PostmortemStatusPublished, s.limit,
+		).Scan(&matches).Error
+	case "sqlite":
+		err = db.Raw(`
+			SELECT p.id, p.title, p.root_cause, -bm25(postmortems_fts) AS score
+			FROM postmortems_fts
+			JOIN postmortems p ON p.id = postmortems_fts.id
+			WHERE postmortems_fts MATCH ? AND p.status = ?
+			ORDER BY score DESC, p.id
+			LIMIT ?`,
+			ftsQuery(terms), model.PostmortemStatusPublished, s.limit,
+		).Scan(&matches).Error
+	default:
+		return nil, fmt.Errorf("postmortem search: unsupported database %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("postmortem search: %w", err)
+	}
+
+	for i := range matches {
+		matches[i].Score = normalize(matches[i].Score)
+	}
+	// Matches are sorted by score, so those under the threshold are a suffix.
+	n := sort.Search(len(matches), func(i int) bool { return matches[i].Score < s.threshold })
+	return matches[:n], nil
+}
+
+// normalize maps a non-negative rank to [0, 1). bm25 rates terms found in
+// most documents below zero; those count as no match.
+func normalize(rank float64) float64 {
+	rank = max(rank, 0)
+	return rank / (1 + rank)
+}
+
+// QueryText returns the search text for an alert: its title and label
+// values.
+func QueryText(a *model.Alert) string {
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := append(make([]string, 0, len(keys)+1), a.Title)
+	for _, k := range keys {
+		parts = append(parts, a.Labels[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+// queryTerms splits text into distinct lowercase words, dropping one-letter
+// words. Only letters and digits survive, so the terms are safe to embed in
+// tsquery and FTS5 query syntax.
+func queryTerms(text string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, t := range termPattern.FindAllString(strings.ToLower(text), -1) {
+		if len([]rune(t)) < 2 || seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+		if len(terms) == maxQueryTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// ftsQuery ORs the terms together in FTS5 query syntax. Quoting keeps words
+// such as "and" or "near" from being read as operators.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = `"` + t + `"`
+	}
+	return strings.Join(quoted, " OR ")
+}
@@ -0,0 +1,97 @@
+package postmortem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/postmortem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func seed(t *testing.T) *gorm.DB {
+	t.Helper()
+	gormDB := dbtest.New(t)
+	for _, p := range []model.Postmortem{
+		{Title: "Checkout outage", RootCause: "Database connection pool exhausted after deploy", Status: model.PostmortemStatusPublished},
+		{Title: "Search latency spike", RootCause: "Elasticsearch garbage collection pauses", Status: model.PostmortemStatusPublished},
+		{Title: "Login failures", RootCause: "Expired TLS certificate on auth service", Status: model.PostmortemStatusPublished},
+		{Title: "Payments database failover", RootCause: "Primary database disk full", Status: model.PostmortemStatusPublished},
+		{Title: "Draft: database connection leak", RootCause: "connection pool", Status: model.PostmortemStatusDraft},
+	} {
+		require.NoError(t, gormDB.Create(&p).Error)
+	}
+	return gormDB
+}
+
+func titles(ms []model.RelatedPostmortem) []string {
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.Title
+	}
+	return out
+}
+
+func TestSearch_RanksPublishedMatches(t *testing.T) {
+	s := postmortem.NewSearcher(seed(t), 5, 0.05)
+
+	got, err := s.Search(context.Background(), "Checkout: database connection pool saturated")
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	assert.Equal(t, "Checkout outage", got[0].Title, "more matching terms rank higher")
+	assert.NotContains(t, titles(got), "Draft: database connection leak", "drafts are not searched")
+	assert.NotContains(t, titles(got), "Login failures")
+	for i := 1; i < len(got); i++ {
+		assert.GreaterOrEqual(t, got[i-1].Score, got[i].Score)
+	}
+	for _, m := range got {
+		assert.True(t, m.Score >= 0.05 && m.Score < 1, "score %v of %q is in [threshold, 1)", m.Score, m.Title)
+	}
+}
+
+func TestSearch_LimitAndThreshold(t *testing.T) {
+	gormDB := seed(t)
+
+	got, err := postmortem.NewSearcher(gormDB, 1, 0).Search(context.Background(), "database")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	got, err = postmortem.NewSearcher(gormDB, 5, 1).Search(context.Background(), "database")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	got, err = postmortem.NewSearcher(gormDB, 0, 0).Search(context.Background(), "database")
+	require.NoError(t, err)
+	assert.Empty(t, got, "limit 0 disables search")
+}
+
+func TestSearch_IndexFollowsUpdates(t *testing.T) {
+	gormDB := seed(t)
+	s := postmortem.NewSearcher(gormDB, 5, 0)
+
+	require.NoError(t, gormDB.Model(&model.Postmortem{}).
+		Where("title = ?", "Login failures").
+		Update("root_cause", "Kafka consumer lag").Error)
+	got, err := s.Search(context.Background(), "kafka")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Login failures"}, titles(got))
+
+	require.NoError(t, gormDB.Where("title = ?", "Login failures").Delete(&model.Postmortem{}).Error)
+	got, err = s.Search(context.Background(), "kafka")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestSearch_QuerySyntaxIsInert(t *testing.T) {
+	got, err := postmortem.NewSearcher(seed(t), 5, 0).Search(context.Background(), `disk AND "NEAR(" OR * -- ' ;`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Payments database failover"}, titles(got))
+}
+
+func TestQueryText(t *testing.T) {
+	a := &model.Alert{Title: "CPU high", Labels: model.Labels{"service": "api", "env": "prod"}}
+	assert.Equal(t, "CPU high prod api", postmortem.QueryText(a))
+}
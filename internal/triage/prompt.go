@@ -0,0 +1,164 @@
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// systemPrompt fixes the answer format the response parser expects.
+const systemPrompt = `You are an experienced site reliability engineer triaging production alerts.
+Assess the alert you are given and answer with a single JSON object with exactly these fields:
+  "severity": one of "SEV1" (critical, customer-facing outage), "SEV2" (major degradation),
+              "SEV3" (minor or partial impact), "SEV4" (no user impact, informational);
+  "summary": one or two sentences describing what is happening;
+  "probable_cause": the most likely cause given the evidence;
+  "suggested_actions": an array of short, concrete next steps for the on-call engineer;
+  "confidence_score": your confidence in this assessment, a number between 0 and 1.`
+
+// DefaultPromptTemplate renders the user message for an alert. It is a
+// text/template executed against PromptData.
+const DefaultPromptTemplate = `Alert: {{.Title}}
+Source: {{.Source}}
+Status: {{.Status}}
+{{- if .SeverityHint}}
+Severity reported by source: {{.SeverityHint}}{{end}}
+Occurred at: {{.OccurredAt}}
+{{- if gt .OccurrenceCount 1}}
+Occurrences: {{.OccurrenceCount}}{{end}}
+{{- if .Description}}
+
+Description:
+{{.Description}}{{end}}
+{{- if .Labels}}
+
+Labels:
+{{.Labels}}{{end}}
+{{- if .Annotations}}
+
+Annotations:
+{{.Annotations}}{{end}}
+{{- if .RelatedPostmortems}}
+
+Postmortems of similar past incidents:
+{{- range .RelatedPostmortems}}
+- {{.Title}}: {{.RootCause}}{{end}}{{end}}
+`
+
+var defaultTemplate = template.Must(newTemplate(DefaultPromptTemplate))
+
+// ErrPromptTemplate marks prompt templates that fail to parse or render.
+var ErrPromptTemplate = errors.New("invalid prompt template")
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("triage").Option("missingkey=error").Parse(text)
+}
+
+// ParseTemplate parses a prompt template and checks that it renders for
+// SampleAlert, so references to unknown fields are caught when the template
+// is saved rather than when an alert arrives. Empty text yields the built-in
+// template.
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return defaultTemplate, nil
+	}
+	tmpl, err := newTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPromptTemplate, err)
+	}
+	sample := SampleAlert()
+	if err := tmpl.Execute(io.Discard, promptData(&sample, sampleRelated)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPromptTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// SampleAlert returns the alert prompt previews render when none is given.
+func SampleAlert() model.Alert {
+	return model.Alert{
+		Title:           "High error rate on checkout-api",
+		Description:     "5xx responses above 5% for 10 minutes.",
+		Source:          "sample",
+		Status:          model.AlertStatusFiring,
+		SeverityHint:    "critical",
+		OccurrenceCount: 1,
+		OccurredAt:      time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Labels:          model.Labels{"service": "checkout-api", "env": "production"},
+		Annotations:     model.Labels{"runbook_url": "https://runbooks.example.com/checkout-5xx"},
+	}
+}
+
+// PromptData is the value prompt templates are executed against. Labels and
+// Annotations are pre-rendered as sorted "key=value" lines.
+// RelatedPostmortems holds published postmortems found by full-text search.
+type PromptData struct {
+	Title              string
+	Description        string
+	Source             string
+	Status             string
+	SeverityHint       string
+	OccurredAt         string
+	OccurrenceCount    int
+	Labels             string
+	Annotations        string
+	LabelMap           map[string]string
+	AnnotationMap      map[string]string
+	RelatedPostmortems []model.RelatedPostmortem
+}
+
+func promptData(a *model.Alert, related []model.RelatedPostmortem) PromptData {
+	return PromptData{
+		Title:              a.Title,
+		Description:        a.Description,
+		Source:             a.Source,
+		Status:             a.Status,
+		SeverityHint:       a.SeverityHint,
+		OccurredAt:         a.OccurredAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+		OccurrenceCount:    a.OccurrenceCount,
+		Labels:             keyValueLines(a.Labels),
+		Annotations:        keyValueLines(a.Annotations),
+		LabelMap:           a.Labels,
+		AnnotationMap:      a.Annotations,
+		RelatedPostmortems: related,
+	}
+}
+
+// sampleRelated lets ParseTemplate exercise templates that range over
+// RelatedPostmortems.
+var sampleRelated = []model.RelatedPostmortem{{
+	ID:        "00000000-0000-0000-0000-000000000000",
+	Title:     "Checkout outage",
+	RootCause: "Database connection pool exhausted after a deploy.",
+	Score:     0.5,
+}}
+
+// renderPrompt executes tmpl for a.
+func renderPrompt(tmpl *template.Template, a *model.Alert, related []model.RelatedPostmortem) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, promptData(a, related)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPromptTemplate, err)
+	}
+	return b.String(), nil
+}
+
+func keyValueLines(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(k + "=" + m[k])
+	}
+	return b.String()
+}
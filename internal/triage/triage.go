@@ -0,0 +1,273 @@
+// Package triage asks the configured AI provider to assess incoming alerts
+// and records the outcome as model.TriageResult rows.
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ai"
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/postmortem"
+	"gorm.io/gorm"
+)
+
+// ErrAlertNotFound is returned by Run when the alert no longer exists.
+var ErrAlertNotFound = errors.New("triage: alert not found")
+
+// Service runs AI triage for alerts.
+type Service struct {
+	db          *gorm.DB
+	provider    ai.AIProvider
+	cfg         config.AIConfig
+	postmortems *postmortem.Searcher
+}
+
+// NewService creates a Service that sends alerts to provider. cfg supplies
+// the model and token budget for sources that do not override them, and the
+// limits for related-postmortem search.
+func NewService(db *gorm.DB, provider ai.AIProvider, cfg config.AIConfig) *Service {
+	return &Service{
+		db:          db,
+		provider:    provider,
+		cfg:         cfg,
+		postmortems: postmortem.NewSearcher(db, cfg.PostmortemFTSLimit, cfg.PostmortemFTSThreshold),
+	}
+}
+
+// Prompt is a fully resolved triage request for one alert. An empty Model
+// means the provider's default model.
+type Prompt struct {
+	System    string
+	User      string
+	Model     string
+	MaxTokens int
+	// Related are the postmortems included in User.
+	Related []model.RelatedPostmortem
+}
+
+// BuildPrompt renders the triage prompt for a using src's prompt template,
+// model and token budget, falling back to the built-in template and the
+// global AI configuration. src may be nil. Published postmortems matching
+// the alert are included; a failed search is logged and leaves them out
+// rather than failing the prompt.
+func (s *Service) BuildPrompt(ctx context.Context, src *model.WebhookSource, a *model.Alert) (Prompt, error) {
+	p := Prompt{System: systemPrompt, Model: s.cfg.Model, MaxTokens: s.cfg.MaxTokens}
+	tmpl := defaultTemplate
+	if src != nil {
+		if src.PromptTemplate != "" {
+			var err error
+			if tmpl, err = ParseTemplate(src.PromptTemplate); err != nil {
+				return p, err
+			}
+		}
+		if src.AIModel != "" {
+			p.Model = src.AIModel
+		}
+		if src.AIMaxTokens > 0 {
+			p.MaxTokens = src.AIMaxTokens
+		}
+	}
+
+	related, err := s.postmortems.Search(ctx, postmortem.QueryText(a))
+	if err != nil {
+		slog.WarnContext(ctx, "related postmortem search failed", "alert_id", a.ID, "err", err)
+	}
+	p.Related = related
+
+	user, err := renderPrompt(tmpl, a, related)
+	if err != nil {
+		return p, err
+	}
+	p.User = user
+	return p, nil
+}
+
+// Run triages the alert with the given ID as job attempt number attempt and
+// stores the result, including the exact prompt, the raw answer, the model
+// and the token usage, whether or not the answer could be parsed. final reports whether no further attempt will follow;
+// the alert's triage status only becomes failed on the final attempt or on
+// an error that Retryable rejects.
+//
+// A completed result at or above the source's AutoDeclareSeverity declares
+// an incident for the alert, or attaches it to the one already open for its
+// fingerprint, in the same transaction as the result.
+//
+// Suppressed alerts, and every alert while the provider is disabled, are
+// marked skipped without calling the provider; Run then returns a nil result
+// and no error.
+func (s *Service) Run(ctx context.Context, alertID string, attempt int, final bool) (*model.TriageResult, error) {
+	var alert model.Alert
+	err := s.db.WithContext(ctx).Where("id = ?", alertID).First(&alert).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrAlertNotFound
+	case err != nil:
+		return nil, fmt.Errorf("load alert: %w", err)
+	}
+	if alert.Suppressed {
+		return nil, s.setAlertStatus(ctx, alert.ID, model.TriageStatusSkipped)
+	}
+
+	result := model.TriageResult{
+		OrganizationID:     alert.OrganizationID,
+		AlertID:            alert.ID,
+		Attempt:            attempt,
+		Provider:           s.provider.Name(),
+		SuggestedActions:   []string{},
+		SystemPrompt:       systemPrompt,
+		RelatedPostmortems: []model.RelatedPostmortem{},
+	}
+
+	src, err := s.loadSource(ctx, alert.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := s.BuildPrompt(ctx, src, &alert)
+	if err == nil {
+		result.Prompt = prompt.User
+		result.Model = prompt.Model
+		if prompt.Related != nil {
+			result.RelatedPostmortems = prompt.Related
+		}
+		started := time.Now()
+		var resp ai.Response
+		resp, err = s.provider.Complete(ctx, ai.Request{
+			System:    prompt.System,
+			Messages:  []ai.Message{{Role: "user", Content: prompt.User}},
+			Model:     prompt.Model,
+			MaxTokens: prompt.MaxTokens,
+			JSON:      true,
+		})
+		if errors.Is(err, ai.ErrDisabled) {
+			return nil, s.setAlertStatus(ctx, alert.ID, model.TriageStatusSkipped)
+		}
+		result.LatencyMS = time.Since(started).Milliseconds()
+		if err == nil {
+			result.Response = resp.Content
+			if resp.Model != "" {
+				result.Model = resp.Model
+			}
+			result.InputTokens = resp.InputTokens
+			result.OutputTokens = resp.OutputTokens
+			err = applyVerdict(ctx, &result, resp.Content)
+		}
+	}
+
+	status := model.TriageStatusCompleted
+	if err != nil {
+		result.Status = model.TriageStatusFailed
+		result.Error = err.Error()
+		status = model.TriageStatusPending
+		if final || !Retryable(err) {
+			status = model.TriageStatusFailed
+		}
+	} else {
+		result.Status = model.TriageStatusCompleted
+	}
+
+	declare := err == nil && autoDeclares(src, &result)
+	if txErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&result).Error; err != nil {
+			return fmt.Errorf("store triage result: %w", err)
+		}
+		if err := tx.Model(&model.Alert{}).Where("id = ?", alert.ID).
+			Update("triage_status", status).Error; err != nil {
+			return err
+		}
+		if declare {
+			return s.openIncident(ctx, tx, &alert, &result)
+		}
+		return nil
+	}); txErr != nil {
+		return nil, txErr
+	}
+	if err != nil {
+		return &result, fmt.Errorf("triage alert %s: %w", alert.ID, err)
+	}
+	return &result, nil
+}
+
+// autoDeclares reports whether result is severe enough for src to declare an
+// incident.
+func autoDeclares(src *model.WebhookSource, result *model.TriageResult) bool {
+	return src != nil && src.AutoDeclareSeverity != "" &&
+		model.SeverityAtLeast(result.Severity, src.AutoDeclareSeverity)
+}
+
+// openIncident declares an incident for the alert, or attaches it to the
+// open incident for its fingerprint.
+func (s *Service) openIncident(ctx context.Context, tx *gorm.DB, a *model.Alert, result *model.TriageResult) error {
+	inc, declared, err := incident.OpenForAlert(tx, a, result)
+	if err != nil || inc == nil {
+		return err
+	}
+	if declared {
+		slog.InfoContext(ctx, "incident declared from alert",
+			"incident_id", inc.ID, "alert_id", a.ID, "ai_severity", result.Severity)
+	} else {
+		slog.InfoContext(ctx, "alert attached to open incident",
+			"incident_id", inc.ID, "alert_id", a.ID)
+	}
+	return nil
+}
+
+// applyVerdict parses the model answer into result.
+func applyVerdict(ctx context.Context, result *model.TriageResult, content string) error {
+	v, validSeverity, err := parseVerdict(content)
+	if err != nil {
+		return err
+	}
+	if !validSeverity {
+		slog.WarnContext(ctx, "ai triage returned an invalid severity; using SEV4",
+			"alert_id", result.AlertID, "provider", result.Provider)
+	}
+	result.Severity = v.Severity
+	result.Summary = v.Summary
+	result.ProbableCause = v.ProbableCause
+	result.SuggestedActions = v.SuggestedActions
+	result.ConfidenceScore = v.ConfidenceScore
+	return nil
+}
+
+// loadSource returns the alert's webhook source, or nil if it was deleted.
+func (s *Service) loadSource(ctx context.Context, id string) (*model.WebhookSource, error) {
+	var src model.WebhookSource
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&src).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("load webhook source: %w", err)
+	}
+	return &src, nil
+}
+
+func (s *Service) setAlertStatus(ctx context.Context, alertID, status string) error {
+	if err := s.db.WithContext(ctx).Model(&model.Alert{}).
+		Where("id = ?", alertID).
+		Update("triage_status", status).Error; err != nil {
+		return fmt.Errorf("update triage status: %w", err)
+	}
+	return nil
+}
+
+// Retryable reports whether a Run error may clear up on a later attempt.
+// Missing alerts, broken prompt templates and provider errors such as bad
+// credentials or an unknown model are permanent; timeouts, rate limits, server errors and unparseable
+// answers are worth retrying.
+func Retryable(err error) bool {
+	if errors.Is(err, ErrAlertNotFound) || errors.Is(err, ErrPromptTemplate) {
+		return false
+	}
+	var apiErr *ai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return true
+}
@@ -0,0 +1,340 @@
+package triage_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/ai"
+	"github.com/d9705996/autopsy/internal/config"
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/incident"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/triage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newService(gormDB *gorm.DB, provider ai.AIProvider) *triage.Service {
+	return triage.NewService(gormDB, provider, config.AIConfig{
+		Model:                  "global-model",
+		MaxTokens:              512,
+		PostmortemFTSLimit:     5,
+		PostmortemFTSThreshold: 0.05,
+	})
+}
+
+func newAlert(t *testing.T, gormDB *gorm.DB, suppressed bool) *model.Alert {
+	t.Helper()
+	src := &model.WebhookSource{Name: "src-" + time.Now().Format("150405.000000"), HMACSecret: "s", Enabled: true}
+	require.NoError(t, gormDB.Create(src).Error)
+	pending := model.TriageStatusPending
+	a := &model.Alert{
+		SourceID:     src.ID,
+		Source:       src.Name,
+		Fingerprint:  "fp",
+		Title:        "Checkout latency p99 > 2s",
+		Labels:       model.Labels{"service": "checkout", "env": "prod"},
+		Status:       model.AlertStatusFiring,
+		OccurredAt:   time.Now(),
+		ReceivedAt:   time.Now(),
+		LastSeenAt:   time.Now(),
+		Suppressed:   suppressed,
+		TriageStatus: &pending,
+	}
+	require.NoError(t, gormDB.Create(a).Error)
+	return a
+}
+
+func alertTriageStatus(t *testing.T, gormDB *gorm.DB, id string) string {
+	t.Helper()
+	var a model.Alert
+	require.NoError(t, gormDB.First(&a, "id = ?", id).Error)
+	require.NotNil(t, a.TriageStatus)
+	return *a.TriageStatus
+}
+
+func TestRun_Completed(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+	fake := ai.NewFake(ai.FakeReply{Content: "```json\n" + `{
+		"severity": "sev2",
+		"summary": "Checkout is slow for all users.",
+		"probable_cause": "Database connection pool exhaustion.",
+		"suggested_actions": ["Check pool metrics", "Scale the database"],
+		"confidence_score": 0.8
+	}` + "\n```"})
+
+	res, err := newService(gormDB, fake).Run(context.Background(), alert.ID, 1, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, model.TriageStatusCompleted, res.Status)
+	assert.Equal(t, model.SeveritySEV2, res.Severity)
+	assert.Equal(t, "Checkout is slow for all users.", res.Summary)
+	assert.Equal(t, "Database connection pool exhaustion.", res.ProbableCause)
+	assert.Equal(t, []string{"Check pool metrics", "Scale the database"}, res.SuggestedActions)
+	assert.InDelta(t, 0.8, res.ConfidenceScore, 1e-9)
+	assert.Equal(t, model.TriageStatusCompleted, alertTriageStatus(t, gormDB, alert.ID))
+
+	var stored model.TriageResult
+	require.NoError(t, gormDB.First(&stored, "alert_id = ?", alert.ID).Error)
+	assert.Equal(t, res.SuggestedActions, stored.SuggestedActions)
+
+	reqs := fake.Requests()
+	require.Len(t, reqs, 1)
+	assert.True(t, reqs[0].JSON)
+	assert.Contains(t, reqs[0].Messages[0].Content, "Checkout latency p99 > 2s")
+	assert.Contains(t, reqs[0].Messages[0].Content, "env=prod\nservice=checkout")
+
+	// The exchange is stored verbatim.
+	assert.Equal(t, reqs[0].System, stored.SystemPrompt)
+	assert.Equal(t, reqs[0].Messages[0].Content, stored.Prompt)
+	assert.Contains(t, stored.Response, "```json")
+	assert.Equal(t, "fake", stored.Model)
+	assert.GreaterOrEqual(t, stored.LatencyMS, int64(0))
+}
+
+func TestRun_UnparseableAnswerIsStored(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+
+	res, err := newService(gormDB, ai.NewFake(ai.FakeReply{Content: "I think it is bad."})).
+		Run(context.Background(), alert.ID, 1, false)
+	require.Error(t, err)
+	assert.Equal(t, model.TriageStatusFailed, res.Status)
+	assert.Equal(t, "I think it is bad.", res.Response)
+	assert.NotEmpty(t, res.Prompt)
+}
+
+func TestRun_InvalidSeverityDefaultsToSEV4(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+	fake := ai.NewFake(ai.FakeReply{Content: `{"severity":"catastrophic","summary":"x","confidence_score":7}`})
+
+	res, err := newService(gormDB, fake).Run(context.Background(), alert.ID, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, model.SeveritySEV4, res.Severity)
+	assert.Equal(t, 1.0, res.ConfidenceScore)
+	assert.Equal(t, []string{}, res.SuggestedActions)
+}
+
+func TestRun_FailureIsRetriedUntilFinal(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+	unavailable := &ai.APIError{Provider: "fake", StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+	svc := newService(gormDB, ai.NewFake(
+		ai.FakeReply{Err: unavailable},
+		ai.FakeReply{Content: "not json"},
+		ai.FakeReply{Err: unavailable},
+	))
+
+	_, err := svc.Run(context.Background(), alert.ID, 1, false)
+	require.Error(t, err)
+	assert.True(t, triage.Retryable(err))
+	assert.Equal(t, model.TriageStatusPending, alertTriageStatus(t, gormDB, alert.ID))
+
+	_, err = svc.Run(context.Background(), alert.ID, 2, false)
+	require.Error(t, err)
+	assert.True(t, triage.Retryable(err), "unparseable answers are retried")
+
+	res, err := svc.Run(context.Background(), alert.ID, 3, true)
+	require.Error(t, err)
+	assert.Equal(t, model.TriageStatusFailed, res.Status)
+	assert.Contains(t, res.Error, "overloaded")
+	assert.Equal(t, model.TriageStatusFailed, alertTriageStatus(t, gormDB, alert.ID))
+
+	var runs int64
+	gormDB.Model(&model.TriageResult{}).Where("alert_id = ?", alert.ID).Count(&runs)
+	assert.EqualValues(t, 3, runs)
+}
+
+func TestRun_PermanentProviderError(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+	svc := newService(gormDB, ai.NewFake(ai.FakeReply{
+		Err: &ai.APIError{Provider: "fake", StatusCode: http.StatusUnauthorized, Message: "bad key"},
+	}))
+
+	_, err := svc.Run(context.Background(), alert.ID, 1, false)
+	require.Error(t, err)
+	assert.False(t, triage.Retryable(err))
+	assert.Equal(t, model.TriageStatusFailed, alertTriageStatus(t, gormDB, alert.ID))
+}
+
+func TestRun_Skipped(t *testing.T) {
+	gormDB := dbtest.New(t)
+
+	suppressed := newAlert(t, gormDB, true)
+	fake := ai.NewFake()
+	res, err := newService(gormDB, fake).Run(context.Background(), suppressed.ID, 1, false)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Empty(t, fake.Requests(), "suppressed alerts are never sent to the provider")
+	assert.Equal(t, model.TriageStatusSkipped, alertTriageStatus(t, gormDB, suppressed.ID))
+
+	alert := newAlert(t, gormDB, false)
+	res, err = newService(gormDB, ai.Noop{}).Run(context.Background(), alert.ID, 1, false)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.Equal(t, model.TriageStatusSkipped, alertTriageStatus(t, gormDB, alert.ID))
+}
+
+func TestRun_AlertNotFound(t *testing.T) {
+	_, err := newService(dbtest.New(t), ai.NewFake()).Run(context.Background(), "missing", 1, false)
+	assert.True(t, errors.Is(err, triage.ErrAlertNotFound))
+	assert.False(t, triage.Retryable(err))
+}
+
+func TestRun_SourceOverrides(t *testing.T) {
+	gormDB := dbtest.New(t)
+	alert := newAlert(t, gormDB, false)
+	require.NoError(t, gormDB.Model(&model.WebhookSource{}).Where("id = ?", alert.SourceID).Updates(map[string]any{
+		"prompt_template": "DBA view of {{.Title}} on {{index .LabelMap \"service\"}}",
+		"ai_model":        "dba-model",
+		"ai_max_tokens":   2048,
+	}).Error)
+	fake := ai.NewFake(ai.FakeReply{Content: `{"severity":"SEV3","summary":"ok"}`})
+
+	_, err := newService(gormDB, fake).Run(context.Background(), alert.ID, 1, false)
+	require.NoError(t, err)
+
+	req := fake.Requests()[0]
+	assert.Equal(t, "DBA view of Checkout latency p99 > 2s on checkout", req.Messages[0].Content)
+	assert.Equal(t, "dba-model", req.Model)
+	assert.Equal(t, 2048, req.MaxTokens)
+}
+
+func TestBuildPrompt_GlobalFallback(t *testing.T) {
+	sample := triage.SampleAlert()
+	p, err := newService(dbtest.New(t), ai.NewFake()).BuildPrompt(context.Background(), &model.WebhookSource{}, &sample)
+	require.NoError(t, err)
+	assert.Equal(t, "global-model", p.Model)
+	assert.Equal(t, 512, p.MaxTokens)
+	assert.Contains(t, p.User, "Alert: High error rate on checkout-api")
+	assert.Contains(t, p.User, "env=production\nservice=checkout-api")
+	assert.NotEmpty(t, p.System)
+}
+
+func TestParseTemplate(t *testing.T) {
+	_, err := triage.ParseTemplate("")
+	require.NoError(t, err)
+	_, err = triage.ParseTemplate("{{.Title}} {{range $k, $v := .LabelMap}}{{$k}}{{end}}")
+	require.NoError(t, err)
+
+	_, err = triage.ParseTemplate("{{.Title")
+	assert.ErrorIs(t, err, triage.ErrPromptTemplate)
+	_, err = triage.ParseTemplate("{{.NoSuchField}}")
+	assert.ErrorIs(t, err, triage.ErrPromptTemplate, "unknown fields are caught at parse time")
+}
+
+func TestRun_RelatedPostmortems(t *testing.T) {
+	gormDB := dbtest.New(t)
+	for _, p := range []model.Postmortem{
+		{Title: "Checkout latency incident", RootCause: "Slow queries after index drop", Status: model.PostmortemStatusPublished},
+		{Title: "Login outage", RootCause: "Expired certificate", Status: model.PostmortemStatusPublished},
+		{Title: "Unrelated", RootCause: "Kafka lag", Status: model.PostmortemStatusPublished},
+	} {
+		require.NoError(t, gormDB.Create(&p).Error)
+	}
+	alert := newAlert(t, gormDB, false)
+	fake := ai.NewFake(ai.FakeReply{Content: `{"severity":"SEV2","summary":"slow"}`})
+
+	res, err := newService(gormDB, fake).Run(context.Background(), alert.ID, 1, false)
+	require.NoError(t, err)
+
+	require.Len(t, res.RelatedPostmortems, 1)
+	assert.Equal(t, "Checkout latency incident", res.RelatedPostmortems[0].Title)
+	assert.Contains(t, fake.Requests()[0].Messages[0].Content,
+		"Postmortems of similar past incidents:\n- Checkout latency incident: Slow queries after index drop")
+
+	var stored model.TriageResult
+	require.NoError(t, gormDB.First(&stored, "id = ?", res.ID).Error)
+	assert.Equal(t, res.RelatedPostmortems, stored.RelatedPostmortems)
+}
+
+func verdict(severity string) ai.FakeReply {
+	return ai.FakeReply{Content: `{"severity": "` + severity + `", "summary": "Checkout is down.", "probable_cause": "Bad deploy.", "confidence_score": 0.9}`}
+}
+
+func TestRun_AutoDeclaresIncident(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	first := newAlert(t, gormDB, false)
+	require.NoError(t, gormDB.Model(&model.WebhookSource{}).Where("id = ?", first.SourceID).
+		Update("auto_declare_severity", model.SeveritySEV2).Error)
+	// A later alert with the same fingerprint, e.g. after the dedup window.
+	repeat := *first
+	repeat.ID, repeat.DedupKey = "", nil
+	require.NoError(t, gormDB.Create(&repeat).Error)
+	other := *first
+	other.ID, other.Fingerprint = "", "other"
+	require.NoError(t, gormDB.Create(&other).Error)
+
+	svc := newService(gormDB, ai.NewFake(verdict("SEV1"), verdict("SEV2"), verdict("SEV3")))
+	for _, a := range []*model.Alert{first, &repeat, &other} {
+		_, err := svc.Run(ctx, a.ID, 1, false)
+		require.NoError(t, err)
+	}
+
+	var incidents []model.Incident
+	require.NoError(t, gormDB.Find(&incidents).Error)
+	require.Len(t, incidents, 1, "the repeat attaches and SEV3 is below the threshold")
+	inc := incidents[0]
+	assert.Equal(t, model.IncidentStatusDeclared, inc.Status)
+	assert.Equal(t, model.SeveritySEV1, inc.Severity)
+	assert.Equal(t, first.Title, inc.Title)
+
+	for id, want := range map[string]*string{first.ID: &inc.ID, repeat.ID: &inc.ID, other.ID: nil} {
+		var a model.Alert
+		require.NoError(t, gormDB.First(&a, "id = ?", id).Error)
+		assert.Equal(t, want, a.IncidentID)
+	}
+
+	var entries []model.TimelineEntry
+	require.NoError(t, gormDB.Where("incident_id = ?", inc.ID).Find(&entries).Error)
+	kinds := make(map[string]int)
+	for _, e := range entries {
+		kinds[e.Kind]++
+	}
+	assert.Equal(t, map[string]int{
+		model.TimelineKindStatusChange: 1,
+		model.TimelineKindAlertLinked:  2,
+		model.TimelineKindAIHypothesis: 2,
+	}, kinds)
+
+	var hypothesis model.TimelineEntry
+	require.NoError(t, gormDB.First(&hypothesis, "incident_id = ? AND kind = ? AND details LIKE ?",
+		inc.ID, model.TimelineKindAIHypothesis, "%"+first.ID+"%").Error)
+	assert.Equal(t, "Checkout is down.", hypothesis.Body)
+	assert.Equal(t, "Bad deploy.", hypothesis.Details["probable_cause"])
+	assert.Equal(t, "0.90", hypothesis.Details["confidence_score"])
+}
+
+func TestRun_AutoDeclareAfterResolve(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	first := newAlert(t, gormDB, false)
+	require.NoError(t, gormDB.Model(&model.WebhookSource{}).Where("id = ?", first.SourceID).
+		Update("auto_declare_severity", model.SeveritySEV1).Error)
+	svc := newService(gormDB, ai.NewFake(verdict("SEV1"), verdict("SEV1")))
+	_, err := svc.Run(ctx, first.ID, 1, false)
+	require.NoError(t, err)
+
+	require.NoError(t, gormDB.First(first, "id = ?", first.ID).Error)
+	require.NotNil(t, first.IncidentID)
+	_, err = incident.NewService(gormDB).Transition(ctx, *first.IncidentID, model.IncidentStatusResolved, nil)
+	require.NoError(t, err)
+
+	next := *first
+	next.ID, next.DedupKey, next.IncidentID = "", nil, nil
+	require.NoError(t, gormDB.Create(&next).Error)
+	_, err = svc.Run(ctx, next.ID, 1, false)
+	require.NoError(t, err)
+
+	require.NoError(t, gormDB.First(&next, "id = ?", next.ID).Error)
+	require.NotNil(t, next.IncidentID)
+	assert.NotEqual(t, *first.IncidentID, *next.IncidentID, "a resolved incident does not absorb new alerts")
+}
@@ -0,0 +1,52 @@
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/d9705996/autopsy/internal/model"
+)
+
+// Verdict is the structured answer expected from the model.
+type Verdict struct {
+	Severity         string   `json:"severity"`
+	Summary          string   `json:"summary"`
+	ProbableCause    string   `json:"probable_cause"`
+	SuggestedActions []string `json:"suggested_actions"`
+	ConfidenceScore  float64  `json:"confidence_score"`
+}
+
+// errNoJSON is returned when the model answer contains no JSON object.
+var errNoJSON = errors.New("model response contains no JSON object")
+
+// parseVerdict extracts the JSON object from a model answer, tolerating
+// surrounding prose or Markdown code fences. An unknown severity is replaced
+// by SEV4 and reported via validSeverity=false; the confidence score is
+// clamped to [0, 1].
+func parseVerdict(content string) (v Verdict, validSeverity bool, err error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return Verdict{}, false, errNoJSON
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &v); err != nil {
+		return Verdict{}, false, fmt.Errorf("decode model response: %w", err)
+	}
+	if strings.TrimSpace(v.Summary) == "" {
+		return Verdict{}, false, errors.New("model response has no summary")
+	}
+
+	v.Severity = strings.ToUpper(strings.TrimSpace(v.Severity))
+	validSeverity = slices.Contains(model.Severities, v.Severity)
+	if !validSeverity {
+		v.Severity = model.SeveritySEV4
+	}
+	v.ConfidenceScore = min(max(v.ConfidenceScore, 0), 1)
+	if v.SuggestedActions == nil {
+		v.SuggestedActions = []string{}
+	}
+	return v, validSeverity, nil
+}
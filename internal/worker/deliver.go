@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/notify"
+	"github.com/riverqueue/river"
+)
+
+// DeliveryMaxAttempts bounds how often a notification is tried before the
+// delivery is marked as failed.
+const DeliveryMaxAttempts = 8
+
+// Delivery retries wait deliveryBaseBackoff, doubling with each further
+// retry up to deliveryMaxBackoff.
+const (
+	deliveryBaseBackoff = 5 * time.Second
+	deliveryMaxBackoff  = 5 * time.Minute
+)
+
+// DeliverArgs sends one page notification.
+type DeliverArgs struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+func (DeliverArgs) Kind() string { return "notification_delivery" }
+
+// InsertOpts implements river.JobArgsWithInsertOpts.
+func (DeliverArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{MaxAttempts: DeliveryMaxAttempts}
+}
+
+type deliveryWorker struct {
+	river.WorkerDefaults[DeliverArgs]
+	notify *notify.Service
+	log    *slog.Logger
+}
+
+func (w *deliveryWorker) Work(ctx context.Context, job *river.Job[DeliverArgs]) error {
+	err := w.notify.Deliver(ctx, job.Args.DeliveryID, job.Attempt, job.Attempt >= job.MaxAttempts)
+	if err == nil {
+		return nil
+	}
+	w.log.WarnContext(ctx, "notification delivery failed",
+		"delivery_id", job.Args.DeliveryID, "attempt", job.Attempt, "err", err)
+	if errors.Is(err, notify.ErrDeliveryNotFound) || !notify.Retryable(err) {
+		return river.JobCancel(err)
+	}
+	return err
+}
+
+// NextRetry backs off exponentially from 5s, capped at 5m: 5s, 10s, 20s,
+// ..., 5m, 5m.
+func (w *deliveryWorker) NextRetry(job *river.Job[DeliverArgs]) time.Time {
+	return time.Now().Add(deliveryBackoff(job.Attempt))
+}
+
+func deliveryBackoff(attempt int) time.Duration {
+	// Shifting past the cap's magnitude would overflow.
+	if attempt > 10 {
+		return deliveryMaxBackoff
+	}
+	return min(deliveryBaseBackoff<<max(0, attempt-1), deliveryMaxBackoff)
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/escalation"
+	"github.com/riverqueue/river"
+	"gorm.io/gorm"
+)
+
+// EscalateArgs moves a page on from Tier of Cycle when it comes due At,
+// unless it was acknowledged in the meantime.
+type EscalateArgs struct {
+	PageID string    `json:"page_id"`
+	Tier   int       `json:"tier"`
+	Cycle  int       `json:"cycle"`
+	At     time.Time `json:"at"`
+}
+
+// NewEscalateArgs returns the job that carries out step.
+func NewEscalateArgs(step *escalation.Step) EscalateArgs {
+	return EscalateArgs{PageID: step.PageID, Tier: step.Tier, Cycle: step.Cycle, At: step.At}
+}
+
+func (EscalateArgs) Kind() string { return "page_escalation" }
+
+// InsertOpts implements river.JobArgsWithInsertOpts. A step is only ever
+// scheduled once, so retries and stale jobs that reschedule a page's
+// pending step do not fork its escalation.
+func (a EscalateArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{ScheduledAt: a.At, UniqueOpts: river.UniqueOpts{ByArgs: true}}
+}
+
+type escalationWorker struct {
+	river.WorkerDefaults[EscalateArgs]
+	escalation *escalation.Service
+	log        *slog.Logger
+}
+
+// Schedule is an escalation.Scheduler that stages an outcome's deliveries
+// and next step in tx.
+func Schedule(tx *gorm.DB, out escalation.Outcome) error {
+	for _, id := range out.Deliveries {
+		if err := Stage(tx, DeliverArgs{DeliveryID: id}); err != nil {
+			return err
+		}
+	}
+	if out.Next == nil {
+		return nil
+	}
+	return Stage(tx, NewEscalateArgs(out.Next))
+}
+
+// Work escalates the page. The notifications it sends and its next step
+// are staged with the escalation, so they are queued if and only if it
+// commits.
+func (w *escalationWorker) Work(ctx context.Context, job *river.Job[EscalateArgs]) error {
+	step := escalation.Step{PageID: job.Args.PageID, Tier: job.Args.Tier, Cycle: job.Args.Cycle, At: job.Args.At}
+	out, err := w.escalation.Escalate(ctx, step, time.Now(), Schedule)
+	if errors.Is(err, escalation.ErrNotFound) {
+		return river.JobCancel(err)
+	}
+	if err != nil {
+		w.log.WarnContext(ctx, "page escalation failed",
+			"page_id", job.Args.PageID, "attempt", job.Attempt, "err", err)
+		return err
+	}
+	if next := out.Next; next != nil && next.Tier == job.Args.Tier && next.Cycle == job.Args.Cycle && next.At.Equal(job.Args.At) {
+		// Ran before the step came due, e.g. with clock skew; rescheduling
+		// it was a no-op since this job is still running.
+		return river.JobSnooze(time.Until(next.At))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/riverqueue/river"
+	"gorm.io/gorm"
+)
+
+// outboxChannel is the Postgres NOTIFY channel announcing staged jobs.
+const outboxChannel = "autopsy_jobs"
+
+const (
+	// relayInterval is how often the outbox is checked for jobs whose
+	// announcement was missed, and how often SQLite checks it at all.
+	relayInterval = 5 * time.Second
+	// relayBatch is how many staged jobs are moved at a time.
+	relayBatch = 100
+	// relayRetryDelay is the pause before the relay tries again after an
+	// error.
+	relayRetryDelay = 2 * time.Second
+)
+
+// Stage adds a job to the outbox. tx should be the transaction making the
+// change the job is for: the job is queued once tx commits, and never if it
+// rolls back.
+func Stage(tx *gorm.DB, args river.JobArgs) error {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("encode %s job: %w", args.Kind(), err)
+	}
+	if err := tx.Create(&model.OutboxJob{Kind: args.Kind(), Args: string(raw)}).Error; err != nil {
+		return fmt.Errorf("stage %s job: %w", args.Kind(), err)
+	}
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_notify(?, '')", outboxChannel).Error; err != nil {
+			return fmt.Errorf("announce %s job: %w", args.Kind(), err)
+		}
+	}
+	return nil
+}
+
+// decodeArgs rebuilds the arguments of a staged job, so the queue applies
+// their insert options.
+func decodeArgs(kind string, raw []byte) (river.JobArgs, error) {
+	switch kind {
+	case TriageArgs{}.Kind():
+		return decode[TriageArgs](raw)
+	case EscalateArgs{}.Kind():
+		return decode[EscalateArgs](raw)
+	case DeliverArgs{}.Kind():
+		return decode[DeliverArgs](raw)
+	case HealthCheckArgs{}.Kind():
+		return decode[HealthCheckArgs](raw)
+	}
+	return nil, fmt.Errorf("unknown job kind %q", kind)
+}
+
+func decode[T river.JobArgs](raw []byte) (river.JobArgs, error) {
+	var args T
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// relayJobs moves staged jobs onto the queue until ctx is done. It follows
+// NOTIFY on a dedicated connection to move jobs as soon as they commit,
+// and checks every relayInterval in case an announcement was missed.
+func (c *Client) relayJobs(ctx context.Context) {
+	for {
+		err := c.relayOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.log.Error("job relay failed; retrying", "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetryDelay):
+		}
+	}
+}
+
+func (c *Client) relayOnce(ctx context.Context) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	for {
+		if err := c.flush(ctx); err != nil {
+			return err
+		}
+		wait, cancel := context.WithTimeout(ctx, relayInterval)
+		_, err := conn.Conn().WaitForNotification(wait)
+		cancel()
+		if err != nil && (ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded)) {
+			return err
+		}
+	}
+}
+
+// flush moves every staged job onto the queue.
+func (c *Client) flush(ctx context.Context) error {
+	for {
+		n, err := c.flushBatch(ctx)
+		if err != nil {
+			return fmt.Errorf("relay staged jobs: %w", err)
+		}
+		if n < relayBatch {
+			return nil
+		}
+	}
+}
+
+// flushBatch inserts up to relayBatch staged jobs and deletes them from the
+// outbox in one transaction, and returns how many it took. Replicas relay
+// disjoint batches.
+func (c *Client) flushBatch(ctx context.Context) (int, error) {
+	var n int
+	err := pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			"SELECT id, kind, args FROM outbox_jobs ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED", relayBatch)
+		if err != nil {
+			return err
+		}
+		var (
+			ids    []int64
+			params []river.InsertManyParams
+			id     int64
+			kind   string
+			raw    string
+		)
+		_, err = pgx.ForEachRow(rows, []any{&id, &kind, &raw}, func() error {
+			ids = append(ids, id)
+			args, err := decodeArgs(kind, []byte(raw))
+			if err != nil {
+				// Keeping the job would block the outbox for good.
+				c.log.ErrorContext(ctx, "dropping staged job", "outbox_id", id, "kind", kind, "err", err)
+				return nil
+			}
+			params = append(params, river.InsertManyParams{Args: args})
+			return nil
+		})
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if len(params) > 0 {
+			if _, err := c.client.InsertManyTx(ctx, tx, params); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM outbox_jobs WHERE id = ANY($1)", ids); err != nil {
+			return err
+		}
+		n = len(ids)
+		return nil
+	})
+	return n, err
+}
+
+// drain drops staged jobs every relayInterval until ctx is done, since
+// there is no queue to move them to.
+func (n *noopQueue) drain(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+	for {
+		if err := n.dropStaged(ctx); err != nil && ctx.Err() == nil {
+			n.log.Error("drop staged jobs", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *noopQueue) dropStaged(ctx context.Context) error {
+	var staged []model.OutboxJob
+	if err := n.db.WithContext(ctx).Order("id").Find(&staged).Error; err != nil || len(staged) == 0 {
+		return err
+	}
+	ids := make([]int64, len(staged))
+	for i, job := range staged {
+		ids[i] = job.ID
+		n.drop(ctx, job.Kind)
+	}
+	return n.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.OutboxJob{}).Error
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/triage"
+	"github.com/riverqueue/river"
+	"gorm.io/gorm"
+)
+
+// TriageMaxAttempts bounds how often an alert triage job is tried before the
+// alert is marked as failed.
+const TriageMaxAttempts = 3
+
+// triageBaseBackoff is the delay before the first retry; each further retry
+// doubles it.
+const triageBaseBackoff = 10 * time.Second
+
+// TriageArgs asks for AI triage of a newly created alert.
+type TriageArgs struct {
+	AlertID string `json:"alert_id"`
+}
+
+func (TriageArgs) Kind() string { return "alert_triage" }
+
+// StageTriage is an ingest.TriageQueuer that stages triage of alertID in tx.
+func StageTriage(tx *gorm.DB, alertID string) error {
+	return Stage(tx, TriageArgs{AlertID: alertID})
+}
+
+// InsertOpts implements river.JobArgsWithInsertOpts.
+func (TriageArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{MaxAttempts: TriageMaxAttempts}
+}
+
+type triageWorker struct {
+	river.WorkerDefaults[TriageArgs]
+	triage *triage.Service
+	log    *slog.Logger
+}
+
+func (w *triageWorker) Work(ctx context.Context, job *river.Job[TriageArgs]) error {
+	_, err := w.triage.Run(ctx, job.Args.AlertID, job.Attempt, job.Attempt >= job.MaxAttempts)
+	if err == nil {
+		return nil
+	}
+	w.log.WarnContext(ctx, "alert triage failed",
+		"alert_id", job.Args.AlertID, "attempt", job.Attempt, "err", err)
+	if !triage.Retryable(err) {
+		return river.JobCancel(err)
+	}
+	return err
+}
+
+// NextRetry backs off exponentially: 10s, 20s, 40s, ...
+func (w *triageWorker) NextRetry(job *river.Job[TriageArgs]) time.Time {
+	return time.Now().Add(triageBackoff(job.Attempt))
+}
+
+func triageBackoff(attempt int) time.Duration {
+	return triageBaseBackoff << max(0, attempt-1)
+}
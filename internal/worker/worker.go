@@ -5,12 +5,20 @@ import (
 "context"
 "fmt"
 "log/slog"
+"strings"
 
+"github.com/d9705996/autopsy/internal/escalation"
+"github.com/d9705996/autopsy/internal/notify"
+"github.com/d9705996/autopsy/internal/triage"
 "github.com/jackc/pgx/v5"
 "github.com/jackc/pgx/v5/pgxpool"
 "github.com/riverqueue/river"
 "github.com/riverqueue/river/riverdriver/riverpgxv5"
 "github.com/riverqueue/river/rivermigrate"
+"go.opentelemetry.io/otel"
+"go.opentelemetry.io/otel/attribute"
+"go.opentelemetry.io/otel/metric"
+"gorm.io/gorm"
 )
 
 // HealthCheckArgs is a trivial job used to validate queue wiring.
@@ -29,40 +37,140 @@ return nil
 }
 
 // Queue is the interface exposed by both the real River client and noopQueue.
+// While started, a queue also takes the jobs staged with Stage.
 type Queue interface {
 Start(ctx context.Context) error
 Stop(ctx context.Context) error
+// Enqueue schedules a job. It returns once the job is stored, without
+// waiting for it to run. Jobs for a change being made should be staged
+// with Stage instead.
+Enqueue(ctx context.Context, args river.JobArgs) error
 }
 
-// Client wraps river.Client and exposes a Start/Stop lifecycle.
+// Services holds the application services job workers call into.
+type Services struct {
+Triage     *triage.Service
+Escalation *escalation.Service
+Notify     *notify.Service
+}
+
+// degradedFeatures lists the async features that do nothing without River.
+var degradedFeatures = []string{"ai triage", "page escalation", "page notifications"}
+
+// Client wraps river.Client and exposes a Start/Stop lifecycle. It relays
+// staged jobs onto the queue while started.
 type Client struct {
 client *river.Client[pgx.Tx]
+pool   *pgxpool.Pool
 log    *slog.Logger
+relay  *background
+}
+
+func (c *Client) Start(ctx context.Context) error {
+if err := c.client.Start(ctx); err != nil {
+return err
+}
+c.relay = startBackground(ctx, c.relayJobs)
+return nil
+}
+
+func (c *Client) Stop(ctx context.Context) error {
+c.relay.stop(ctx)
+return c.client.Stop(ctx)
 }
 
-func (c *Client) Start(ctx context.Context) error { return c.client.Start(ctx) }
-func (c *Client) Stop(ctx context.Context) error  { return c.client.Stop(ctx) }
+func (c *Client) Enqueue(ctx context.Context, args river.JobArgs) error {
+if _, err := c.client.Insert(ctx, args, nil); err != nil {
+return fmt.Errorf("enqueue %s job: %w", args.Kind(), err)
+}
+return nil
+}
 
 // noopQueue is used when River is unavailable (e.g. DB_DRIVER=sqlite).
-type noopQueue struct{ log *slog.Logger }
+// Enqueued and staged jobs are dropped with a warning and counted.
+type noopQueue struct {
+db      *gorm.DB
+log     *slog.Logger
+dropped metric.Int64Counter
+drainer *background
+}
+
+func newNoopQueue(db *gorm.DB, log *slog.Logger) *noopQueue {
+// Instrument creation only fails for invalid names; fall back to a no-op.
+dropped, _ := otel.Meter("github.com/d9705996/autopsy/internal/worker").
+Int64Counter("worker_jobs_dropped",
+metric.WithDescription("Jobs dropped because the worker queue is disabled (DB_DRIVER=sqlite)."))
+return &noopQueue{db: db, log: log, dropped: dropped}
+}
 
-func (n *noopQueue) Start(_ context.Context) error {
-n.log.Info("worker queue disabled (sqlite driver — River requires postgres)")
+func (n *noopQueue) Start(ctx context.Context) error {
+n.log.Warn("worker queue disabled (sqlite driver — River requires postgres)",
+"degraded_features", strings.Join(degradedFeatures, ", "))
+n.drainer = startBackground(ctx, n.drain)
 return nil
 }
-func (n *noopQueue) Stop(_ context.Context) error { return nil }
+
+func (n *noopQueue) Stop(ctx context.Context) error {
+n.drainer.stop(ctx)
+return nil
+}
+
+func (n *noopQueue) Enqueue(ctx context.Context, args river.JobArgs) error {
+n.drop(ctx, args.Kind())
+return nil
+}
+
+func (n *noopQueue) drop(ctx context.Context, kind string) {
+n.dropped.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", kind)))
+n.log.WarnContext(ctx, "job dropped: async features require DB_DRIVER=postgres", "kind", kind)
+}
+
+// background is a goroutine that runs until it is stopped.
+type background struct {
+cancel context.CancelFunc
+done   chan struct{}
+}
+
+// startBackground runs fn in a goroutine until ctx is done or the returned
+// background is stopped.
+func startBackground(ctx context.Context, fn func(context.Context)) *background {
+ctx, cancel := context.WithCancel(ctx)
+b := &background{cancel: cancel, done: make(chan struct{})}
+go func() {
+defer close(b.done)
+fn(ctx)
+}()
+return b
+}
+
+// stop ends b and waits for it, or for ctx. A nil b was never started.
+func (b *background) stop(ctx context.Context) {
+if b == nil {
+return
+}
+b.cancel()
+select {
+case <-b.done:
+case <-ctx.Done():
+}
+}
 
 // New creates a queue implementation appropriate for the given driver.
 //   - "postgres": returns a fully-functional River client backed by pool.
-//   - anything else: returns a no-op queue that logs a startup notice.
+//   - anything else: returns a no-op queue that logs a startup notice and
+//     drops enqueued and staged jobs.
 //
-// pool may be nil when driver != "postgres".
-func New(ctx context.Context, pool *pgxpool.Pool, driver string, concurrency int, log *slog.Logger) (Queue, error) {
+// db holds the outbox of staged jobs. pool may be nil when
+// driver != "postgres".
+func New(ctx context.Context, db *gorm.DB, pool *pgxpool.Pool, driver string, concurrency int, svc Services, log *slog.Logger) (Queue, error) {
 if driver != "postgres" {
-return &noopQueue{log: log}, nil
+return newNoopQueue(db, log), nil
 }
 workers := river.NewWorkers()
 river.AddWorker(workers, &healthCheckWorker{log: log})
+river.AddWorker(workers, &triageWorker{triage: svc.Triage, log: log})
+river.AddWorker(workers, &escalationWorker{escalation: svc.Escalation, log: log})
+river.AddWorker(workers, &deliveryWorker{notify: svc.Notify, log: log})
 
 client, err := river.NewClient(riverpgxv5.New(pool), &river.Config{
 Queues: map[string]river.QueueConfig{
@@ -74,7 +182,7 @@ Logger:  log,
 if err != nil {
 return nil, fmt.Errorf("create river client: %w", err)
 }
-return &Client{client: client, log: log}, nil
+return &Client{client: client, pool: pool, log: log}, nil
 }
 
 // MigrateRiver runs River's built-in schema migrations against the given pool.
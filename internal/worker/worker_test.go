@@ -0,0 +1,53 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/d9705996/autopsy/internal/db/dbtest"
+	"github.com/d9705996/autopsy/internal/model"
+	"github.com/d9705996/autopsy/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNew_SQLiteDropsJobs(t *testing.T) {
+	ctx := context.Background()
+	gormDB := dbtest.New(t)
+	q, err := worker.New(ctx, gormDB, nil, "sqlite", 1, worker.Services{}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	require.NoError(t, worker.Stage(gormDB, worker.TriageArgs{AlertID: "a1"}))
+
+	require.NoError(t, q.Start(ctx))
+	require.NoError(t, q.Enqueue(ctx, worker.TriageArgs{AlertID: "a2"}))
+	require.Eventually(t, func() bool {
+		var n int64
+		require.NoError(t, gormDB.Model(&model.OutboxJob{}).Count(&n).Error)
+		return n == 0
+	}, 5*time.Second, 10*time.Millisecond, "staged jobs are dropped")
+	require.NoError(t, q.Stop(ctx))
+}
+
+func TestStage_CommitsWithTransaction(t *testing.T) {
+	gormDB := dbtest.New(t)
+	errRollback := errors.New("rollback")
+
+	err := gormDB.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, worker.Stage(tx, worker.DeliverArgs{DeliveryID: "d1"}))
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+	require.NoError(t, gormDB.Transaction(func(tx *gorm.DB) error {
+		return worker.Stage(tx, worker.DeliverArgs{DeliveryID: "d2"})
+	}))
+
+	var staged []model.OutboxJob
+	require.NoError(t, gormDB.Find(&staged).Error)
+	require.Len(t, staged, 1, "rolled-back jobs are never staged")
+	assert.Equal(t, "notification_delivery", staged[0].Kind)
+	assert.JSONEq(t, `{"delivery_id":"d2"}`, staged[0].Args)
+}